@@ -1,9 +1,22 @@
 package marrow
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"github.com/go-andiamo/marrow/internal/jwks"
+	"github.com/go-andiamo/marrow/with"
 	"github.com/golang-jwt/jwt/v5"
+	"sync"
 	"time"
 )
 
@@ -135,6 +148,116 @@ func JwtHS512(secret any, claims ...ClaimValue) Resolvable {
 	}
 }
 
+// JwtRS256 creates an RS256-signed JWT with the given key and claims
+//
+// the key can be:
+//   - an *rsa.PrivateKey
+//   - a PEM encoded private key (string or []byte)
+//   - a JwtKey (to also specify the "kid" header)
+//   - a supporting OIDC issuer (see OIDCIssuerKey and with.OIDCIssuer) - the "kid" header and "iss" claim (if not specified) are set from the issuer
+//   - nil - a generated key is used (see GeneratedJwks for configuring the API under test to verify these tokens)
+//
+// this returns a resolvable value that can be used with Auth - which can then be used by Method_.AuthHeader
+// example:
+//
+//	Method(GET, "do get").
+//	    AuthHeader(BearerAuth, JwtRS256(
+//	        OIDCIssuerKey("oidc"),
+//	        SubjectClaim(Var("my-user")),
+//	        ExpireAfterClaim(5 * time.Minute))
+func JwtRS256(key any, claims ...ClaimValue) Resolvable {
+	return jwtValue{
+		signingMethod: jwt.SigningMethodRS256,
+		secret:        key,
+		claims:        claims,
+	}
+}
+
+// JwtES256 creates an ES256-signed JWT with the given key and claims
+//
+// the key can be:
+//   - an *ecdsa.PrivateKey (P-256)
+//   - a PEM encoded private key (string or []byte)
+//   - a JwtKey (to also specify the "kid" header)
+//   - a supporting OIDC issuer (see OIDCIssuerKey and with.OIDCIssuer) - the "kid" header and "iss" claim (if not specified) are set from the issuer
+//   - nil - a generated key is used (see GeneratedJwks for configuring the API under test to verify these tokens)
+//
+// this returns a resolvable value that can be used with Auth - which can then be used by Method_.AuthHeader
+// example:
+//
+//	Method(GET, "do get").
+//	    AuthHeader(BearerAuth, JwtES256(
+//	        OIDCIssuerKey("oidc"),
+//	        SubjectClaim(Var("my-user")),
+//	        ExpireAfterClaim(5 * time.Minute))
+func JwtES256(key any, claims ...ClaimValue) Resolvable {
+	return jwtValue{
+		signingMethod: jwt.SigningMethodES256,
+		secret:        key,
+		claims:        claims,
+	}
+}
+
+// JwtEdDSA creates an EdDSA (Ed25519) signed JWT with the given key and claims
+//
+// the key can be:
+//   - an ed25519.PrivateKey
+//   - a PEM encoded private key (string or []byte)
+//   - a JwtKey (to also specify the "kid" header)
+//   - a supporting OIDC issuer (see OIDCIssuerKey and with.OIDCIssuer) - the "kid" header and "iss" claim (if not specified) are set from the issuer
+//   - nil - a generated key is used (see GeneratedJwks for configuring the API under test to verify these tokens)
+//
+// this returns a resolvable value that can be used with Auth - which can then be used by Method_.AuthHeader
+// example:
+//
+//	Method(GET, "do get").
+//	    AuthHeader(BearerAuth, JwtEdDSA(
+//	        OIDCIssuerKey("oidc"),
+//	        SubjectClaim(Var("my-user")),
+//	        ExpireAfterClaim(5 * time.Minute))
+func JwtEdDSA(key any, claims ...ClaimValue) Resolvable {
+	return jwtValue{
+		signingMethod: jwt.SigningMethodEdDSA,
+		secret:        key,
+		claims:        claims,
+	}
+}
+
+// JwtKey is a key used for asymmetric signing of JWTs (see JwtRS256, JwtES256 and JwtEdDSA)
+type JwtKey struct {
+	// Kid is the key id - set as the "kid" header of the JWT (if not empty)
+	Kid string
+	// Key is the private key - can be a crypto.Signer (e.g. *rsa.PrivateKey) or a PEM encoded private key (string or []byte)
+	Key any
+}
+
+// OIDCIssuerKey returns a resolvable that resolves to the named supporting OIDC issuer - for use as the key
+// with JwtRS256, JwtES256 or JwtEdDSA
+//
+// see with.OIDCIssuer
+func OIDCIssuerKey(issuerName string) Resolvable {
+	return &oidcIssuerKey{
+		name: issuerName,
+	}
+}
+
+type oidcIssuerKey struct {
+	name string
+}
+
+func (k *oidcIssuerKey) ResolveValue(ctx Context) (av any, err error) {
+	if img, ok := ctx.GetImage(k.name).(with.ImageJwtIssuer); ok {
+		av = img
+	} else {
+		err = fmt.Errorf("oidc issuer %q not found", k.name)
+	}
+	return av, err
+}
+
+func (k *oidcIssuerKey) String() string {
+	return fmt.Sprintf("OIDCIssuerKey(%q)", k.name)
+}
+
 func (j jwtValue) ResolveValue(ctx Context) (av any, err error) {
 	var sv any
 	if sv, err = ResolveValue(j.secret, ctx); err == nil {
@@ -149,19 +272,154 @@ func (j jwtValue) ResolveValue(ctx Context) (av any, err error) {
 		}
 		if err == nil {
 			token := jwt.NewWithClaims(j.signingMethod, claims)
-			switch svt := sv.(type) {
-			case []byte:
-				av, err = token.SignedString(svt)
-			case string:
-				av, err = token.SignedString([]byte(svt))
-			default:
-				av, err = token.SignedString([]byte(fmt.Sprintf("%v", sv)))
+			if _, ok := j.signingMethod.(*jwt.SigningMethodHMAC); ok {
+				switch svt := sv.(type) {
+				case []byte:
+					av, err = token.SignedString(svt)
+				case string:
+					av, err = token.SignedString([]byte(svt))
+				default:
+					av, err = token.SignedString([]byte(fmt.Sprintf("%v", sv)))
+				}
+			} else {
+				av, err = j.signAsymmetric(token, claims, sv, ctx)
 			}
 		}
 	}
 	return av, err
 }
 
+func (j jwtValue) signAsymmetric(token *jwt.Token, claims jwt.MapClaims, key any, ctx Context) (av any, err error) {
+	alg := j.signingMethod.Alg()
+	var kid string
+	var signer crypto.Signer
+	switch kt := key.(type) {
+	case with.ImageJwtIssuer:
+		var ok bool
+		if kid, signer, ok = kt.SigningKey(alg); !ok {
+			return nil, fmt.Errorf("oidc issuer %q has no %s key", kt.Name(), alg)
+		}
+		if _, ok = claims["iss"]; !ok {
+			claims["iss"] = kt.Issuer()
+		}
+	case JwtKey:
+		kid = kt.Kid
+		var kv any
+		if kv, err = ResolveValue(kt.Key, ctx); err == nil {
+			signer, err = parseJwtSigningKey(kv)
+		}
+	case nil:
+		var gk *generatedKey
+		if gk, err = generatedJwtKey(alg); err == nil {
+			kid, signer = gk.kid, gk.signer
+		}
+	default:
+		signer, err = parseJwtSigningKey(key)
+	}
+	if err == nil {
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		av, err = token.SignedString(signer)
+	}
+	return av, err
+}
+
+func parseJwtSigningKey(key any) (signer crypto.Signer, err error) {
+	var data []byte
+	switch kt := key.(type) {
+	case crypto.Signer:
+		return kt, nil
+	case string:
+		data = []byte(kt)
+	case []byte:
+		data = kt
+	default:
+		return nil, fmt.Errorf("cannot use %T as jwt signing key", key)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid pem encoded jwt signing key")
+	}
+	var pk any
+	if pk, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		if pk, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			pk, err = x509.ParseECPrivateKey(block.Bytes)
+		}
+	}
+	if err == nil {
+		var ok bool
+		if signer, ok = pk.(crypto.Signer); !ok {
+			err = fmt.Errorf("cannot use %T as jwt signing key", pk)
+		}
+	} else {
+		err = fmt.Errorf("invalid pem encoded jwt signing key: %w", err)
+	}
+	return signer, err
+}
+
+type generatedKey struct {
+	kid    string
+	signer crypto.Signer
+	jwk    map[string]any
+}
+
+var (
+	generatedJwtKeys   = map[string]*generatedKey{}
+	generatedJwtKeysMu sync.Mutex
+)
+
+// generatedJwtAlgs are the algorithms for which keys are generated (see JwtRS256, JwtES256 and JwtEdDSA with a nil key)
+var generatedJwtAlgs = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+
+// GeneratedJwks returns the JWKS (JSON) of the public keys used by JwtRS256, JwtES256 and JwtEdDSA when the key is nil
+//
+// the keys are generated once (per test process) - the JWKS can be used to configure the API under test to verify
+// tokens signed with the generated keys (e.g. as an env var for an API image, or written to a file served by a mock service)
+//
+// Note: where the API under test only accepts a JWKS url, use a supporting OIDC issuer instead (see OIDCIssuerKey and with.OIDCIssuer)
+func GeneratedJwks() (string, error) {
+	keys := make([]map[string]any, 0, len(generatedJwtAlgs))
+	for _, alg := range generatedJwtAlgs {
+		gk, err := generatedJwtKey(alg)
+		if err != nil {
+			return "", err
+		}
+		keys = append(keys, gk.jwk)
+	}
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	return string(data), err
+}
+
+// generatedJwtKey returns a generated key for the algorithm (keys are generated once and re-used)
+func generatedJwtKey(alg string) (gk *generatedKey, err error) {
+	generatedJwtKeysMu.Lock()
+	defer generatedJwtKeysMu.Unlock()
+	var ok bool
+	if gk, ok = generatedJwtKeys[alg]; !ok {
+		var signer crypto.Signer
+		switch alg {
+		case jwt.SigningMethodRS256.Alg():
+			signer, err = rsa.GenerateKey(rand.Reader, 2048)
+		case jwt.SigningMethodES256.Alg():
+			signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		default:
+			_, signer, err = ed25519.GenerateKey(rand.Reader)
+		}
+		var jwk map[string]any
+		if err == nil {
+			if _, jwk, err = jwks.PublicJwk(signer); err == nil {
+				gk = &generatedKey{kid: jwks.Thumbprint(jwk), signer: signer, jwk: jwk}
+				jwk["kid"] = gk.kid
+				jwk["alg"] = alg
+				jwk["use"] = "sig"
+				generatedJwtKeys[alg] = gk
+			}
+		}
+	}
+	return gk, err
+}
+
 type ClaimValue interface {
 	Name() string
 	Value() any
@@ -0,0 +1,94 @@
+package marrow
+
+import (
+	"fmt"
+	"github.com/go-andiamo/marrow/framing"
+	"maps"
+	"net/http"
+)
+
+// AuthCase is a case used by Method_.AuthMatrix
+//
+// example:
+//
+//	Method(GET, "get foo").
+//	    AuthMatrix(
+//	        AuthCase{Name: "none", Status: http.StatusUnauthorized},
+//	        AuthCase{Name: "expired", Auth: Jwt(Var("secret"), ExpireAtClaim(time.Now().Add(-time.Hour))), Status: http.StatusUnauthorized},
+//	        AuthCase{Name: "wrong role", Auth: Jwt(Var("secret"), Claim("role", "guest")), Status: http.StatusForbidden},
+//	        AuthCase{Name: "valid", Auth: Jwt(Var("secret"), Claim("role", "admin")), Status: http.StatusOK},
+//	    )
+type AuthCase struct {
+	// Name is the name of the case (used as the sub-run name) - if empty, the name is "AuthCase[n]"
+	Name string
+	// Scheme is the "Authorization" header scheme (defaults to BearerAuth)
+	Scheme AuthScheme
+	// Auth is the "Authorization" header value (e.g. Jwt) - if nil, the request is made without an "Authorization" header
+	Auth any
+	// Status is the expected response status code
+	Status int
+}
+
+type authCase struct {
+	AuthCase
+	frame *framing.Frame
+}
+
+func (ac *authCase) name(index int) string {
+	if ac.Name != "" {
+		return ac.Name
+	}
+	return fmt.Sprintf("AuthCase[%d]", index+1)
+}
+
+// runAuthMatrix runs each auth case as a sub-run (each reported individually to coverage)
+func (m *method) runAuthMatrix(ctx Context) bool {
+	for i, ac := range m.authMatrix {
+		name := ac.name(i)
+		ok := ctx.run(name, m.authCaseMethod(name, ac))
+		ctx.setCurrentMethod(m)
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// authCaseMethod derives the method used for an auth case - the derived method has the same request (except for the
+// "Authorization" header) but has no befores, afters, authorize functions or expectations other than the expected status
+func (m *method) authCaseMethod(name string, ac *authCase) *method {
+	headers := maps.Clone(m.headers)
+	for k := range headers {
+		if http.CanonicalHeaderKey(k) == "Authorization" {
+			delete(headers, k)
+		}
+	}
+	if ac.Auth != nil {
+		scheme := ac.Scheme
+		if scheme == "" {
+			scheme = BearerAuth
+		}
+		headers["Authorization"] = AuthValue{Scheme: scheme, Value: ac.Auth}
+	}
+	// shallow copy (so that any request related fields are carried over) - then reset everything that is not used by the auth case...
+	cpy := *m
+	result := &cpy
+	result.desc = m.desc + " (auth case: " + name + ")"
+	result.frame = ac.frame
+	result.headers = headers
+	result.skips = nil
+	result.preCaptures = nil
+	result.authFns = nil
+	result.authMatrix = nil
+	result.postOps = nil
+	result.postCaptures = nil
+	result.expectations = nil
+	result.failFast = false
+	result.authCase = true
+	result.addPostExpectation(&expectStatusCode{
+		name:   "Expect Status Code",
+		expect: ac.Status,
+		frame:  ac.frame,
+	})
+	return result
+}
@@ -0,0 +1,130 @@
+package marrow
+
+import (
+	"github.com/go-andiamo/marrow/coverage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMethod_AuthMatrix(t *testing.T) {
+	ctx := newTestContext(map[Var]any{"id": 123})
+	ctx.currEndpoint = Endpoint("/foos/{id}", "")
+	cov := coverage.NewCoverage()
+	ctx.coverage = cov
+	auths := make([]string, 0)
+	ctx.httpDo = doFunc(func(req *http.Request) (*http.Response, error) {
+		auth := req.Header.Get("Authorization")
+		auths = append(auths, auth)
+		assert.Equal(t, "/foos/123", req.URL.Path)
+		status := http.StatusOK
+		switch {
+		case auth == "":
+			status = http.StatusUnauthorized
+		case strings.HasSuffix(auth, "guest"):
+			status = http.StatusForbidden
+		}
+		return (&dummyDo{status: status, body: []byte(`{}`)}).Do(req)
+	})
+	authFnCalls := 0
+	m := Method(GET, "get foo").
+		PathParam(Var("id")).
+		AuthHeader(BearerAuth, "admin").
+		Authorize(func(ctx Context) error {
+			authFnCalls++
+			return nil
+		}).
+		AuthMatrix(
+			AuthCase{Name: "none", Status: http.StatusUnauthorized},
+			AuthCase{Name: "guest", Auth: "guest", Status: http.StatusForbidden},
+			AuthCase{Auth: "admin", Scheme: TokenAuth, Status: http.StatusOK},
+			AuthCase{Name: "wrong", Auth: "guest", Status: http.StatusOK},
+		).
+		AssertOK()
+	err := m.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "Bearer guest", "Token admin", "Bearer guest", "Bearer admin"}, auths)
+	assert.Equal(t, 1, authFnCalls)
+	assert.Len(t, cov.Timings, 5)
+	require.Len(t, cov.Met, 4)
+	assert.Equal(t, "get foo (auth case: none)", cov.Met[0].Method.Description())
+	assert.Equal(t, "get foo (auth case: guest)", cov.Met[1].Method.Description())
+	assert.Equal(t, "get foo (auth case: AuthCase[3])", cov.Met[2].Method.Description())
+	assert.Equal(t, "get foo", cov.Met[3].Method.Description())
+	require.Len(t, cov.Unmet, 1)
+	assert.Equal(t, "get foo (auth case: wrong)", cov.Unmet[0].Method.Description())
+	assert.NotNil(t, cov.Unmet[0].Expectation.Frame())
+
+	raw := m.(*method)
+	assert.Len(t, raw.authMatrix, 4)
+	assert.Len(t, raw.expectations, 1)
+}
+
+func TestMethod_AuthMatrix_Failure(t *testing.T) {
+	ctx := newTestContext(nil)
+	ctx.currEndpoint = Endpoint("/foos", "")
+	ctx.coverage = coverage.NewCoverage()
+	calls := 0
+	ctx.httpDo = doFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return nil, assert.AnError
+	})
+	m := Method(GET, "").
+		AuthMatrix(AuthCase{Status: http.StatusUnauthorized}, AuthCase{Status: http.StatusUnauthorized})
+	err := m.Run(ctx)
+	require.NoError(t, err)
+	assert.True(t, ctx.failed)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMethod_AuthCaseMethod(t *testing.T) {
+	m := Method(POST, "foo").
+		PathParam("id").
+		QueryParam("q", "x").
+		RequestHeader("X-Foo", "foo").
+		RequestHeader("Authorization", "Bearer original").
+		RequestBody(JSON{"foo": "bar"}).
+		RequestCompression("gzip").
+		Timeout(time.Second).
+		SetVar(Before, "foo", "bar").
+		AuthMatrix(AuthCase{Name: "anon", Status: http.StatusUnauthorized}).
+		AssertOK().(*method)
+	ac := m.authMatrix[0]
+	cm := m.authCaseMethod("anon", ac)
+	assert.Equal(t, "foo (auth case: anon)", cm.desc)
+	assert.Equal(t, ac.frame, cm.frame)
+	assert.Equal(t, map[string]any{"X-Foo": "foo"}, cm.headers)
+	assert.Contains(t, m.headers, "Authorization")
+	assert.Empty(t, cm.preCaptures)
+	assert.Empty(t, cm.authMatrix)
+	require.Len(t, cm.expectations, 1)
+	require.Len(t, cm.postOps, 1)
+	assert.Len(t, m.expectations, 1)
+
+	// every other (request related) field is carried over...
+	expect := *m
+	expect.desc, expect.frame, expect.headers = cm.desc, cm.frame, cm.headers
+	expect.skips, expect.preCaptures, expect.authFns, expect.authMatrix = nil, nil, nil, nil
+	expect.postOps, expect.postCaptures, expect.expectations, expect.failFast = cm.postOps, nil, cm.expectations, false
+	expect.authCase = true
+	assert.Equal(t, expect, *cm)
+}
+
+func TestMethod_AuthCaseMethod_HeaderCase(t *testing.T) {
+	m := Method(GET, "foo").
+		RequestHeader("authorization", "Bearer original").
+		RequestHeader("AUTHORIZATION", "Bearer other").
+		RequestHeader("X-Foo", "foo").
+		AuthMatrix(
+			AuthCase{Name: "none", Status: http.StatusUnauthorized},
+			AuthCase{Name: "forbidden", Auth: "forbidden", Status: http.StatusForbidden},
+		).(*method)
+	cm := m.authCaseMethod("none", m.authMatrix[0])
+	assert.Equal(t, map[string]any{"X-Foo": "foo"}, cm.headers)
+	cm = m.authCaseMethod("forbidden", m.authMatrix[1])
+	assert.Equal(t, map[string]any{"X-Foo": "foo", "Authorization": AuthValue{Scheme: BearerAuth, Value: "forbidden"}}, cm.headers)
+	assert.Len(t, m.headers, 3)
+}
@@ -1,7 +1,17 @@
 package marrow
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"github.com/go-andiamo/marrow/internal/jwks"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -167,3 +177,164 @@ func TestJwtHS512(t *testing.T) {
 	assert.Equal(t, "my-audience", claims["aud"])
 	assert.Equal(t, "bar", claims["foo"])
 }
+
+func TestJwtAsymmetric(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+	ecPem := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+	rsaPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	issuer := &mockJwtIssuerImage{keys: map[string]crypto.Signer{"RS256": rsaKey, "ES256": ecKey, "EdDSA": edKey}}
+
+	testCases := []struct {
+		name      string
+		value     Resolvable
+		publicKey any
+		expectKid string
+		expectIss string
+		expectErr string
+	}{
+		{
+			name:      "RS256 key",
+			value:     JwtRS256(rsaKey, SubjectClaim("my-user")),
+			publicKey: rsaKey.Public(),
+		},
+		{
+			name:      "RS256 pem",
+			value:     JwtRS256(Var("pem"), SubjectClaim("my-user")),
+			publicKey: rsaKey.Public(),
+		},
+		{
+			name:      "RS256 issuer",
+			value:     JwtRS256(OIDCIssuerKey("mock"), SubjectClaim("my-user")),
+			publicKey: rsaKey.Public(),
+			expectKid: "kid-RS256",
+			expectIss: "http://localhost:8080",
+		},
+		{
+			name:      "ES256 pem",
+			value:     JwtES256(ecPem, SubjectClaim("my-user")),
+			publicKey: ecKey.Public(),
+		},
+		{
+			name:      "ES256 JwtKey",
+			value:     JwtES256(JwtKey{Kid: "my-kid", Key: ecKey}, SubjectClaim("my-user")),
+			publicKey: ecKey.Public(),
+			expectKid: "my-kid",
+		},
+		{
+			name:      "ES256 issuer",
+			value:     JwtES256(OIDCIssuerKey("mock"), SubjectClaim("my-user"), IssuerClaim("other")),
+			publicKey: ecKey.Public(),
+			expectKid: "kid-ES256",
+			expectIss: "other",
+		},
+		{
+			name:      "EdDSA key",
+			value:     JwtEdDSA(edKey, SubjectClaim("my-user")),
+			publicKey: edKey.Public(),
+		},
+		{
+			name:      "EdDSA issuer",
+			value:     JwtEdDSA(OIDCIssuerKey("mock"), SubjectClaim("my-user")),
+			publicKey: edKey.Public(),
+			expectKid: "kid-EdDSA",
+			expectIss: "http://localhost:8080",
+		},
+		{
+			name:      "wrong key type",
+			value:     JwtRS256(ecKey),
+			expectErr: "key is of invalid type",
+		},
+		{
+			name:      "unsupported key type",
+			value:     JwtRS256(42),
+			expectErr: "cannot use int as jwt signing key",
+		},
+		{
+			name:      "invalid pem",
+			value:     JwtRS256("not a pem"),
+			expectErr: "invalid pem encoded jwt signing key",
+		},
+		{
+			name:      "unknown issuer",
+			value:     JwtRS256(OIDCIssuerKey("unknown")),
+			expectErr: `oidc issuer "unknown" not found`,
+		},
+		{
+			name:      "issuer missing alg",
+			value:     JwtRS256(OIDCIssuerKey("mock2")),
+			expectErr: `oidc issuer "mock" has no RS256 key`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := newTestContext(map[Var]any{"pem": rsaPem})
+			ctx.images["mock"] = issuer
+			ctx.images["mock2"] = &mockJwtIssuerImage{}
+			v, err := tc.value.ResolveValue(ctx)
+			if tc.expectErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			token, err := jwt.Parse(v.(string), func(token *jwt.Token) (interface{}, error) {
+				return tc.publicKey, nil
+			})
+			require.NoError(t, err)
+			claims := token.Claims.(jwt.MapClaims)
+			assert.Equal(t, "my-user", claims["sub"])
+			if tc.expectKid != "" {
+				assert.Equal(t, tc.expectKid, token.Header["kid"])
+			} else {
+				assert.NotContains(t, token.Header, "kid")
+			}
+			if tc.expectIss != "" {
+				assert.Equal(t, tc.expectIss, claims["iss"])
+			} else {
+				assert.NotContains(t, claims, "iss")
+			}
+		})
+	}
+	assert.Equal(t, `OIDCIssuerKey("mock")`, fmt.Sprintf("%s", OIDCIssuerKey("mock")))
+}
+
+func TestGeneratedJwks(t *testing.T) {
+	data, err := GeneratedJwks()
+	require.NoError(t, err)
+	set := struct {
+		Keys []map[string]any `json:"keys"`
+	}{}
+	require.NoError(t, json.Unmarshal([]byte(data), &set))
+	require.Len(t, set.Keys, 3)
+	ctx := newTestContext(nil)
+	for _, jv := range []Resolvable{JwtRS256(nil, SubjectClaim("my-user")), JwtES256(nil, SubjectClaim("my-user")), JwtEdDSA(nil, SubjectClaim("my-user"))} {
+		v, err := jv.ResolveValue(ctx)
+		require.NoError(t, err)
+		token, err := jwt.Parse(v.(string), func(token *jwt.Token) (interface{}, error) {
+			// the token must be verifiable by the key (with matching kid) published in the generated JWKS...
+			for _, jwk := range set.Keys {
+				if jwk["kid"] == token.Header["kid"] {
+					assert.Equal(t, token.Method.Alg(), jwk["alg"])
+					gk, err := generatedJwtKey(token.Method.Alg())
+					require.NoError(t, err)
+					_, expect, err := jwks.PublicJwk(gk.signer)
+					require.NoError(t, err)
+					for k, ev := range expect {
+						assert.Equal(t, ev, jwk[k])
+					}
+					return gk.signer.Public(), nil
+				}
+			}
+			return nil, fmt.Errorf("kid %v not in jwks", token.Header["kid"])
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "my-user", token.Claims.(jwt.MapClaims)["sub"])
+	}
+}
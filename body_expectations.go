@@ -0,0 +1,117 @@
+package marrow
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-andiamo/marrow/framing"
+	"strings"
+)
+
+type expectBodyBytes struct {
+	expected any
+	frame    *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*expectBodyBytes)(nil)
+
+// ExpectBodyBytes asserts that the raw (undecoded) response body is exactly the expected bytes
+//
+// the expected value (or resolved value) can be []byte or string - e.g. for asserting file downloads
+//
+//go:noinline
+func ExpectBodyBytes(expected any) Expectation {
+	return &expectBodyBytes{
+		expected: expected,
+		frame:    framing.NewFrame(0),
+	}
+}
+
+func (e *expectBodyBytes) Name() string {
+	return "Expect Body Bytes"
+}
+
+func (e *expectBodyBytes) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *expectBodyBytes) Met(ctx Context) (unmet error, err error) {
+	var ev any
+	if ev, err = ResolveValue(e.expected, ctx); err == nil {
+		var expected []byte
+		switch evt := ev.(type) {
+		case []byte:
+			expected = evt
+		case string:
+			expected = []byte(evt)
+		case nil:
+		default:
+			return nil, fmt.Errorf("cannot compare body bytes with %T", ev)
+		}
+		if actual := ctx.CurrentRawBody(); !bytes.Equal(expected, actual) {
+			offset := 0
+			for offset < len(expected) && offset < len(actual) && expected[offset] == actual[offset] {
+				offset++
+			}
+			unmet = &unmetError{
+				msg:      fmt.Sprintf("expected body bytes - differ at offset %d (expected %d bytes, actual %d bytes)", offset, len(expected), len(actual)),
+				name:     e.Name(),
+				expected: OperandValue{Original: e.expected, Resolved: ev},
+				actual:   OperandValue{Original: RawBody, Resolved: actual},
+				frame:    e.frame,
+			}
+		}
+	}
+	return
+}
+
+type expectBodyHash struct {
+	hash  any
+	frame *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*expectBodyHash)(nil)
+
+// ExpectBodyHash asserts that the SHA-256 hash of the raw (undecoded) response body is the expected hex encoded hash
+//
+// the expected hash can be a string or resolvable (e.g. Var) - the comparison is case-insensitive
+//
+//go:noinline
+func ExpectBodyHash(sha256 any) Expectation {
+	return &expectBodyHash{
+		hash:  sha256,
+		frame: framing.NewFrame(0),
+	}
+}
+
+func (e *expectBodyHash) Name() string {
+	return "Expect Body Hash"
+}
+
+func (e *expectBodyHash) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *expectBodyHash) Met(ctx Context) (unmet error, err error) {
+	var ev any
+	if ev, err = ResolveValue(e.hash, ctx); err == nil {
+		expected, ok := ev.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected body hash must be a string, not %T", ev)
+		}
+		sum := sha256.Sum256(ctx.CurrentRawBody())
+		if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(strings.TrimSpace(expected), actual) {
+			unmet = &unmetError{
+				msg:      "expected body hash",
+				name:     e.Name(),
+				expected: OperandValue{Original: e.hash, Resolved: expected},
+				actual:   OperandValue{Original: RawBody, Resolved: actual},
+				frame:    e.frame,
+			}
+		}
+	}
+	return
+}
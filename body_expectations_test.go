@@ -0,0 +1,154 @@
+package marrow
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/go-andiamo/marrow/coverage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestExpectBodyBytes(t *testing.T) {
+	ctx := newTestContext(map[Var]any{"file": []byte("hello world")})
+	ctx.currRawBody = []byte("hello world")
+	exp := ExpectBodyBytes(Var("file"))
+	assert.Equal(t, "Expect Body Bytes", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	assert.False(t, exp.IsRequired())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectBodyBytes("hello world!").Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected body bytes - differ at offset 11 (expected 12 bytes, actual 11 bytes)", unmet.Error())
+
+	unmet, err = ExpectBodyBytes("hello there").Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected body bytes - differ at offset 6 (expected 11 bytes, actual 11 bytes)", unmet.Error())
+
+	unmet, err = ExpectBodyBytes(nil).Met(newTestContext(nil))
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	_, err = ExpectBodyBytes(42).Met(ctx)
+	require.Error(t, err)
+	_, err = ExpectBodyBytes(Var("missing")).Met(ctx)
+	require.Error(t, err)
+}
+
+func TestExpectBodyHash(t *testing.T) {
+	ctx := newTestContext(nil)
+	ctx.currRawBody = []byte("hello world")
+	exp := ExpectBodyHash("B94D27B9934D3E08A52E52D7DA7DABFAC484EFE37A5380EE9088F7ACE2EFCDE9")
+	assert.Equal(t, "Expect Body Hash", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectBodyHash("0000").Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected body hash", unmet.Error())
+	assert.Equal(t, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", unmet.(UnmetError).Actual().Resolved)
+
+	_, err = ExpectBodyHash(42).Met(ctx)
+	require.Error(t, err)
+	_, err = ExpectBodyHash(Var("missing")).Met(ctx)
+	require.Error(t, err)
+}
+
+func TestMethod_Run_RawBody(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write([]byte(`{"foo":"bar"}`))
+	_ = gw.Close()
+	var df bytes.Buffer
+	zw := zlib.NewWriter(&df)
+	_, _ = zw.Write([]byte(`{"foo":"bar"}`))
+	_ = zw.Close()
+	testCases := []struct {
+		encoding string
+		body     []byte
+	}{
+		{"", []byte(`{"foo":"bar"}`)},
+		{"gzip", gz.Bytes()},
+		{"deflate", df.Bytes()},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.encoding, func(t *testing.T) {
+			ctx := newTestContext(nil)
+			hdrs := map[string]string{}
+			if tc.encoding != "" {
+				hdrs["Content-Encoding"] = tc.encoding
+			}
+			ctx.httpDo = &dummyDo{status: http.StatusOK, body: tc.body, hdrs: hdrs}
+			ctx.currEndpoint = Endpoint("/foos", "")
+			err := Method(GET, "").Run(ctx)
+			require.NoError(t, err)
+			require.False(t, ctx.failed)
+			assert.Equal(t, map[string]any{"foo": "bar"}, ctx.currBody)
+			assert.Equal(t, `{"foo":"bar"}`, string(ctx.currRawBody))
+		})
+	}
+	t.Run("response unmarshal gets wire body", func(t *testing.T) {
+		ctx := newTestContext(nil)
+		ctx.httpDo = &dummyDo{status: http.StatusOK, body: gz.Bytes(), hdrs: map[string]string{"Content-Encoding": "gzip"}}
+		ctx.currEndpoint = Endpoint("/foos", "")
+		err := Method(GET, "").ResponseUnmarshal(func(response *http.Response) (any, error) {
+			data, err := io.ReadAll(response.Body)
+			assert.Equal(t, gz.Bytes(), data)
+			return len(data), err
+		}).Run(ctx)
+		require.NoError(t, err)
+		require.False(t, ctx.failed)
+		assert.Equal(t, gz.Len(), ctx.currBody)
+		assert.Equal(t, `{"foo":"bar"}`, string(ctx.currRawBody))
+	})
+	t.Run("raw body in coverage", func(t *testing.T) {
+		ctx := newTestContext(nil)
+		ctx.httpDo = &dummyDo{status: http.StatusOK, body: []byte(`{"foo":"bar"}`)}
+		ctx.currEndpoint = Endpoint("/foos", "")
+		cov := coverage.NewCoverage()
+		ctx.coverage = cov
+		err := Method(GET, "").
+			AssertEqual(BodyText, "").
+			ResponseUnmarshal(func(response *http.Response) (any, error) {
+				return nil, nil
+			}).Run(ctx)
+		require.NoError(t, err)
+		require.Len(t, cov.Unmet, 1)
+		assert.Equal(t, `{"foo":"bar"}`, string(cov.Unmet[0].RawBody))
+
+		ctx.httpDo = &dummyDo{status: http.StatusOK, body: []byte(`{`)}
+		err = Method(GET, "").Run(ctx)
+		require.NoError(t, err)
+		require.Len(t, cov.Failures, 1)
+		assert.Equal(t, `{`, string(cov.Failures[0].RawBody))
+	})
+}
+
+func TestDecompressBody(t *testing.T) {
+	data, err := decompressBody([]byte("not gzip"), "gzip")
+	require.NoError(t, err)
+	assert.Equal(t, "not gzip", string(data))
+	data, err = decompressBody([]byte("br data"), "br")
+	require.NoError(t, err)
+	assert.Equal(t, "br data", string(data))
+	data, err = decompressBody(nil, "gzip")
+	require.NoError(t, err)
+	assert.Empty(t, data)
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write([]byte("hello world"))
+	_ = gw.Close()
+	_, err = decompressBody(gz.Bytes()[:gz.Len()-4], "gzip")
+	require.Error(t, err)
+}
@@ -3,6 +3,7 @@ package marrow
 import (
 	"fmt"
 	"github.com/go-andiamo/marrow/framing"
+	"github.com/go-andiamo/marrow/mocks/service"
 	"net/http"
 	"os"
 	"reflect"
@@ -193,6 +194,72 @@ func (c *dbClearTable) Frame() *framing.Frame {
 	return c.frame
 }
 
+type dbSnapshot struct {
+	when         When
+	dbName       string
+	snapshotName string
+	restore      bool
+	frame        *framing.Frame
+}
+
+var _ Capture = (*dbSnapshot)(nil)
+var _ BeforeAfter = (*dbSnapshot)(nil)
+
+// DbSnapshot is used to take a named snapshot of a database
+//
+// the database must be provided by a supporting image that supports snapshots (see with.ImageDatabaseSnapshots)
+//
+// Note: when only one database is used by tests, the dbName can be ""
+//
+//go:noinline
+func DbSnapshot(when When, dbName string, snapshotName string) BeforeAfter {
+	return &dbSnapshot{
+		when:         when,
+		dbName:       dbName,
+		snapshotName: snapshotName,
+		frame:        framing.NewFrame(0),
+	}
+}
+
+// DbRestoreSnapshot is used to restore a database to a previously taken named snapshot
+//
+// the database must be provided by a supporting image that supports snapshots (see with.ImageDatabaseSnapshots)
+//
+// Note: when only one database is used by tests, the dbName can be ""
+//
+//go:noinline
+func DbRestoreSnapshot(when When, dbName string, snapshotName string) BeforeAfter {
+	return &dbSnapshot{
+		when:         when,
+		dbName:       dbName,
+		snapshotName: snapshotName,
+		restore:      true,
+		frame:        framing.NewFrame(0),
+	}
+}
+
+func (c *dbSnapshot) Name() string {
+	if c.restore {
+		return "RESTORE SNAPSHOT " + c.snapshotName
+	}
+	return "SNAPSHOT " + c.snapshotName
+}
+
+func (c *dbSnapshot) When() When {
+	return c.when
+}
+
+func (c *dbSnapshot) Run(ctx Context) error {
+	if c.restore {
+		return wrapCaptureError(ctx.DbRestoreSnapshot(c.dbName, c.snapshotName), "", c)
+	}
+	return wrapCaptureError(ctx.DbSnapshot(c.dbName, c.snapshotName), "", c)
+}
+
+func (c *dbSnapshot) Frame() *framing.Frame {
+	return c.frame
+}
+
 type userDefinedCapture struct {
 	name  string
 	fn    func(ctx Context) error
@@ -396,6 +463,57 @@ func (m *mockServiceCall) Frame() *framing.Frame {
 	return m.frame
 }
 
+type mockServiceFault struct {
+	name   string
+	path   string
+	method string
+	fault  service.Fault
+	frame  *framing.Frame
+}
+
+var _ Capture = (*mockServiceFault)(nil)
+
+// MockServiceFault is used to set up a fault (e.g. latency, connection reset, 5xx burst, timeout) on a specific named mock service
+//
+// an empty path or method matches any path or method - see service.Fault for details of fault injection
+//
+//go:noinline
+func MockServiceFault(svcName string, path string, method MethodName, fault service.Fault) Capture {
+	return &mockServiceFault{
+		name:   svcName,
+		path:   path,
+		method: strings.ToUpper(string(method)),
+		fault:  fault,
+		frame:  framing.NewFrame(0),
+	}
+}
+
+func (m *mockServiceFault) Name() string {
+	return "MOCK SERVICE FAULT [" + m.name + "]: " + anyIfEmpty(m.method) + " " + anyIfEmpty(m.path)
+}
+
+func anyIfEmpty(s string) string {
+	if s == "" {
+		return "*"
+	}
+	return s
+}
+
+func (m *mockServiceFault) Run(ctx Context) (err error) {
+	if ms := ctx.GetMockService(m.name); ms != nil {
+		var actualPath string
+		if actualPath, err = resolveValueString(m.path, ctx); err == nil {
+			ms.MockFault(actualPath, m.method, m.fault)
+		}
+		return wrapCaptureError(err, "", m)
+	}
+	return newCaptureError(fmt.Sprintf("unknown mock service %q", m.name), nil, m)
+}
+
+func (m *mockServiceFault) Frame() *framing.Frame {
+	return m.frame
+}
+
 type wait struct {
 	ms    int
 	frame *framing.Frame
@@ -2,10 +2,12 @@ package marrow
 
 import (
 	"bytes"
+	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-andiamo/marrow/common"
 	"github.com/go-andiamo/marrow/coverage"
 	"github.com/go-andiamo/marrow/framing"
+	"github.com/go-andiamo/marrow/mocks/service"
 	htesting "github.com/go-andiamo/marrow/testing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -111,6 +113,51 @@ func TestDbClearTable(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestDbSnapshot(t *testing.T) {
+	c := DbSnapshot(After, "", "snap")
+	assert.Equal(t, "SNAPSHOT snap", c.(Capture).Name())
+	assert.Equal(t, After, c.When())
+	assert.NotNil(t, c.Frame())
+
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := newTestContext(nil)
+	ctx.dbs.register("", db, common.DatabaseArgs{})
+	err = c.Run(ctx)
+	require.Error(t, err)
+
+	img := &mockSnapshotsImage{db: db}
+	ctx.images["mock"] = img
+	err = c.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"snap"}, img.snapshots)
+	assert.Empty(t, img.restores)
+}
+
+func TestDbRestoreSnapshot(t *testing.T) {
+	c := DbRestoreSnapshot(Before, "", "snap")
+	assert.Equal(t, "RESTORE SNAPSHOT snap", c.(Capture).Name())
+	assert.Equal(t, Before, c.When())
+	assert.NotNil(t, c.Frame())
+
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := newTestContext(nil)
+	ctx.dbs.register("", db, common.DatabaseArgs{})
+	img := &mockSnapshotsImage{db: db}
+	ctx.images["mock"] = img
+	err = c.Run(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, img.snapshots)
+	assert.Equal(t, []string{"snap"}, img.restores)
+
+	img.err = errors.New("fooey")
+	err = c.Run(ctx)
+	require.Error(t, err)
+}
+
 func Test_userDefinedCapture(t *testing.T) {
 	c := &userDefinedCapture{
 		fn: func(ctx Context) error {
@@ -307,6 +354,41 @@ func TestMockServiceCall(t *testing.T) {
 	})
 }
 
+func TestMockServiceFault(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		c := MockServiceFault("mock", "/foos/{$id}", http.MethodGet, service.Fault{Status: http.StatusServiceUnavailable, Count: 2})
+		assert.Equal(t, "MOCK SERVICE FAULT [mock]: GET /foos/{$id}", c.Name())
+		assert.NotNil(t, c.Frame())
+		ctx := newTestContext(map[Var]any{"id": "123"})
+		ms := &mockMockedService{}
+		ctx.mockServices["mock"] = ms
+		err := c.Run(ctx)
+		require.NoError(t, err)
+		assert.True(t, ms.faulted)
+	})
+	t.Run("any path & method", func(t *testing.T) {
+		c := MockServiceFault("mock", "", "", service.Fault{ConnectionReset: true})
+		assert.Equal(t, "MOCK SERVICE FAULT [mock]: * *", c.Name())
+	})
+	t.Run("missing var in path", func(t *testing.T) {
+		c := MockServiceFault("mock", "/foos/{$id}", http.MethodGet, service.Fault{})
+		ctx := newTestContext(nil)
+		ms := &mockMockedService{}
+		ctx.mockServices["mock"] = ms
+		err := c.Run(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unresolved variables in string ")
+		assert.False(t, ms.faulted)
+	})
+	t.Run("unknown mock", func(t *testing.T) {
+		c := MockServiceFault("mock", "/foos", http.MethodGet, service.Fault{})
+		ctx := newTestContext(nil)
+		err := c.Run(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown mock service ")
+	})
+}
+
 func TestWait(t *testing.T) {
 	c := Wait(10)
 	assert.Equal(t, "WAIT 10ms", c.Name())
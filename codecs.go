@@ -0,0 +1,63 @@
+package marrow
+
+import (
+	"github.com/go-andiamo/marrow/common"
+	"mime"
+	"strings"
+)
+
+type codecRegistry map[string]common.Codec
+
+// defaultCodecs returns the built-in codecs keyed by media type
+func defaultCodecs() codecRegistry {
+	return codecRegistry{
+		"application/json":                  common.JsonCodec,
+		"application/xml":                   common.XmlCodec,
+		"text/xml":                          common.XmlCodec,
+		"application/yaml":                  common.YamlCodec,
+		"application/x-yaml":                common.YamlCodec,
+		"text/yaml":                         common.YamlCodec,
+		"text/x-yaml":                       common.YamlCodec,
+		"text/csv":                          common.CsvCodec,
+		"application/x-www-form-urlencoded": common.FormCodec,
+		"text/*":                            common.TextCodec,
+		"application/octet-stream":          common.BinaryCodec,
+		"image/*":                           common.BinaryCodec,
+		"audio/*":                           common.BinaryCodec,
+		"video/*":                           common.BinaryCodec,
+	}
+}
+
+// register registers a codec for a media type (the media type can be a wildcard, e.g. "text/*")
+func (r codecRegistry) register(mediaType string, codec common.Codec) {
+	if mt, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = mt
+	}
+	r[strings.ToLower(mediaType)] = codec
+}
+
+// lookup finds the codec for a content type
+//
+// the codec is looked up by exact media type, then structured syntax suffix (e.g. "application/problem+json" uses
+// the "application/json" codec), then wildcard subtype (e.g. "text/*") - if no codec is found (or the content type is empty), the json codec is used
+func (r codecRegistry) lookup(contentType string) common.Codec {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		if c, ok := r[mt]; ok {
+			return c
+		}
+		major, minor, _ := strings.Cut(mt, "/")
+		if i := strings.LastIndexByte(minor, '+'); i != -1 {
+			suffix := minor[i+1:]
+			if c, ok := r["application/"+suffix]; ok {
+				return c
+			}
+		}
+		if c, ok := r[major+"/*"]; ok {
+			return c
+		}
+	}
+	if c, ok := r["application/json"]; ok {
+		return c
+	}
+	return common.JsonCodec
+}
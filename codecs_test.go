@@ -0,0 +1,126 @@
+package marrow
+
+import (
+	"github.com/go-andiamo/marrow/common"
+	"github.com/go-andiamo/marrow/coverage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestCodecRegistry_Lookup(t *testing.T) {
+	r := defaultCodecs()
+	testCases := []struct {
+		contentType string
+		expect      common.Codec
+	}{
+		{"", common.JsonCodec},
+		{"not a content type;;", common.JsonCodec},
+		{"application/json", common.JsonCodec},
+		{"application/JSON; charset=utf-8", common.JsonCodec},
+		{"application/problem+json", common.JsonCodec},
+		{"application/xml", common.XmlCodec},
+		{"text/xml; charset=utf-8", common.XmlCodec},
+		{"application/atom+xml", common.XmlCodec},
+		{"application/yaml", common.YamlCodec},
+		{"application/vnd.foo+yaml", common.YamlCodec},
+		{"text/csv", common.CsvCodec},
+		{"application/x-www-form-urlencoded", common.FormCodec},
+		{"text/plain", common.TextCodec},
+		{"text/html; charset=utf-8", common.TextCodec},
+		{"application/octet-stream", common.BinaryCodec},
+		{"image/png", common.BinaryCodec},
+		{"application/pdf", common.JsonCodec},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.contentType, func(t *testing.T) {
+			assert.Equal(t, tc.expect, r.lookup(tc.contentType))
+		})
+	}
+	r.register("application/vnd.foo; version=1", common.TextCodec)
+	assert.Equal(t, common.TextCodec, r.lookup("application/vnd.foo"))
+	r.register("application/*", common.BinaryCodec)
+	assert.Equal(t, common.BinaryCodec, r.lookup("application/pdf"))
+	assert.Equal(t, common.JsonCodec, codecRegistry(nil).lookup("text/plain"))
+}
+
+func TestMethod_Run_DecodesByContentType(t *testing.T) {
+	testCases := []struct {
+		contentType string
+		body        string
+		query       string
+		expect      any
+	}{
+		{"application/json", `{"foo":{"bar":42}}`, "$.foo.bar", int64(42)},
+		{"application/xml", `<foo><bar id="1">42</bar></foo>`, "$.foo.bar['#text']", "42"},
+		{"application/yaml", "foo:\n  bar: 42\n", "$.foo.bar", int64(42)},
+		{"text/csv", "bar,baz\n42,43\n", "$[0].bar", "42"},
+		{"application/x-www-form-urlencoded", "bar=42", "$.bar", "42"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.contentType, func(t *testing.T) {
+			ctx := newTestContext(nil)
+			ctx.httpDo = &dummyDo{
+				status: http.StatusOK,
+				body:   []byte(tc.body),
+				hdrs:   map[string]string{"Content-Type": tc.contentType},
+			}
+			ctx.currEndpoint = Endpoint("/foos", "")
+			cov := coverage.NewCoverage()
+			ctx.coverage = cov
+			m := Method(GET, "").
+				AssertEqual(JsonQuery(Body, tc.query), tc.expect)
+			err := m.Run(ctx)
+			require.NoError(t, err)
+			require.False(t, ctx.failed)
+			assert.Len(t, cov.Met, 1)
+		})
+	}
+	t.Run("text", func(t *testing.T) {
+		ctx := newTestContext(nil)
+		ctx.httpDo = &dummyDo{
+			status: http.StatusOK,
+			body:   []byte(`<html></html>`),
+			hdrs:   map[string]string{"Content-Type": "text/html"},
+		}
+		ctx.currEndpoint = Endpoint("/foos", "")
+		err := Method(GET, "").Run(ctx)
+		require.NoError(t, err)
+		require.False(t, ctx.failed)
+		assert.Equal(t, "<html></html>", ctx.currBody)
+	})
+	t.Run("empty body", func(t *testing.T) {
+		ctx := newTestContext(nil)
+		ctx.httpDo = &dummyDo{
+			status: http.StatusNoContent,
+			hdrs:   map[string]string{"Content-Type": "application/xml"},
+		}
+		ctx.currEndpoint = Endpoint("/foos", "")
+		err := Method(GET, "").Run(ctx)
+		require.NoError(t, err)
+		require.False(t, ctx.failed)
+		assert.Nil(t, ctx.currBody)
+	})
+	t.Run("decode fails", func(t *testing.T) {
+		ctx := newTestContext(nil)
+		ctx.httpDo = &dummyDo{
+			status: http.StatusOK,
+			body:   []byte(`<foo>`),
+			hdrs:   map[string]string{"Content-Type": "application/xml"},
+		}
+		ctx.currEndpoint = Endpoint("/foos", "")
+		err := Method(GET, "").Run(ctx)
+		require.NoError(t, err)
+		require.True(t, ctx.failed)
+	})
+}
+
+func TestSuite_AddCodec(t *testing.T) {
+	s := Suite().(*suite)
+	s.AddCodec("application/vnd.foo", common.TextCodec)
+	ctx := s.initializeContext()
+	s.finalizeContext(ctx, coverage.NewNullCoverage(), nil)
+	assert.Equal(t, common.TextCodec, ctx.codecFor("application/vnd.foo; charset=utf-8"))
+	assert.Equal(t, common.XmlCodec, ctx.codecFor("application/xml"))
+}
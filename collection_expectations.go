@@ -0,0 +1,494 @@
+package marrow
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/go-andiamo/marrow/framing"
+	"reflect"
+	"time"
+)
+
+// SortOrder is the sort order used by ExpectSortedBy
+type SortOrder int
+
+const (
+	Ascending SortOrder = iota
+	Descending
+)
+
+func (o SortOrder) String() string {
+	if o == Descending {
+		return "descending"
+	}
+	return "ascending"
+}
+
+// CollectionElement is the offending element reported (as the unmet error actual value) by collection expectations
+// (e.g. ExpectEvery, ExpectNone, ExpectSortedBy & ExpectUnique)
+type CollectionElement struct {
+	Index int
+	Value any
+}
+
+func (e CollectionElement) stringify() string {
+	return fmt.Sprintf("[%d] %s", e.Index, stringifyJsonValue(e.Value))
+}
+
+type collectionMatch int
+
+const (
+	matchEvery collectionMatch = iota
+	matchAny
+	matchNone
+)
+
+type collectionPredicate struct {
+	value     any
+	predicate any
+	match     collectionMatch
+	frame     *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*collectionPredicate)(nil)
+
+// ExpectEvery asserts that every element of the value (or resolved value) matches the predicate
+//
+// the value (or resolved value) must be a slice
+//
+// the predicate can be:
+//   - func(any) bool - called with each element
+//   - Expectation - met against each element (the element is set as Var(".") whilst each element is tested)
+//   - JsonMatcher - e.g. AnyUUID()
+//   - any other value (or resolvable) - each element must structurally match it (as per ExpectJsonSubset)
+//
+// if unmet, the unmet error actual value is a CollectionElement (giving the index of the first element that did not match)
+//
+//go:noinline
+func ExpectEvery(value any, predicate any) Expectation {
+	return &collectionPredicate{
+		value:     value,
+		predicate: predicate,
+		match:     matchEvery,
+		frame:     framing.NewFrame(0),
+	}
+}
+
+// ExpectAny asserts that at least one element of the value (or resolved value) matches the predicate
+//
+// see ExpectEvery for predicate details
+//
+//go:noinline
+func ExpectAny(value any, predicate any) Expectation {
+	return &collectionPredicate{
+		value:     value,
+		predicate: predicate,
+		match:     matchAny,
+		frame:     framing.NewFrame(0),
+	}
+}
+
+// ExpectNone asserts that no element of the value (or resolved value) matches the predicate
+//
+// see ExpectEvery for predicate details
+//
+// if unmet, the unmet error actual value is a CollectionElement (giving the index of the first element that matched)
+//
+//go:noinline
+func ExpectNone(value any, predicate any) Expectation {
+	return &collectionPredicate{
+		value:     value,
+		predicate: predicate,
+		match:     matchNone,
+		frame:     framing.NewFrame(0),
+	}
+}
+
+func (e *collectionPredicate) Name() string {
+	switch e.match {
+	case matchAny:
+		return "Expect Any"
+	case matchNone:
+		return "Expect None"
+	default:
+		return "Expect Every"
+	}
+}
+
+func (e *collectionPredicate) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *collectionPredicate) Met(ctx Context) (unmet error, err error) {
+	var items []any
+	if items, err = resolveCollection(e.value, ctx); err == nil {
+		var expected any
+		if expected, err = e.resolvePredicate(ctx); err == nil {
+			if _, ok := expected.(Expectation); ok {
+				// the element is set as Var(".") - which is restored afterwards...
+				defer preserveVar(ctx, ".")()
+			}
+			for i, item := range items {
+				var ok bool
+				var cause error
+				if ok, cause, err = e.test(ctx, expected, item); err != nil {
+					return
+				}
+				switch {
+				case e.match == matchEvery && !ok:
+					unmet = e.unmet(fmt.Sprintf("expected every element to match - element [%d] did not match", i), CollectionElement{Index: i, Value: item}, cause)
+					return
+				case e.match == matchNone && ok:
+					unmet = e.unmet(fmt.Sprintf("expected no element to match - element [%d] matched", i), CollectionElement{Index: i, Value: item}, nil)
+					return
+				case e.match == matchAny && ok:
+					return
+				}
+			}
+			if e.match == matchAny {
+				unmet = e.unmet("expected any element to match", items, nil)
+			}
+		}
+	}
+	return
+}
+
+func (e *collectionPredicate) unmet(msg string, actual any, cause error) error {
+	return &unmetError{
+		msg:      msg,
+		name:     e.Name(),
+		expected: OperandValue{Original: e.predicate},
+		actual:   OperandValue{Original: e.value, Resolved: actual},
+		cause:    cause,
+		frame:    e.frame,
+	}
+}
+
+func (e *collectionPredicate) resolvePredicate(ctx Context) (any, error) {
+	switch e.predicate.(type) {
+	case func(any) bool, Expectation, JsonMatcher:
+		return e.predicate, nil
+	}
+	av, err := ResolveValue(e.predicate, ctx)
+	if err == nil {
+		av, err = normalizeJsonValue(av)
+	}
+	return av, err
+}
+
+func (e *collectionPredicate) test(ctx Context, predicate any, item any) (ok bool, cause error, err error) {
+	switch pt := predicate.(type) {
+	case func(any) bool:
+		ok = pt(item)
+	case Expectation:
+		ctx.SetVar(".", item)
+		cause, err = pt.Met(ctx)
+		ok = cause == nil && err == nil
+	default:
+		var nv any
+		if nv, err = normalizeJsonValue(item); err == nil {
+			c, _ := newJsonComparer(true, JsonCompareOptions{})
+			ok = len(c.compare(jsonPath{}, predicate, nv)) == 0
+		}
+	}
+	return
+}
+
+type sortedBy struct {
+	value any
+	path  string
+	order SortOrder
+	frame *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*sortedBy)(nil)
+
+// ExpectSortedBy asserts that the elements of the value (or resolved value) are sorted (in the supplied order)
+// by the value at the path within each element
+//
+// the path is the same as used by JsonPath - if the path is "" (or "."), the elements themselves are compared
+//
+// the compared values must be numbers, strings or time.Time
+//
+// if unmet, the unmet error actual value is a CollectionElement (giving the index of the first element out of order)
+//
+//go:noinline
+func ExpectSortedBy(value any, path string, order SortOrder) Expectation {
+	return &sortedBy{
+		value: value,
+		path:  path,
+		order: order,
+		frame: framing.NewFrame(0),
+	}
+}
+
+func (e *sortedBy) Name() string {
+	return "Expect Sorted By " + e.path
+}
+
+func (e *sortedBy) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *sortedBy) Met(ctx Context) (unmet error, err error) {
+	var items []any
+	if items, err = resolveCollection(e.value, ctx); err == nil {
+		var prev any
+		for i, item := range items {
+			var v any
+			if v, err = elementValue(item, e.path); err != nil {
+				return nil, fmt.Errorf("element [%d]: %w", i, err)
+			}
+			if i > 0 {
+				c, ok := compareOrdered(prev, v)
+				msg := ""
+				if !ok {
+					msg = fmt.Sprintf("expected sorted %s by %q - element [%d] cannot be compared", e.order, e.path, i)
+				} else if (e.order == Ascending && c > 0) || (e.order == Descending && c < 0) {
+					msg = fmt.Sprintf("expected sorted %s by %q - element [%d] out of order", e.order, e.path, i)
+				}
+				if msg != "" {
+					unmet = &unmetError{
+						msg:      msg,
+						name:     e.Name(),
+						expected: OperandValue{Original: e.order},
+						actual:   OperandValue{Original: e.value, Resolved: CollectionElement{Index: i, Value: item}},
+						frame:    e.frame,
+					}
+					return
+				}
+			}
+			prev = v
+		}
+	}
+	return
+}
+
+func compareOrdered(a any, b any) (int, bool) {
+	if an, ok := jsonNumber(a); ok {
+		if bn, ok := jsonNumber(b); ok {
+			return cmp.Compare(an, bn), true
+		}
+	} else if as, ok := a.(string); ok {
+		if bs, ok := b.(string); ok {
+			return cmp.Compare(as, bs), true
+		}
+	} else if at, ok := a.(time.Time); ok {
+		if bt, ok := b.(time.Time); ok {
+			return at.Compare(bt), true
+		}
+	}
+	return 0, false
+}
+
+type unique struct {
+	value any
+	path  string
+	frame *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*unique)(nil)
+
+// ExpectUnique asserts that the elements of the value (or resolved value) are unique by the value at the path within each element
+//
+// the path is the same as used by JsonPath - if the path is "" (or "."), the elements themselves are compared
+//
+// if unmet, the unmet error actual value is a CollectionElement (giving the index of the first duplicate element)
+//
+//go:noinline
+func ExpectUnique(value any, path string) Expectation {
+	return &unique{
+		value: value,
+		path:  path,
+		frame: framing.NewFrame(0),
+	}
+}
+
+func (e *unique) Name() string {
+	return "Expect Unique " + e.path
+}
+
+func (e *unique) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *unique) Met(ctx Context) (unmet error, err error) {
+	var items []any
+	if items, err = resolveCollection(e.value, ctx); err == nil {
+		seen := make([]any, 0, len(items))
+		for i, item := range items {
+			var v any
+			if v, err = elementValue(item, e.path); err == nil {
+				v, err = normalizeJsonValue(v)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("element [%d]: %w", i, err)
+			}
+			for j, sv := range seen {
+				if jqEqual(sv, v) {
+					unmet = &unmetError{
+						msg:      fmt.Sprintf("expected unique by %q - element [%d] duplicates element [%d]", e.path, i, j),
+						name:     e.Name(),
+						expected: OperandValue{Original: e.path},
+						actual:   OperandValue{Original: e.value, Resolved: CollectionElement{Index: i, Value: item}},
+						frame:    e.frame,
+					}
+					return
+				}
+			}
+			seen = append(seen, v)
+		}
+	}
+	return
+}
+
+type containsElement struct {
+	value   any
+	element any
+	frame   *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*containsElement)(nil)
+
+// ExpectContainsElement asserts that the value (or resolved value) contains an element that structurally matches
+// the expected element (as per ExpectJsonSubset - so the expected element can contain JsonMatcher placeholders)
+//
+//go:noinline
+func ExpectContainsElement(value any, element any) Expectation {
+	return &containsElement{
+		value:   value,
+		element: element,
+		frame:   framing.NewFrame(0),
+	}
+}
+
+func (e *containsElement) Name() string {
+	return "Expect Contains Element"
+}
+
+func (e *containsElement) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *containsElement) Met(ctx Context) (unmet error, err error) {
+	var items []any
+	if items, err = resolveCollection(e.value, ctx); err == nil {
+		var ev any
+		if ev, err = ResolveValue(e.element, ctx); err == nil {
+			if ev, err = normalizeJsonValue(ev); err == nil {
+				for _, item := range items {
+					var nv any
+					if nv, err = normalizeJsonValue(item); err != nil {
+						return
+					}
+					if c, _ := newJsonComparer(true, JsonCompareOptions{}); len(c.compare(jsonPath{}, ev, nv)) == 0 {
+						return
+					}
+				}
+				unmet = &unmetError{
+					msg:      "expected to contain element",
+					name:     e.Name(),
+					expected: OperandValue{Original: e.element, Resolved: ev},
+					actual:   OperandValue{Original: e.value, Resolved: items},
+					frame:    e.frame,
+				}
+			}
+		}
+	}
+	return
+}
+
+type setEqual struct {
+	value    any
+	expected any
+	frame    *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*setEqual)(nil)
+
+// ExpectSetEqual asserts that the value (or resolved value) contains the same elements as the expected value -
+// regardless of order
+//
+// elements are compared structurally (as per ExpectJsonEqual - so expected elements can contain JsonMatcher placeholders)
+//
+// if unmet, the unmet error actual value is a JsonDiff (giving the indexes of missing and unexpected elements)
+//
+//go:noinline
+func ExpectSetEqual(value any, expected any) Expectation {
+	return &setEqual{
+		value:    value,
+		expected: expected,
+		frame:    framing.NewFrame(0),
+	}
+}
+
+func (e *setEqual) Name() string {
+	return "Expect Set Equal"
+}
+
+func (e *setEqual) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *setEqual) Met(ctx Context) (unmet error, err error) {
+	var items, expected []any
+	if items, err = resolveCollection(e.value, ctx); err == nil {
+		if expected, err = resolveCollection(e.expected, ctx); err == nil {
+			var av, ev any
+			if av, err = normalizeJsonValue(items); err == nil {
+				if ev, err = normalizeJsonValue(expected); err == nil {
+					c, _ := newJsonComparer(false, JsonCompareOptions{})
+					if diff := c.compareUnorderedArrays(jsonPath{}, ev.([]any), av.([]any)); len(diff) > 0 {
+						unmet = &unmetError{
+							msg:      "expected set equal",
+							name:     e.Name(),
+							expected: OperandValue{Original: e.expected, Resolved: ev},
+							actual:   OperandValue{Original: e.value, Resolved: diff},
+							frame:    e.frame,
+						}
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+func elementValue(item any, path string) (any, error) {
+	if path == "" || path == "." {
+		return item, nil
+	}
+	return resolveJsonPath(item, path)
+}
+
+// resolveCollection resolves the value and converts it to a slice of elements
+func resolveCollection(value any, ctx Context) (items []any, err error) {
+	var av any
+	if av, err = ResolveValue(value, ctx); err == nil {
+		items, err = collectionItems(av)
+	}
+	return
+}
+
+func collectionItems(v any) ([]any, error) {
+	switch vt := v.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return vt, nil
+	}
+	to := reflect.ValueOf(v)
+	if to.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("expected slice but got %T", v)
+	}
+	l := to.Len()
+	items := make([]any, l)
+	for i := 0; i < l; i++ {
+		items[i] = to.Index(i).Interface()
+	}
+	return items, nil
+}
@@ -0,0 +1,211 @@
+package marrow
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var testPets = []any{
+	map[string]any{"id": int64(1), "name": "Felix", "type": "cat", "status": "active"},
+	map[string]any{"id": int64(2), "name": "Rex", "type": "dog", "status": "active"},
+	map[string]any{"id": int64(3), "name": "Tweety", "type": "bird", "status": "inactive"},
+}
+
+func TestExpectEvery(t *testing.T) {
+	ctx := newTestContext(map[Var]any{"pets": testPets})
+	exp := ExpectEvery(Var("pets"), func(v any) bool {
+		return v.(map[string]any)["id"].(int64) > 0
+	})
+	assert.Equal(t, "Expect Every", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	assert.False(t, exp.IsRequired())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectEvery(Var("pets"), JSON{"status": "active"}).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected every element to match - element [2] did not match", unmet.Error())
+	uerr := unmet.(UnmetError)
+	elem, ok := uerr.Actual().Resolved.(CollectionElement)
+	require.True(t, ok)
+	assert.Equal(t, 2, elem.Index)
+	assert.Contains(t, uerr.TestFormat(), `[2] {"id":3,"name":"Tweety","status":"inactive","type":"bird"}`)
+
+	unmet, err = ExpectEvery(Var("pets"), ExpectNotEqual(JsonPath(Var("."), "name"), "Rex")).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	uerr = unmet.(UnmetError)
+	assert.Equal(t, 1, uerr.Actual().Resolved.(CollectionElement).Index)
+	assert.NotNil(t, uerr.Cause())
+	_, leaked := ctx.Vars()["."]
+	assert.False(t, leaked)
+
+	ctx.SetVar(".", "existing")
+	unmet, err = ExpectAny(Var("pets"), ExpectEqual(JsonPath(Var("."), "name"), "Rex")).Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+	assert.Equal(t, "existing", ctx.Vars()["."])
+
+	unmet, err = ExpectEvery([]string{"a", "b"}, AnyString()).Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	_, err = ExpectEvery(Var("pets"), ExpectEqual(Var("missing"), 1)).Met(ctx)
+	require.Error(t, err)
+	_, err = ExpectEvery(Var("pets"), Var("missing")).Met(ctx)
+	require.Error(t, err)
+	_, err = ExpectEvery("not a slice", AnyString()).Met(ctx)
+	require.Error(t, err)
+	_, err = ExpectEvery(Var("missing"), AnyString()).Met(ctx)
+	require.Error(t, err)
+}
+
+func TestExpectAny(t *testing.T) {
+	ctx := newTestContext(map[Var]any{"pets": testPets})
+	exp := ExpectAny(Var("pets"), JSON{"name": "Rex"})
+	assert.Equal(t, "Expect Any", exp.Name())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectAny(Var("pets"), JSON{"name": "Garfield"}).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected any element to match", unmet.Error())
+}
+
+func TestExpectNone(t *testing.T) {
+	ctx := newTestContext(map[Var]any{"pets": testPets})
+	exp := ExpectNone(Var("pets"), JSON{"type": "fish"})
+	assert.Equal(t, "Expect None", exp.Name())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectNone(Var("pets"), JSON{"type": "dog"}).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected no element to match - element [1] matched", unmet.Error())
+	assert.Equal(t, 1, unmet.(UnmetError).Actual().Resolved.(CollectionElement).Index)
+}
+
+func TestExpectSortedBy(t *testing.T) {
+	ctx := newTestContext(map[Var]any{"pets": testPets})
+	exp := ExpectSortedBy(Var("pets"), "id", Ascending)
+	assert.Equal(t, "Expect Sorted By id", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+	unmet, err = ExpectSortedBy(Var("pets"), "name", Ascending).Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectSortedBy(Var("pets"), "type", Ascending).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, `expected sorted ascending by "type" - element [2] out of order`, unmet.Error())
+	assert.Equal(t, 2, unmet.(UnmetError).Actual().Resolved.(CollectionElement).Index)
+
+	unmet, err = ExpectSortedBy(Var("pets"), "id", Descending).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, `expected sorted descending by "id" - element [1] out of order`, unmet.Error())
+
+	unmet, err = ExpectSortedBy([]any{3, 2.5, 2.5, 1}, "", Descending).Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+	now := time.Now()
+	unmet, err = ExpectSortedBy([]time.Time{now, now.Add(time.Second)}, ".", Ascending).Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectSortedBy([]any{1, "a"}, "", Ascending).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, `expected sorted ascending by "" - element [1] cannot be compared`, unmet.Error())
+
+	_, err = ExpectSortedBy(Var("pets"), "missing", Ascending).Met(ctx)
+	require.Error(t, err)
+	assert.Equal(t, `element [0]: json path "missing" does not exist`, err.Error())
+}
+
+func TestExpectUnique(t *testing.T) {
+	ctx := newTestContext(map[Var]any{"pets": testPets})
+	exp := ExpectUnique(Var("pets"), "id")
+	assert.Equal(t, "Expect Unique id", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectUnique(Var("pets"), "status").Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, `expected unique by "status" - element [1] duplicates element [0]`, unmet.Error())
+	assert.Equal(t, 1, unmet.(UnmetError).Actual().Resolved.(CollectionElement).Index)
+
+	unmet, err = ExpectUnique([]any{1, int64(2), 1.0}, "").Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, 2, unmet.(UnmetError).Actual().Resolved.(CollectionElement).Index)
+
+	_, err = ExpectUnique(Var("pets"), "missing").Met(ctx)
+	require.Error(t, err)
+}
+
+func TestExpectContainsElement(t *testing.T) {
+	ctx := newTestContext(map[Var]any{"pets": testPets})
+	exp := ExpectContainsElement(Var("pets"), JSON{"id": AnyNumber(), "name": "Tweety"})
+	assert.Equal(t, "Expect Contains Element", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectContainsElement(Var("pets"), JSON{"name": "Garfield"}).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected to contain element", unmet.Error())
+
+	_, err = ExpectContainsElement(Var("pets"), Var("missing")).Met(ctx)
+	require.Error(t, err)
+}
+
+func TestExpectSetEqual(t *testing.T) {
+	ctx := newTestContext(map[Var]any{"ids": []any{int64(3), int64(1), int64(2)}})
+	exp := ExpectSetEqual(Var("ids"), []int{1, 2, 3})
+	assert.Equal(t, "Expect Set Equal", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectSetEqual(Var("ids"), JSONArray{1, 2, 4}).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected set equal", unmet.Error())
+	diff := unmet.(UnmetError).Actual().Resolved.(JsonDiff)
+	require.Len(t, diff, 2)
+	assert.Equal(t, "$[2]: missing, expected 4", diff[0].String())
+	assert.Equal(t, "$[0]: unexpected 3", diff[1].String())
+
+	_, err = ExpectSetEqual(Var("ids"), "not a slice").Met(ctx)
+	require.Error(t, err)
+}
+
+func TestCollectionItems(t *testing.T) {
+	items, err := collectionItems(nil)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	items, err = collectionItems([]string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"a", "b"}, items)
+	_, err = collectionItems(map[string]any{})
+	require.Error(t, err)
+	assert.Equal(t, "expected slice but got map[string]interface {}", err.Error())
+}
@@ -0,0 +1,404 @@
+package common
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Codec is the interface for decoding (and encoding) http bodies of a particular media type
+//
+// Decode should normalise to the same shapes as decoded json (i.e. map[string]any, []any, string, bool, json.Number and nil)
+// so that resolvables (e.g. marrow.JsonPath) and expectations work across formats
+type Codec interface {
+	// Decode decodes the body data
+	Decode(data []byte) (any, error)
+	// Encode encodes a value as body data
+	Encode(v any) ([]byte, error)
+}
+
+var (
+	// JsonCodec is the built-in Codec for json - numbers are decoded as json.Number
+	JsonCodec Codec = jsonCodec{}
+	// XmlCodec is the built-in Codec for xml
+	//
+	// xml is decoded to a map[string]any with a single key of the root element name, where each element is decoded as:
+	//   - a string (if the element has no attributes or child elements)
+	//   - a map[string]any of child elements (repeated child elements are decoded as []any) - attributes are keyed as "@<name>"
+	//     and any text content is keyed as "#text"
+	XmlCodec Codec = xmlCodec{}
+	// YamlCodec is the built-in Codec for yaml - numbers are decoded as json.Number and timestamps as RFC 3339 strings
+	YamlCodec Codec = yamlCodec{}
+	// CsvCodec is the built-in Codec for csv - the first row is treated as the header and each subsequent row
+	// is decoded as a map[string]any (of string values) keyed by the header names
+	CsvCodec Codec = csvCodec{}
+	// FormCodec is the built-in Codec for form-urlencoded - decoded as a map[string]any, where single values are strings
+	// and multiple values are []any
+	FormCodec Codec = formCodec{}
+	// TextCodec is the built-in Codec for text (e.g. "text/plain", "text/html") - decoded as a string
+	TextCodec Codec = textCodec{}
+	// BinaryCodec is the built-in Codec for binary data (e.g. "application/octet-stream") - decoded as []byte
+	BinaryCodec Codec = binaryCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(data []byte) (v any, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&v); err == io.EOF {
+		err = nil
+	}
+	return v, err
+}
+
+func (jsonCodec) Encode(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) Decode(data []byte) (any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tkn, err := decoder.Token()
+		if err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if start, ok := tkn.(xml.StartElement); ok {
+			v, err := xmlDecodeElement(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{start.Name.Local: v}, nil
+		}
+	}
+}
+
+func xmlDecodeElement(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	m := make(map[string]any, len(start.Attr))
+	for _, attr := range start.Attr {
+		m["@"+attr.Name.Local] = attr.Value
+	}
+	var text strings.Builder
+	for {
+		tkn, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tt := tkn.(type) {
+		case xml.StartElement:
+			cv, err := xmlDecodeElement(decoder, tt)
+			if err != nil {
+				return nil, err
+			}
+			if ev, exists := m[tt.Name.Local]; exists {
+				if sl, ok := ev.([]any); ok {
+					m[tt.Name.Local] = append(sl, cv)
+				} else {
+					m[tt.Name.Local] = []any{ev, cv}
+				}
+			} else {
+				m[tt.Name.Local] = cv
+			}
+		case xml.CharData:
+			text.Write(tt)
+		case xml.EndElement:
+			s := strings.TrimSpace(text.String())
+			if len(m) == 0 {
+				return s, nil
+			} else if s != "" {
+				m["#text"] = s
+			}
+			return m, nil
+		}
+	}
+}
+
+func (xmlCodec) Encode(v any) ([]byte, error) {
+	if m, ok := v.(map[string]any); ok && len(m) == 1 {
+		var buf bytes.Buffer
+		encoder := xml.NewEncoder(&buf)
+		for k, mv := range m {
+			if err := xmlEncodeElement(encoder, k, mv); err != nil {
+				return nil, err
+			}
+		}
+		if err := encoder.Flush(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return xml.Marshal(v)
+}
+
+func xmlEncodeElement(encoder *xml.Encoder, name string, v any) error {
+	if sl, ok := v.([]any); ok {
+		for _, sv := range sl {
+			if err := xmlEncodeElement(encoder, name, sv); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	m, isMap := v.(map[string]any)
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if an, ok := strings.CutPrefix(k, "@"); ok {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: an}, Value: fmt.Sprint(m[k])})
+		} else if k != "#text" {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(start.Attr, func(a, b xml.Attr) int {
+		return strings.Compare(a.Name.Local, b.Name.Local)
+	})
+	slices.Sort(keys)
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	if isMap {
+		if text, ok := m["#text"]; ok {
+			if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(text))); err != nil {
+				return err
+			}
+		}
+		for _, k := range keys {
+			if err := xmlEncodeElement(encoder, k, m[k]); err != nil {
+				return err
+			}
+		}
+	} else if v != nil {
+		if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) Decode(data []byte) (v any, err error) {
+	if err = yaml.Unmarshal(data, &v); err == nil {
+		v, err = yamlNormalize(v)
+	}
+	return v, err
+}
+
+func yamlNormalize(v any) (any, error) {
+	switch vt := v.(type) {
+	case int:
+		return json.Number(strconv.Itoa(vt)), nil
+	case uint64:
+		return json.Number(strconv.FormatUint(vt, 10)), nil
+	case float64:
+		if math.IsNaN(vt) || math.IsInf(vt, 0) {
+			return vt, nil
+		}
+		s := strconv.FormatFloat(vt, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			// retain float-ness (as per json)...
+			s += ".0"
+		}
+		return json.Number(s), nil
+	case time.Time:
+		return vt.Format(time.RFC3339Nano), nil
+	case map[string]any:
+		for k, mv := range vt {
+			nv, err := yamlNormalize(mv)
+			if err != nil {
+				return nil, err
+			}
+			vt[k] = nv
+		}
+	case map[any]any:
+		m := make(map[string]any, len(vt))
+		for k, mv := range vt {
+			nv, err := yamlNormalize(mv)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(k)] = nv
+		}
+		return m, nil
+	case []any:
+		for i, sv := range vt {
+			nv, err := yamlNormalize(sv)
+			if err != nil {
+				return nil, err
+			}
+			vt[i] = nv
+		}
+	}
+	return v, nil
+}
+
+func (yamlCodec) Encode(v any) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+type csvCodec struct{}
+
+func (csvCodec) Decode(data []byte) (any, error) {
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]any, 0, max(len(rows)-1, 0))
+	if len(rows) > 0 {
+		header := rows[0]
+		for _, row := range rows[1:] {
+			m := make(map[string]any, len(header))
+			for i, col := range header {
+				if i < len(row) {
+					m[col] = row[i]
+				}
+			}
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// Encode encodes a [][]string or a slice of maps (in which case the header is the sorted union of all map keys)
+func (csvCodec) Encode(v any) ([]byte, error) {
+	var rows [][]string
+	switch vt := v.(type) {
+	case [][]string:
+		rows = vt
+	case []map[string]any:
+		sl := make([]any, len(vt))
+		for i, m := range vt {
+			sl[i] = m
+		}
+		rows = csvRowsFromMaps(sl)
+	case []any:
+		rows = csvRowsFromMaps(vt)
+	default:
+		return nil, fmt.Errorf("cannot encode %T as csv", v)
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func csvRowsFromMaps(sl []any) [][]string {
+	header := make([]string, 0)
+	for _, item := range sl {
+		if m, ok := item.(map[string]any); ok {
+			for k := range m {
+				if !slices.Contains(header, k) {
+					header = append(header, k)
+				}
+			}
+		}
+	}
+	slices.Sort(header)
+	rows := [][]string{header}
+	for _, item := range sl {
+		m, _ := item.(map[string]any)
+		row := make([]string, len(header))
+		for i, col := range header {
+			if cv, ok := m[col]; ok && cv != nil {
+				row[i] = fmt.Sprint(cv)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+type formCodec struct{}
+
+func (formCodec) Decode(data []byte) (any, error) {
+	values, err := url.ParseQuery(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]any, len(values))
+	for k, vs := range values {
+		if len(vs) == 1 {
+			result[k] = vs[0]
+		} else {
+			sl := make([]any, len(vs))
+			for i, s := range vs {
+				sl[i] = s
+			}
+			result[k] = sl
+		}
+	}
+	return result, nil
+}
+
+// Encode encodes a url.Values or a map[string]any (slice values are encoded as multiple values)
+func (formCodec) Encode(v any) ([]byte, error) {
+	switch vt := v.(type) {
+	case url.Values:
+		return []byte(vt.Encode()), nil
+	case map[string]any:
+		values := url.Values{}
+		for k, mv := range vt {
+			if sl, ok := mv.([]any); ok {
+				for _, sv := range sl {
+					values.Add(k, fmt.Sprint(sv))
+				}
+			} else if mv != nil {
+				values.Set(k, fmt.Sprint(mv))
+			}
+		}
+		return []byte(values.Encode()), nil
+	}
+	return nil, fmt.Errorf("cannot encode %T as form", v)
+}
+
+type textCodec struct{}
+
+func (textCodec) Decode(data []byte) (any, error) {
+	return string(data), nil
+}
+
+func (textCodec) Encode(v any) ([]byte, error) {
+	switch vt := v.(type) {
+	case string:
+		return []byte(vt), nil
+	case []byte:
+		return vt, nil
+	case nil:
+		return []byte{}, nil
+	}
+	return []byte(fmt.Sprint(v)), nil
+}
+
+type binaryCodec struct{}
+
+func (binaryCodec) Decode(data []byte) (any, error) {
+	return data, nil
+}
+
+func (binaryCodec) Encode(v any) ([]byte, error) {
+	switch vt := v.(type) {
+	case []byte:
+		return vt, nil
+	case string:
+		return []byte(vt), nil
+	case nil:
+		return []byte{}, nil
+	}
+	return nil, fmt.Errorf("cannot encode %T as binary", v)
+}
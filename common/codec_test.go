@@ -0,0 +1,175 @@
+package common
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+)
+
+func TestJsonCodec(t *testing.T) {
+	v, err := JsonCodec.Decode([]byte(`{"foo":[1,1.5,"bar",true,null]}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"foo": []any{json.Number("1"), json.Number("1.5"), "bar", true, nil}}, v)
+	v, err = JsonCodec.Decode([]byte(" \n"))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+	_, err = JsonCodec.Decode([]byte(`{`))
+	require.Error(t, err)
+
+	data, err := JsonCodec.Encode(map[string]any{"foo": "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, string(data))
+}
+
+func TestXmlCodec(t *testing.T) {
+	v, err := XmlCodec.Decode([]byte(`<?xml version="1.0"?>
+<order id="1">
+	<item sku="a">Apple</item>
+	<item sku="b">Banana</item>
+	<note>  fragile  </note>
+	<empty/>
+	paid
+</order>`))
+	require.NoError(t, err)
+	expected := map[string]any{
+		"order": map[string]any{
+			"@id": "1",
+			"item": []any{
+				map[string]any{"@sku": "a", "#text": "Apple"},
+				map[string]any{"@sku": "b", "#text": "Banana"},
+			},
+			"note":  "fragile",
+			"empty": "",
+			"#text": "paid",
+		},
+	}
+	assert.Equal(t, expected, v)
+
+	data, err := XmlCodec.Encode(expected)
+	require.NoError(t, err)
+	assert.Equal(t, `<order id="1">paid<empty></empty><item sku="a">Apple</item><item sku="b">Banana</item><note>fragile</note></order>`, string(data))
+	rv, err := XmlCodec.Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, expected, rv)
+
+	v, err = XmlCodec.Decode([]byte(""))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+	_, err = XmlCodec.Decode([]byte("<foo>"))
+	require.Error(t, err)
+	_, err = XmlCodec.Decode([]byte("<foo><bar></foo>"))
+	require.Error(t, err)
+
+	type foo struct {
+		Bar string `xml:"bar"`
+	}
+	data, err = XmlCodec.Encode(foo{Bar: "baz"})
+	require.NoError(t, err)
+	assert.Equal(t, `<foo><bar>baz</bar></foo>`, string(data))
+}
+
+func TestYamlCodec(t *testing.T) {
+	v, err := YamlCodec.Decode([]byte(`
+foo:
+  int: 42
+  float: 1.0
+  big: 18446744073709551615
+  nan: .nan
+  when: 2025-01-02T03:04:05Z
+  list: [a, 1]
+  keyed:
+    1: one
+`))
+	require.NoError(t, err)
+	m := v.(map[string]any)["foo"].(map[string]any)
+	assert.Equal(t, json.Number("42"), m["int"])
+	assert.Equal(t, json.Number("1.0"), m["float"])
+	assert.Equal(t, json.Number("18446744073709551615"), m["big"])
+	assert.IsType(t, float64(0), m["nan"])
+	assert.Equal(t, "2025-01-02T03:04:05Z", m["when"])
+	assert.Equal(t, []any{"a", json.Number("1")}, m["list"])
+	assert.Equal(t, map[string]any{"1": "one"}, m["keyed"])
+	_, err = YamlCodec.Decode([]byte("foo: ["))
+	require.Error(t, err)
+
+	data, err := YamlCodec.Encode(map[string]any{"foo": "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, "foo: bar\n", string(data))
+}
+
+func TestCsvCodec(t *testing.T) {
+	v, err := CsvCodec.Decode([]byte("id,name\n1,Bilbo\n2,Frodo\n"))
+	require.NoError(t, err)
+	expected := []any{
+		map[string]any{"id": "1", "name": "Bilbo"},
+		map[string]any{"id": "2", "name": "Frodo"},
+	}
+	assert.Equal(t, expected, v)
+	v, err = CsvCodec.Decode([]byte(""))
+	require.NoError(t, err)
+	assert.Equal(t, []any{}, v)
+	_, err = CsvCodec.Decode([]byte("id,name\n1\n"))
+	require.Error(t, err)
+
+	data, err := CsvCodec.Encode(expected)
+	require.NoError(t, err)
+	assert.Equal(t, "id,name\n1,Bilbo\n2,Frodo\n", string(data))
+	data, err = CsvCodec.Encode([]map[string]any{{"id": 1, "name": nil}})
+	require.NoError(t, err)
+	assert.Equal(t, "id,name\n1,\n", string(data))
+	data, err = CsvCodec.Encode([][]string{{"a"}, {"b"}})
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\n", string(data))
+	_, err = CsvCodec.Encode("foo")
+	require.Error(t, err)
+}
+
+func TestFormCodec(t *testing.T) {
+	v, err := FormCodec.Decode([]byte("name=Bilbo&tag=a&tag=b\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "Bilbo", "tag": []any{"a", "b"}}, v)
+	_, err = FormCodec.Decode([]byte("%zz"))
+	require.Error(t, err)
+
+	data, err := FormCodec.Encode(map[string]any{"name": "Bilbo", "tag": []any{"a", "b"}, "nil": nil})
+	require.NoError(t, err)
+	assert.Equal(t, "name=Bilbo&tag=a&tag=b", string(data))
+	data, err = FormCodec.Encode(url.Values{"a": {"1"}})
+	require.NoError(t, err)
+	assert.Equal(t, "a=1", string(data))
+	_, err = FormCodec.Encode("foo")
+	require.Error(t, err)
+}
+
+func TestTextAndBinaryCodecs(t *testing.T) {
+	v, err := TextCodec.Decode([]byte("foo"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", v)
+	for _, ev := range []any{"foo", []byte("foo")} {
+		data, err := TextCodec.Encode(ev)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", string(data))
+	}
+	data, err := TextCodec.Encode(42)
+	require.NoError(t, err)
+	assert.Equal(t, "42", string(data))
+	data, err = TextCodec.Encode(nil)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	v, err = BinaryCodec.Decode([]byte{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, v)
+	for _, ev := range []any{"foo", []byte("foo")} {
+		data, err := BinaryCodec.Encode(ev)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", string(data))
+	}
+	data, err = BinaryCodec.Encode(nil)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+	_, err = BinaryCodec.Encode(42)
+	require.Error(t, err)
+}
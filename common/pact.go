@@ -0,0 +1,129 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// PactSpecificationVersion is the Pact specification version of written Pact files
+const PactSpecificationVersion = "3.0.0"
+
+// Pact is a Pact (consumer-driven contract) file
+//
+// Pact v3 files are written (see service.Pact) - Pact v2 and v3 files can be read (see marrow.PactEndpoints)
+//
+// Note: matching rules and generators are not supported (and are ignored when reading)
+type Pact struct {
+	Consumer     PactParticipant   `json:"consumer"`
+	Provider     PactParticipant   `json:"provider"`
+	Interactions []PactInteraction `json:"interactions"`
+	Metadata     map[string]any    `json:"metadata,omitempty"`
+}
+
+// PactParticipant is the consumer or provider of a Pact
+type PactParticipant struct {
+	Name string `json:"name"`
+}
+
+// PactInteraction is a single request/response interaction of a Pact
+type PactInteraction struct {
+	Description    string              `json:"description"`
+	ProviderStates []PactProviderState `json:"providerStates,omitempty"`
+	// ProviderState is the (Pact v2) provider state
+	ProviderState string       `json:"providerState,omitempty"`
+	Request       PactRequest  `json:"request"`
+	Response      PactResponse `json:"response"`
+}
+
+// States returns the names of all provider states of the interaction
+func (i PactInteraction) States() []string {
+	result := make([]string, 0, len(i.ProviderStates)+1)
+	if i.ProviderState != "" {
+		result = append(result, i.ProviderState)
+	}
+	for _, ps := range i.ProviderStates {
+		result = append(result, ps.Name)
+	}
+	return result
+}
+
+// PactProviderState is a provider state of a Pact interaction
+type PactProviderState struct {
+	Name   string         `json:"name"`
+	Params map[string]any `json:"params,omitempty"`
+}
+
+// PactRequest is the request of a Pact interaction
+type PactRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Query   PactQuery         `json:"query,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// PactResponse is the response of a Pact interaction
+type PactResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// PactQuery is the query of a Pact request
+//
+// when unmarshalled, it can be a Pact v3 query (object of string arrays) or a Pact v2 query (string)
+type PactQuery map[string][]string
+
+func (q *PactQuery) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		var v url.Values
+		if v, err = url.ParseQuery(s); err != nil {
+			return err
+		}
+		*q = PactQuery(v)
+		return nil
+	}
+	var m map[string][]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*q = m
+	return nil
+}
+
+// PactBody converts body data to a Pact body
+//
+// json data is used as is - any other (non-empty) data is used as a json string
+func PactBody(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	if json.Valid(data) {
+		return bytes.Clone(data)
+	}
+	result, _ := json.Marshal(string(data))
+	return result
+}
+
+// PactBodyValue returns the value of a Pact body (given the content type)
+//
+// for a json content type (or no content type), the value is the unmarshalled json - otherwise, where the
+// body is a json string, the value is the (text) string
+//
+// if there is no body, ok is false
+func PactBodyValue(body json.RawMessage, contentType string) (value any, isJson bool, ok bool) {
+	if len(body) == 0 || string(body) == "null" {
+		return nil, false, false
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, false, false
+	}
+	if s, isStr := v.(string); isStr && contentType != "" && !strings.Contains(strings.ToLower(contentType), "json") {
+		return s, false, true
+	}
+	return v, true, true
+}
@@ -0,0 +1,74 @@
+package common
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPact_Unmarshal(t *testing.T) {
+	const data = `{
+		"consumer": {"name": "c"},
+		"provider": {"name": "p"},
+		"interactions": [
+			{
+				"description": "v3",
+				"providerStates": [{"name": "s1", "params": {"id": 1}}],
+				"request": {"method": "GET", "path": "/foos", "query": {"a": ["1", "2"]}},
+				"response": {"status": 200, "body": {"foo": "bar"}}
+			},
+			{
+				"description": "v2",
+				"providerState": "s2",
+				"request": {"method": "GET", "path": "/foos", "query": "a=1&b=2"},
+				"response": {"status": 404}
+			}
+		]
+	}`
+	pact := Pact{}
+	err := json.Unmarshal([]byte(data), &pact)
+	require.NoError(t, err)
+	require.Len(t, pact.Interactions, 2)
+	assert.Equal(t, PactQuery{"a": {"1", "2"}}, pact.Interactions[0].Request.Query)
+	assert.Equal(t, []string{"s1"}, pact.Interactions[0].States())
+	assert.JSONEq(t, `{"foo":"bar"}`, string(pact.Interactions[0].Response.Body))
+	assert.Equal(t, PactQuery{"a": {"1"}, "b": {"2"}}, pact.Interactions[1].Request.Query)
+	assert.Equal(t, []string{"s2"}, pact.Interactions[1].States())
+	assert.Empty(t, pact.Interactions[1].Response.Body)
+
+	err = json.Unmarshal([]byte(`{"interactions":[{"request":{"query":true}}]}`), &pact)
+	require.Error(t, err)
+	err = json.Unmarshal([]byte(`{"interactions":[{"request":{"query":"%zz"}}]}`), &pact)
+	require.Error(t, err)
+}
+
+func TestPactBody(t *testing.T) {
+	assert.Nil(t, PactBody(nil))
+	assert.Equal(t, `{"foo":"bar"}`, string(PactBody([]byte(`{"foo":"bar"}`))))
+	assert.Equal(t, `"some text"`, string(PactBody([]byte(`some text`))))
+}
+
+func TestPactBodyValue(t *testing.T) {
+	_, _, ok := PactBodyValue(nil, "")
+	assert.False(t, ok)
+	_, _, ok = PactBodyValue(json.RawMessage(`null`), "")
+	assert.False(t, ok)
+	_, _, ok = PactBodyValue(json.RawMessage(`{`), "")
+	assert.False(t, ok)
+
+	v, isJson, ok := PactBodyValue(json.RawMessage(`{"foo":"bar"}`), "application/json")
+	assert.True(t, ok)
+	assert.True(t, isJson)
+	assert.Equal(t, map[string]any{"foo": "bar"}, v)
+
+	v, isJson, ok = PactBodyValue(json.RawMessage(`"text"`), "text/plain")
+	assert.True(t, ok)
+	assert.False(t, isJson)
+	assert.Equal(t, "text", v)
+
+	v, isJson, ok = PactBodyValue(json.RawMessage(`"text"`), "")
+	assert.True(t, ok)
+	assert.True(t, isJson)
+	assert.Equal(t, "text", v)
+}
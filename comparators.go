@@ -7,6 +7,7 @@ import (
 	"github.com/shopspring/decimal"
 	"strconv"
 	"strings"
+	"time"
 )
 
 //go:noinline
@@ -107,6 +108,20 @@ func (c *comparator) Met(ctx Context) (unmet error, err error) {
 		case string:
 			compared = true
 			comparison = strings.Compare(vt1, vt2)
+			if comparison != 0 {
+				// compare as times if both strings are times...
+				if t1, ok := parseTime(vt1); ok {
+					if t2, ok := parseTime(vt2); ok {
+						ov1.Coerced, ov2.Coerced = t1, t2
+						comparison = t1.Compare(t2)
+					}
+				}
+			}
+		case time.Time:
+			if ov1.Coerced, ov1.CoercionError = toTime(vt1); ov1.CoercionError == nil {
+				compared = true
+				comparison = ov1.Coerced.(time.Time).Compare(vt2)
+			}
 		case []byte:
 			compared = true
 			comparison = strings.Compare(vt1, string(vt2))
@@ -266,6 +281,17 @@ func (c *comparator) Met(ctx Context) (unmet error, err error) {
 				}
 			}
 		}
+	case time.Time:
+		switch vt2 := ov2.Resolved.(type) {
+		case time.Time:
+			compared = true
+			comparison = vt1.Compare(vt2)
+		case string:
+			if ov2.Coerced, ov2.CoercionError = toTime(vt2); ov2.CoercionError == nil {
+				compared = true
+				comparison = vt1.Compare(ov2.Coerced.(time.Time))
+			}
+		}
 	case decimal.Decimal:
 		switch vt2 := ov2.Resolved.(type) {
 		case decimal.Decimal:
@@ -341,6 +367,7 @@ func (c *comparator) String() string {
 // values can be any of:
 //   - primitive type of string, bool, int, int64, float64
 //   - decimal.Decimal
+//   - time.Time (strings are compared as times when they are RFC 3339 or HTTP date format times)
 //   - or anything that is resolvable...
 //
 // examples of resolvable values are: Var, Body, BodyPath, Query, QueryRows, JsonPath, JsonTraverse,
@@ -356,6 +383,7 @@ func ExpectEqual(v1, v2 any) Expectation {
 // values can be any of:
 //   - primitive type of string, bool, int, int64, float64
 //   - decimal.Decimal
+//   - time.Time (strings are compared as times when they are RFC 3339 or HTTP date format times)
 //   - or anything that is resolvable...
 //
 // examples of resolvable values are: Var, Body, BodyPath, Query, QueryRows, JsonPath, JsonTraverse,
@@ -371,6 +399,7 @@ func ExpectNotEqual(v1, v2 any) Expectation {
 // values can be any of:
 //   - primitive type of string, int, int64, float64
 //   - decimal.Decimal
+//   - time.Time (strings are compared as times when they are RFC 3339 or HTTP date format times)
 //   - or anything that is resolvable...
 //
 // examples of resolvable values are: Var, Body, BodyPath, Query, QueryRows, JsonPath, JsonTraverse,
@@ -386,6 +415,7 @@ func ExpectLessThan(v1, v2 any) Expectation {
 // values can be any of:
 //   - primitive type of string, int, int64, float64
 //   - decimal.Decimal
+//   - time.Time (strings are compared as times when they are RFC 3339 or HTTP date format times)
 //   - or anything that is resolvable...
 //
 // examples of resolvable values are: Var, Body, BodyPath, Query, QueryRows, JsonPath, JsonTraverse,
@@ -401,6 +431,7 @@ func ExpectLessThanOrEqual(v1, v2 any) Expectation {
 // values can be any of:
 //   - primitive type of string, int, int64, float64
 //   - decimal.Decimal
+//   - time.Time (strings are compared as times when they are RFC 3339 or HTTP date format times)
 //   - or anything that is resolvable...
 //
 // examples of resolvable values are: Var, Body, BodyPath, Query, QueryRows, JsonPath, JsonTraverse,
@@ -416,6 +447,7 @@ func ExpectGreaterThan(v1, v2 any) Expectation {
 // values can be any of:
 //   - primitive type of string, int, int64, float64
 //   - decimal.Decimal
+//   - time.Time (strings are compared as times when they are RFC 3339 or HTTP date format times)
 //   - or anything that is resolvable...
 //
 // examples of resolvable values are: Var, Body, BodyPath, Query, QueryRows, JsonPath, JsonTraverse,
@@ -431,6 +463,7 @@ func ExpectGreaterThanOrEqual(v1, v2 any) Expectation {
 // values can be any of:
 //   - primitive type of string, int, int64, float64
 //   - decimal.Decimal
+//   - time.Time (strings are compared as times when they are RFC 3339 or HTTP date format times)
 //   - or anything that is resolvable...
 //
 // examples of resolvable values are: Var, Body, BodyPath, Query, QueryRows, JsonPath, JsonTraverse,
@@ -446,6 +479,7 @@ func ExpectNotLessThan(v1, v2 any) Expectation {
 // values can be any of:
 //   - primitive type of string, int, int64, float64
 //   - decimal.Decimal
+//   - time.Time (strings are compared as times when they are RFC 3339 or HTTP date format times)
 //   - or anything that is resolvable...
 //
 // examples of resolvable values are: Var, Body, BodyPath, Query, QueryRows, JsonPath, JsonTraverse,
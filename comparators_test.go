@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func Test_newComparator(t *testing.T) {
@@ -501,6 +502,56 @@ func Test_comparator_Met_Values(t *testing.T) {
 			expectErr:   "cannot compare NOT(1 == \"not a number\") on: v1 (left) = decimal.Decimal, v2 (right) = string",
 			expectV2Err: true,
 		},
+		{
+			v1:       "2025-01-02T03:04:05Z",
+			v2:       "2025-01-02T04:04:05+01:00",
+			comp:     compEqual,
+			expectOk: true,
+		},
+		{
+			v1:       "2025-01-02T03:04:05Z",
+			v2:       "Thu, 02 Jan 2025 03:04:06 GMT",
+			comp:     compLessThan,
+			expectOk: true,
+		},
+		{
+			v1:        "2025-01-02T03:04:05Z",
+			v2:        "2025-01-02T03:04:04Z",
+			comp:      compLessThan,
+			expectErr: "expected \"2025-01-02T03:04:05Z\" < \"2025-01-02T03:04:04Z\"",
+		},
+		{
+			v1:       time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			v2:       "2025-01-02T03:04:05Z",
+			comp:     compEqual,
+			expectOk: true,
+		},
+		{
+			v1:       "2025-01-02T03:04:05Z",
+			v2:       time.Date(2025, 1, 2, 3, 4, 6, 0, time.UTC),
+			comp:     compLessThan,
+			expectOk: true,
+		},
+		{
+			v1:       time.Date(2025, 1, 2, 3, 4, 6, 0, time.UTC),
+			v2:       time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			comp:     compGreaterThan,
+			expectOk: true,
+		},
+		{
+			v1:          time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			v2:          "not a time",
+			comp:        compEqual,
+			expectErr:   "cannot compare 2025-01-02 03:04:05 +0000 UTC == \"not a time\" on: v1 (left) = time.Time, v2 (right) = string",
+			expectV2Err: true,
+		},
+		{
+			v1:          "not a time",
+			v2:          time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			comp:        compEqual,
+			expectErr:   "cannot compare \"not a time\" == 2025-01-02 03:04:05 +0000 UTC on: v1 (left) = string, v2 (right) = time.Time",
+			expectV1Err: true,
+		},
 	}
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
@@ -59,6 +59,12 @@ type Context interface {
 	//
 	// Note: may be nil if the method has not yet been called or the response body was empty
 	CurrentBody() any
+	// CurrentRawBody returns the current raw (undecoded) response body for a method call
+	//
+	// gzip and deflate compressed response bodies are decompressed
+	//
+	// Note: may be nil if the method has not yet been called or the response body was empty
+	CurrentRawBody() []byte
 	// DbInsert performs an insert into a database table
 	//
 	// Note: when only one database is used by tests, the dbName can be ""
@@ -67,6 +73,18 @@ type Context interface {
 	//
 	// Note: when only one database is used by tests, the dbName can be ""
 	DbExec(dbName string, query string, args ...any) error
+	// DbSnapshot takes a named snapshot of a database
+	//
+	// the database must be provided by a supporting image that supports snapshots (see with.ImageDatabaseSnapshots)
+	//
+	// Note: when only one database is used by tests, the dbName can be ""
+	DbSnapshot(dbName string, snapshotName string) error
+	// DbRestoreSnapshot restores a database to a previously taken named snapshot
+	//
+	// the database must be provided by a supporting image that supports snapshots (see with.ImageDatabaseSnapshots)
+	//
+	// Note: when only one database is used by tests, the dbName can be ""
+	DbRestoreSnapshot(dbName string, snapshotName string) error
 	// StoreCookie stores the cookie for later use
 	StoreCookie(cookie *http.Cookie)
 	// GetCookie returns a specific named cookie (or nil if that cookie has not been stored)
@@ -98,15 +116,21 @@ type Context interface {
 	RegisterListener(name string, listener Listener)
 	stopListeners()
 
+	dbInsert(dbName string, tableName string, row Columns, idColumn string) (any, error)
+	oauth2Tokens() *oauth2TokenCache
 	setCurrentEndpoint(Endpoint_)
 	setCurrentMethod(Method_)
 	setCurrentRequest(*http.Request)
 	setCurrentBody(any)
-	doRequest() (*http.Response, bool)
+	setCurrentRawBody([]byte)
+	codecFor(contentType string) common.Codec
+	doRequest(timeout time.Duration) (*http.Response, bool)
+	doGrpcRequest(timeout time.Duration) (*http.Response, bool)
 	reportFailure(err error)
 	reportUnmet(exp Expectation, err error)
 	reportMet(exp Expectation)
 	reportSkipped(exp Expectation)
+	reportGraphQL(query string, operationName string)
 
 	run(name string, r Runnable) bool
 }
@@ -127,9 +151,14 @@ type context struct {
 	currRequest  *http.Request
 	currResponse *http.Response
 	currBody     any
+	currRawBody  []byte
 	cookieJar    map[string]*http.Cookie
 	mockServices map[string]service.MockedService
 	listeners    map[string]Listener
+	tokens       *oauth2TokenCache
+	codecs       codecRegistry
+	grpc         *grpcClient
+	traffic      *trafficRecorder
 	failed       bool
 }
 
@@ -143,6 +172,7 @@ func newContext() *context {
 		httpDo:       http.DefaultClient,
 		mockServices: make(map[string]service.MockedService),
 		listeners:    make(map[string]Listener),
+		codecs:       defaultCodecs(),
 	}
 }
 
@@ -194,6 +224,10 @@ func (c *context) CurrentBody() any {
 	return c.currBody
 }
 
+func (c *context) CurrentRawBody() []byte {
+	return c.currRawBody
+}
+
 func (c *context) SetVar(name Var, value any) {
 	c.vars[name] = value
 }
@@ -202,6 +236,23 @@ func (c *context) ClearVars() {
 	c.vars = make(map[Var]any)
 }
 
+func (c *context) deleteVar(name Var) {
+	delete(c.vars, name)
+}
+
+// preserveVar saves the current value of a variable - returning a func that restores it (or removes the
+// variable if it was not previously set)
+func preserveVar(ctx Context, name Var) (restore func()) {
+	prev, existed := ctx.Vars()[name]
+	return func() {
+		if existed {
+			ctx.SetVar(name, prev)
+		} else if d, ok := ctx.(interface{ deleteVar(Var) }); ok {
+			d.deleteVar(name)
+		}
+	}
+}
+
 type Columns map[string]any
 type RawQuery string
 
@@ -212,10 +263,19 @@ func defaultStr(actual string, def string) string {
 	return def
 }
 
-func (c *context) DbInsert(dbName string, tableName string, row Columns) (err error) {
+func (c *context) DbInsert(dbName string, tableName string, row Columns) error {
+	_, err := c.dbInsert(dbName, tableName, row, "")
+	return err
+}
+
+// dbInsert performs an insert into a database table - and, if the idColumn is specified, returns the generated id
+//
+// the generated id is obtained using "RETURNING" for drivers that use "$" numbered args (e.g. "github.com/lib/pq"),
+// otherwise it is obtained from sql.Result.LastInsertId
+func (c *context) dbInsert(dbName string, tableName string, row Columns, idColumn string) (id any, err error) {
 	tdb := c.dbs[dbName]
 	if tdb == nil {
-		return fmt.Errorf("db name %q not found", dbName)
+		return nil, fmt.Errorf("db name %q not found", dbName)
 	}
 	db := tdb.db
 	argMarkers := tdb.argMarkers
@@ -242,6 +302,9 @@ func (c *context) DbInsert(dbName string, tableName string, row Columns) (err er
 			if rq, err = resolveValueString(string(vt), c); err == nil {
 				markers = append(markers, "("+rq+")")
 			}
+		case nil:
+			addMarker(k)
+			args = append(args, nil)
 		default:
 			to := reflect.TypeOf(v)
 			if to.Kind() == reflect.Map || to.Kind() == reflect.Slice || to.Kind() == reflect.Struct {
@@ -264,9 +327,18 @@ func (c *context) DbInsert(dbName string, tableName string, row Columns) (err er
 	}
 	if err == nil {
 		query := "INSERT INTO " + tableName + " (" + strings.Join(cols, ",") + ") VALUES (" + strings.Join(markers, ",") + ")"
-		_, err = db.Exec(query, args...)
+		if idColumn == "" {
+			_, err = db.Exec(query, args...)
+		} else if argMarkers.Style == common.NumberedDbArgs && defaultStr(argMarkers.Prefix, "$") == "$" {
+			err = db.QueryRow(query+" RETURNING "+idColumn, args...).Scan(&id)
+		} else {
+			var res sql.Result
+			if res, err = db.Exec(query, args...); err == nil {
+				id, err = res.LastInsertId()
+			}
+		}
 	}
-	return err
+	return id, err
 }
 
 func (c *context) DbExec(dbName string, query string, args ...any) (err error) {
@@ -290,6 +362,35 @@ func (c *context) DbExec(dbName string, query string, args ...any) (err error) {
 	return err
 }
 
+func (c *context) DbSnapshot(dbName string, snapshotName string) error {
+	snaps, err := c.dbSnapshots(dbName)
+	if err == nil {
+		err = snaps.TakeSnapshot(snapshotName)
+	}
+	return err
+}
+
+func (c *context) DbRestoreSnapshot(dbName string, snapshotName string) error {
+	snaps, err := c.dbSnapshots(dbName)
+	if err == nil {
+		err = snaps.RestoreSnapshot(snapshotName)
+	}
+	return err
+}
+
+func (c *context) dbSnapshots(dbName string) (with.ImageDatabaseSnapshots, error) {
+	tdb := c.dbs[dbName]
+	if tdb == nil {
+		return nil, fmt.Errorf("db name %q not found", dbName)
+	}
+	for _, img := range c.images {
+		if snaps, ok := img.(with.ImageDatabaseSnapshots); ok && snaps.Database() == tdb.db {
+			return snaps, nil
+		}
+	}
+	return nil, fmt.Errorf("db name %q does not support snapshots", dbName)
+}
+
 func (c *context) StoreCookie(cookie *http.Cookie) {
 	if cookie != nil {
 		c.cookieJar[cookie.Name] = cookie
@@ -358,6 +459,7 @@ func (c *context) setCurrentEndpoint(e Endpoint_) {
 	c.currRequest = nil
 	c.currResponse = nil
 	c.currBody = nil
+	c.currRawBody = nil
 }
 
 func (c *context) setCurrentMethod(m Method_) {
@@ -367,6 +469,7 @@ func (c *context) setCurrentMethod(m Method_) {
 		// reset current response and body when starting a new method (otherwise, leave them available)
 		c.currResponse = nil
 		c.currBody = nil
+		c.currRawBody = nil
 	}
 }
 
@@ -374,10 +477,18 @@ func (c *context) setCurrentBody(body any) {
 	c.currBody = body
 }
 
+func (c *context) setCurrentRawBody(data []byte) {
+	c.currRawBody = data
+}
+
 func (c *context) setCurrentRequest(request *http.Request) {
 	c.currRequest = request
 }
 
+func (c *context) codecFor(contentType string) common.Codec {
+	return c.codecs.lookup(contentType)
+}
+
 func (c *context) Listener(name string) Listener {
 	return c.listeners[name]
 }
@@ -396,39 +507,31 @@ func (c *context) stopListeners() {
 	}
 }
 
+func (c *context) oauth2Tokens() *oauth2TokenCache {
+	if c.tokens == nil {
+		c.tokens = &oauth2TokenCache{tokens: make(map[string]*oauth2CachedToken)}
+	}
+	return c.tokens
+}
+
 func (c *context) DoRequest(req *http.Request) (res *http.Response, err error) {
-	return c.httpDo.Do(req)
+	return c.traffic.wrap(c.httpDo).Do(req)
 }
 
-func (c *context) doRequest() (*http.Response, bool) {
+func (c *context) doRequest(timeout time.Duration) (*http.Response, bool) {
+	do := c.traffic.wrap(withTimeout(c.httpDo, timeout))
 	var err error
 	var dur time.Duration
 	var tt *coverage.TraceTiming
 	if c.traceTimings {
 		tt = &coverage.TraceTiming{}
-		trace := &httptrace.ClientTrace{
-			DNSStart:          func(_ httptrace.DNSStartInfo) { tt.DNSStart = time.Now() },
-			DNSDone:           func(_ httptrace.DNSDoneInfo) { tt.DNSDone = time.Now() },
-			ConnectStart:      func(_, _ string) { tt.ConnStart = time.Now() },
-			ConnectDone:       func(_, _ string, _ error) { tt.ConnDone = time.Now() },
-			TLSHandshakeStart: func() { tt.TLSStart = time.Now() },
-			TLSHandshakeDone:  func(_ tls.ConnectionState, _ error) { tt.TLSDone = time.Now() },
-			GotConn: func(info httptrace.GotConnInfo) {
-				tt.ReusedConn = info.Reused
-			},
-			WroteRequest: func(_ httptrace.WroteRequestInfo) { tt.WroteReq = time.Now() },
-			GotFirstResponseByte: func() {
-				tt.FirstByte = time.Now()
-				tt.TTFB = tt.FirstByte.Sub(tt.Start)
-			},
-		}
-		request := c.currRequest.WithContext(httptrace.WithClientTrace(c.currRequest.Context(), trace))
+		request := c.currRequest.WithContext(httptrace.WithClientTrace(c.currRequest.Context(), newClientTrace(tt)))
 		tt.Start = time.Now()
-		c.currResponse, err = c.httpDo.Do(request)
+		c.currResponse, err = do.Do(request)
 		dur = time.Since(tt.Start)
 	} else {
 		start := time.Now()
-		c.currResponse, err = c.httpDo.Do(c.currRequest)
+		c.currResponse, err = do.Do(c.currRequest)
 		dur = time.Since(start)
 	}
 	if err == nil {
@@ -439,9 +542,55 @@ func (c *context) doRequest() (*http.Response, bool) {
 	return nil, false
 }
 
+// newClientTrace creates a http client trace that collects the trace timings
+func newClientTrace(tt *coverage.TraceTiming) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:          func(_ httptrace.DNSStartInfo) { tt.DNSStart = time.Now() },
+		DNSDone:           func(_ httptrace.DNSDoneInfo) { tt.DNSDone = time.Now() },
+		ConnectStart:      func(_, _ string) { tt.ConnStart = time.Now() },
+		ConnectDone:       func(_, _ string, _ error) { tt.ConnDone = time.Now() },
+		TLSHandshakeStart: func() { tt.TLSStart = time.Now() },
+		TLSHandshakeDone:  func(_ tls.ConnectionState, _ error) { tt.TLSDone = time.Now() },
+		GotConn: func(info httptrace.GotConnInfo) {
+			tt.ReusedConn = info.Reused
+		},
+		WroteRequest: func(_ httptrace.WroteRequestInfo) { tt.WroteReq = time.Now() },
+		GotFirstResponseByte: func() {
+			tt.FirstByte = time.Now()
+			tt.TTFB = tt.FirstByte.Sub(tt.Start)
+		},
+	}
+}
+
+func (c *context) doGrpcRequest(timeout time.Duration) (*http.Response, bool) {
+	if c.grpc == nil {
+		c.grpc = newGrpcClient("", nil, nil)
+	}
+	req := c.currRequest
+	if timeout > 0 {
+		tctx, cancel := gctx.WithTimeout(req.Context(), timeout)
+		defer cancel()
+		req = req.WithContext(tctx)
+	}
+	start := time.Now()
+	res, err := c.grpc.invoke(req)
+	dur := time.Since(start)
+	if err == nil {
+		c.currResponse = res
+		c.coverage.ReportTiming(c.currEndpoint, c.currMethod, c.currRequest, dur, nil)
+		return res, true
+	}
+	c.reportFailure(err)
+	return nil, false
+}
+
 func (c *context) reportFailure(err error) {
 	c.failed = true
-	c.coverage.ReportFailure(c.currEndpoint, c.currMethod, c.currRequest, err)
+	if rbc, ok := c.coverage.(coverage.RawBodyCollector); ok {
+		rbc.ReportFailureWithRawBody(c.currEndpoint, c.currMethod, c.currRequest, c.currRawBody, err)
+	} else {
+		c.coverage.ReportFailure(c.currEndpoint, c.currMethod, c.currRequest, err)
+	}
 	if currT := c.currentTest(); currT != nil {
 		if eerr, ok := err.(Error); ok {
 			currT.Log(eerr.TestFormat())
@@ -453,7 +602,11 @@ func (c *context) reportFailure(err error) {
 }
 
 func (c *context) reportUnmet(exp Expectation, err error) {
-	c.coverage.ReportUnmet(c.currEndpoint, c.currMethod, c.currRequest, exp, err)
+	if rbc, ok := c.coverage.(coverage.RawBodyCollector); ok {
+		rbc.ReportUnmetWithRawBody(c.currEndpoint, c.currMethod, c.currRequest, exp, c.currRawBody, err)
+	} else {
+		c.coverage.ReportUnmet(c.currEndpoint, c.currMethod, c.currRequest, exp, err)
+	}
 	if currT := c.currentTest(); currT != nil {
 		if exp.IsRequired() {
 			c.failed = true
@@ -484,6 +637,12 @@ func (c *context) reportSkipped(exp Expectation) {
 	c.coverage.ReportSkipped(c.currEndpoint, c.currMethod, c.currRequest, exp)
 }
 
+func (c *context) reportGraphQL(query string, operationName string) {
+	if gqc, ok := c.coverage.(coverage.GraphQLCollector); ok {
+		gqc.ReportGraphQL(c.currEndpoint, c.currMethod, query, operationName)
+	}
+}
+
 func (c *context) currentTest() testing.Helper {
 	if c.testing == nil {
 		return nil
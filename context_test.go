@@ -280,6 +280,43 @@ func TestContext_DbExec(t *testing.T) {
 	})
 }
 
+func TestContext_DbSnapshots(t *testing.T) {
+	t.Run("unknown db", func(t *testing.T) {
+		ctx := newContext()
+		err := ctx.DbSnapshot("", "snap")
+		require.Error(t, err)
+		assert.Equal(t, `db name "" not found`, err.Error())
+		err = ctx.DbRestoreSnapshot("", "snap")
+		require.Error(t, err)
+	})
+	t.Run("db not supporting snapshots", func(t *testing.T) {
+		ctx := newContext()
+		db, _, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		ctx.dbs.register("", db, common.DatabaseArgs{})
+		ctx.images["foo"] = &mockImage{}
+		err = ctx.DbSnapshot("", "snap")
+		require.Error(t, err)
+		assert.Equal(t, `db name "" does not support snapshots`, err.Error())
+	})
+	t.Run("successful", func(t *testing.T) {
+		ctx := newContext()
+		db, _, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		ctx.dbs.register("foo", db, common.DatabaseArgs{})
+		img := &mockSnapshotsImage{db: db}
+		ctx.images["foo"] = img
+		err = ctx.DbSnapshot("foo", "snap")
+		require.NoError(t, err)
+		err = ctx.DbRestoreSnapshot("foo", "snap")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"snap"}, img.snapshots)
+		assert.Equal(t, []string{"snap"}, img.restores)
+	})
+}
+
 func TestContext_Currents(t *testing.T) {
 	t.Run("initial empty", func(t *testing.T) {
 		ctx := newContext()
@@ -333,7 +370,7 @@ func TestContext_doRequest(t *testing.T) {
 		ctx.coverage = cov
 
 		ctx.setCurrentRequest(httptest.NewRequest(http.MethodGet, "/", nil))
-		res, ok := ctx.doRequest()
+		res, ok := ctx.doRequest(0)
 		require.True(t, ok)
 		assert.Len(t, cov.Timings, 1)
 		assert.Nil(t, cov.Timings[0].Trace)
@@ -355,7 +392,7 @@ func TestContext_doRequest(t *testing.T) {
 		ctx.coverage = cov
 
 		ctx.setCurrentRequest(httptest.NewRequest(http.MethodGet, "/", nil))
-		_, ok := ctx.doRequest()
+		_, ok := ctx.doRequest(0)
 		require.True(t, ok)
 		assert.Len(t, cov.Timings, 1)
 		assert.NotNil(t, cov.Timings[0].Trace)
@@ -367,7 +404,7 @@ func TestContext_doRequest(t *testing.T) {
 		ctx.coverage = cov
 
 		ctx.setCurrentRequest(httptest.NewRequest(http.MethodGet, "/", nil))
-		_, ok := ctx.doRequest()
+		_, ok := ctx.doRequest(0)
 		require.False(t, ok)
 		assert.Len(t, cov.Failures, 1)
 		assert.Equal(t, `fooey`, cov.Failures[0].Error.Error())
@@ -645,6 +682,7 @@ func newTestContext(vars map[Var]any) *context {
 		cookieJar:    make(map[string]*http.Cookie),
 		mockServices: make(map[string]service.MockedService),
 		listeners:    make(map[string]Listener),
+		codecs:       defaultCodecs(),
 	}
 	for k, v := range vars {
 		result.vars[k] = v
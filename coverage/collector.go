@@ -17,3 +17,12 @@ type Collector interface {
 	ReportTiming(endpoint common.Endpoint, method common.Method, req *http.Request, dur time.Duration, tt *TraceTiming)
 	HasFailures() bool
 }
+
+// RawBodyCollector is an optional interface that a Collector can implement to also receive the raw response body
+// with failures and unmet expectations
+//
+// when a Collector implements this interface, these methods are called instead of ReportFailure and ReportUnmet
+type RawBodyCollector interface {
+	ReportFailureWithRawBody(endpoint common.Endpoint, method common.Method, req *http.Request, rawBody []byte, err error)
+	ReportUnmetWithRawBody(endpoint common.Endpoint, method common.Method, req *http.Request, exp common.Expectation, rawBody []byte, err error)
+}
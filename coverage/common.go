@@ -33,6 +33,8 @@ type Failure struct {
 	Method   common.Method
 	Request  *http.Request
 	Error    error
+	// RawBody is the raw response body (if any) at the time of the failure
+	RawBody []byte
 }
 
 // Unmet provides coverage information about an unmet expectation
@@ -42,6 +44,8 @@ type Unmet struct {
 	Request     *http.Request
 	Expectation common.Expectation
 	Error       error
+	// RawBody is the raw response body (if any) at the time the expectation was unmet
+	RawBody []byte
 }
 
 // Met provides coverage information about a met expectation
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/go-andiamo/chioas"
 	"github.com/go-andiamo/marrow/common"
+	"github.com/vektah/gqlparser/v2/ast"
 	"io"
 	"net/http"
 	"sync"
@@ -21,14 +22,23 @@ func NewCoverage() *Coverage {
 type Coverage struct {
 	Endpoints map[string]*Endpoint
 	OAS       *chioas.Definition
+	// GraphQLSchema is the loaded GraphQL schema (if supplied - see LoadGraphQLSchema)
+	GraphQLSchema *ast.Schema
+	// GraphQLQueries are the reported GraphQL queries
+	GraphQLQueries []GraphQLQuery
 	Common
 	mutex           sync.RWMutex
 	normalizedPaths map[string]map[string]struct{}
 }
 
 var _ Collector = (*Coverage)(nil)
+var _ RawBodyCollector = (*Coverage)(nil)
 
 func (c *Coverage) ReportFailure(endpoint common.Endpoint, method common.Method, req *http.Request, err error) {
+	c.ReportFailureWithRawBody(endpoint, method, req, nil, err)
+}
+
+func (c *Coverage) ReportFailureWithRawBody(endpoint common.Endpoint, method common.Method, req *http.Request, rawBody []byte, err error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	fail := Failure{
@@ -36,6 +46,7 @@ func (c *Coverage) ReportFailure(endpoint common.Endpoint, method common.Method,
 		Method:   method,
 		Request:  requestShallowClone(req),
 		Error:    err,
+		RawBody:  rawBody,
 	}
 	covE, covM := c.add(endpoint, method)
 	if covE != nil {
@@ -48,6 +59,10 @@ func (c *Coverage) ReportFailure(endpoint common.Endpoint, method common.Method,
 }
 
 func (c *Coverage) ReportUnmet(endpoint common.Endpoint, method common.Method, req *http.Request, exp common.Expectation, err error) {
+	c.ReportUnmetWithRawBody(endpoint, method, req, exp, nil, err)
+}
+
+func (c *Coverage) ReportUnmetWithRawBody(endpoint common.Endpoint, method common.Method, req *http.Request, exp common.Expectation, rawBody []byte, err error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	unmet := Unmet{
@@ -56,6 +71,7 @@ func (c *Coverage) ReportUnmet(endpoint common.Endpoint, method common.Method, r
 		Request:     requestShallowClone(req),
 		Expectation: exp,
 		Error:       err,
+		RawBody:     rawBody,
 	}
 	covE, covM := c.add(endpoint, method)
 	if covE != nil {
@@ -39,6 +39,18 @@ func TestCoverage_ReportFailure(t *testing.T) {
 	})
 }
 
+func TestCoverage_ReportWithRawBody(t *testing.T) {
+	cov := NewCoverage()
+	cov.ReportFailureWithRawBody(&testEndpoint{"/"}, &testMethod{"GET"}, nil, []byte("foo"), nil)
+	cov.ReportUnmetWithRawBody(&testEndpoint{"/"}, &testMethod{"GET"}, nil, nil, []byte("bar"), nil)
+	require.Len(t, cov.Failures, 1)
+	assert.Equal(t, []byte("foo"), cov.Failures[0].RawBody)
+	assert.Equal(t, []byte("foo"), cov.Endpoints["/"].Methods["GET"].Failures[0].RawBody)
+	require.Len(t, cov.Unmet, 1)
+	assert.Equal(t, []byte("bar"), cov.Unmet[0].RawBody)
+	assert.Equal(t, []byte("bar"), cov.Endpoints["/"].Methods["GET"].Unmet[0].RawBody)
+}
+
 func TestCoverage_ReportUnmet(t *testing.T) {
 	t.Run("top level", func(t *testing.T) {
 		cov := NewCoverage()
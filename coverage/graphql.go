@@ -0,0 +1,187 @@
+package coverage
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-andiamo/marrow/common"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"io"
+	"strings"
+)
+
+// GraphQLCollector is an optional interface that a Collector can implement to also receive GraphQL operations
+//
+// when a Collector implements this interface, a supplied GraphQL schema (SDL) is loaded with LoadGraphQLSchema
+// and each GraphQL request body (see marrow.GraphQL) is reported with ReportGraphQL
+type GraphQLCollector interface {
+	LoadGraphQLSchema(r io.Reader) error
+	ReportGraphQL(endpoint common.Endpoint, method common.Method, query string, operationName string)
+}
+
+var _ GraphQLCollector = (*Coverage)(nil)
+
+// GraphQLQuery provides coverage information about a reported GraphQL query
+type GraphQLQuery struct {
+	Endpoint      common.Endpoint
+	Method        common.Method
+	Query         string
+	OperationName string
+}
+
+// GraphQLSpec provides coverage information against a supplied GraphQL schema (SDL)
+//
+// operations are the fields of the root types (i.e. queries, mutations and subscriptions) - keyed as "<root type>.<field>", e.g. "Query.users"
+//
+// fields are the fields of all other (object and interface) types - keyed as "<type>.<field>", e.g. "User.name"
+type GraphQLSpec struct {
+	CoveredOperations    map[string]*GraphQLField
+	NonCoveredOperations map[string]*GraphQLField
+	CoveredFields        map[string]*GraphQLField
+	NonCoveredFields     map[string]*GraphQLField
+	// InvalidQueries are reported queries that could not be parsed or validated against the schema
+	InvalidQueries []GraphQLInvalidQuery
+}
+
+// GraphQLField provides coverage information about a GraphQL schema field
+type GraphQLField struct {
+	Type     string
+	Field    string
+	FieldDef *ast.FieldDefinition
+	// Count is the number of reported queries that selected the field
+	Count int
+}
+
+// GraphQLInvalidQuery is a reported query that could not be parsed or validated against the schema
+type GraphQLInvalidQuery struct {
+	GraphQLQuery
+	Error error
+}
+
+func (s *GraphQLSpec) OperationsCovered() (total int, covered int, perc float64) {
+	covered = len(s.CoveredOperations)
+	total = covered + len(s.NonCoveredOperations)
+	perc = float64(covered) / float64(total)
+	return
+}
+
+func (s *GraphQLSpec) FieldsCovered() (total int, covered int, perc float64) {
+	covered = len(s.CoveredFields)
+	total = covered + len(s.NonCoveredFields)
+	perc = float64(covered) / float64(total)
+	return
+}
+
+func (c *Coverage) LoadGraphQLSchema(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err == nil {
+		var schema *ast.Schema
+		if schema, err = gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: string(data)}); err == nil {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			c.GraphQLSchema = schema
+			return nil
+		}
+	}
+	return fmt.Errorf("unable to read GraphQL schema: %w", err)
+}
+
+func (c *Coverage) ReportGraphQL(endpoint common.Endpoint, method common.Method, query string, operationName string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.GraphQLQueries = append(c.GraphQLQueries, GraphQLQuery{
+		Endpoint:      endpoint,
+		Method:        method,
+		Query:         query,
+		OperationName: operationName,
+	})
+}
+
+func (c *Coverage) GraphQLCoverage() (*GraphQLSpec, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if c.GraphQLSchema == nil {
+		return nil, errors.New("graphql schema not supplied")
+	}
+	result := &GraphQLSpec{
+		CoveredOperations:    make(map[string]*GraphQLField),
+		NonCoveredOperations: make(map[string]*GraphQLField),
+		CoveredFields:        make(map[string]*GraphQLField),
+		NonCoveredFields:     make(map[string]*GraphQLField),
+	}
+	roots := make(map[string]struct{}, 3)
+	for _, def := range []*ast.Definition{c.GraphQLSchema.Query, c.GraphQLSchema.Mutation, c.GraphQLSchema.Subscription} {
+		if def != nil {
+			roots[def.Name] = struct{}{}
+		}
+	}
+	for _, def := range c.GraphQLSchema.Types {
+		if def.BuiltIn || strings.HasPrefix(def.Name, "__") || (def.Kind != ast.Object && def.Kind != ast.Interface) {
+			continue
+		}
+		_, isRoot := roots[def.Name]
+		for _, fd := range def.Fields {
+			if strings.HasPrefix(fd.Name, "__") {
+				continue
+			}
+			f := &GraphQLField{Type: def.Name, Field: fd.Name, FieldDef: fd}
+			if isRoot {
+				result.NonCoveredOperations[def.Name+"."+fd.Name] = f
+			} else {
+				result.NonCoveredFields[def.Name+"."+fd.Name] = f
+			}
+		}
+	}
+	for _, q := range c.GraphQLQueries {
+		doc, errs := gqlparser.LoadQuery(c.GraphQLSchema, q.Query)
+		if len(errs) > 0 {
+			result.InvalidQueries = append(result.InvalidQueries, GraphQLInvalidQuery{GraphQLQuery: q, Error: errs})
+			continue
+		}
+		seen := make(map[string]struct{})
+		for _, op := range doc.Operations {
+			if q.OperationName == "" || op.Name == q.OperationName {
+				result.walkSelections(op.SelectionSet, seen)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *GraphQLSpec) walkSelections(selections ast.SelectionSet, seen map[string]struct{}) {
+	for _, sel := range selections {
+		switch st := sel.(type) {
+		case *ast.Field:
+			if st.ObjectDefinition != nil && !strings.HasPrefix(st.Name, "__") {
+				s.cover(st.ObjectDefinition.Name+"."+st.Name, seen)
+			}
+			s.walkSelections(st.SelectionSet, seen)
+		case *ast.InlineFragment:
+			s.walkSelections(st.SelectionSet, seen)
+		case *ast.FragmentSpread:
+			if st.Definition != nil {
+				s.walkSelections(st.Definition.SelectionSet, seen)
+			}
+		}
+	}
+}
+
+// cover marks a field as covered - counting each field once per query
+func (s *GraphQLSpec) cover(key string, seen map[string]struct{}) {
+	if _, ok := seen[key]; ok {
+		return
+	}
+	seen[key] = struct{}{}
+	if f, ok := s.NonCoveredOperations[key]; ok {
+		delete(s.NonCoveredOperations, key)
+		s.CoveredOperations[key] = f
+	} else if f, ok = s.NonCoveredFields[key]; ok {
+		delete(s.NonCoveredFields, key)
+		s.CoveredFields[key] = f
+	}
+	if f, ok := s.CoveredOperations[key]; ok {
+		f.Count++
+	} else if f, ok = s.CoveredFields[key]; ok {
+		f.Count++
+	}
+}
@@ -0,0 +1,138 @@
+package coverage
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+const testGraphQLSchema = `
+type Query {
+	users: [User!]!
+	user(id: ID!): User
+	search(term: String!): [SearchResult!]!
+}
+
+type Mutation {
+	addUser(name: String!): User!
+}
+
+interface Named {
+	name: String!
+}
+
+type User implements Named {
+	id: ID!
+	name: String!
+	email: String
+	address: Address
+}
+
+type Address {
+	street: String
+	city: String
+}
+
+type Group implements Named {
+	name: String!
+	members: [User!]!
+}
+
+union SearchResult = User | Group
+`
+
+func TestCoverage_LoadGraphQLSchema(t *testing.T) {
+	cov := NewCoverage()
+	err := cov.LoadGraphQLSchema(strings.NewReader(testGraphQLSchema))
+	require.NoError(t, err)
+	assert.NotNil(t, cov.GraphQLSchema)
+
+	cov = NewCoverage()
+	err = cov.LoadGraphQLSchema(strings.NewReader(`type Query { foo: Unknown }`))
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "unable to read GraphQL schema: "))
+	assert.Nil(t, cov.GraphQLSchema)
+}
+
+func TestCoverage_ReportGraphQL(t *testing.T) {
+	cov := NewCoverage()
+	cov.ReportGraphQL(&testEndpoint{"/graphql"}, &testMethod{"POST"}, "{ users { name } }", "")
+	require.Len(t, cov.GraphQLQueries, 1)
+	assert.Equal(t, "/graphql", cov.GraphQLQueries[0].Endpoint.Url())
+	assert.Equal(t, "POST", cov.GraphQLQueries[0].Method.MethodName())
+	assert.Equal(t, "{ users { name } }", cov.GraphQLQueries[0].Query)
+	assert.Equal(t, "", cov.GraphQLQueries[0].OperationName)
+}
+
+func TestCoverage_GraphQLCoverage(t *testing.T) {
+	t.Run("no schema", func(t *testing.T) {
+		cov := NewCoverage()
+		_, err := cov.GraphQLCoverage()
+		require.Error(t, err)
+		assert.Equal(t, "graphql schema not supplied", err.Error())
+	})
+	t.Run("no queries", func(t *testing.T) {
+		cov := NewCoverage()
+		require.NoError(t, cov.LoadGraphQLSchema(strings.NewReader(testGraphQLSchema)))
+		spec, err := cov.GraphQLCoverage()
+		require.NoError(t, err)
+		total, covered, perc := spec.OperationsCovered()
+		assert.Equal(t, 4, total)
+		assert.Equal(t, 0, covered)
+		assert.Equal(t, 0.0, perc)
+		total, covered, perc = spec.FieldsCovered()
+		assert.Equal(t, 9, total)
+		assert.Equal(t, 0, covered)
+		assert.Equal(t, 0.0, perc)
+		assert.Contains(t, spec.NonCoveredOperations, "Query.users")
+		assert.Contains(t, spec.NonCoveredOperations, "Mutation.addUser")
+		assert.Contains(t, spec.NonCoveredFields, "Named.name")
+		assert.Contains(t, spec.NonCoveredFields, "Address.city")
+		assert.NotContains(t, spec.NonCoveredFields, "__Type.name")
+	})
+	t.Run("queries", func(t *testing.T) {
+		cov := NewCoverage()
+		require.NoError(t, cov.LoadGraphQLSchema(strings.NewReader(testGraphQLSchema)))
+		cov.ReportGraphQL(nil, nil, `{ users { id name name } }`, "")
+		cov.ReportGraphQL(nil, nil, `query GetUser($id: ID!) { user(id: $id) { ...userFields } }
+fragment userFields on User { name address { city } }
+query Other { search(term: "x") { __typename } }`, "GetUser")
+		cov.ReportGraphQL(nil, nil, `{ search(term: "x") { ... on Group { members { email } } ... on Named { name } } }`, "")
+		cov.ReportGraphQL(nil, nil, `mutation { addUser(name: "x") { id } }`, "")
+		cov.ReportGraphQL(nil, nil, `{ users { unknown } }`, "")
+		cov.ReportGraphQL(nil, nil, `{ users {`, "")
+		spec, err := cov.GraphQLCoverage()
+		require.NoError(t, err)
+
+		total, covered, perc := spec.OperationsCovered()
+		assert.Equal(t, 4, total)
+		assert.Equal(t, 4, covered)
+		assert.Equal(t, 1.0, perc)
+		assert.Equal(t, 1, spec.CoveredOperations["Query.users"].Count)
+		assert.Equal(t, 1, spec.CoveredOperations["Query.user"].Count)
+		assert.Equal(t, 1, spec.CoveredOperations["Query.search"].Count)
+		assert.Equal(t, "Mutation", spec.CoveredOperations["Mutation.addUser"].Type)
+		assert.Equal(t, "addUser", spec.CoveredOperations["Mutation.addUser"].Field)
+		assert.NotNil(t, spec.CoveredOperations["Mutation.addUser"].FieldDef)
+
+		total, covered, _ = spec.FieldsCovered()
+		assert.Equal(t, 9, total)
+		assert.Equal(t, 7, covered)
+		assert.Equal(t, 2, spec.CoveredFields["User.id"].Count)
+		assert.Equal(t, 2, spec.CoveredFields["User.name"].Count)
+		assert.Equal(t, 1, spec.CoveredFields["User.email"].Count)
+		assert.Equal(t, 1, spec.CoveredFields["User.address"].Count)
+		assert.Equal(t, 1, spec.CoveredFields["Address.city"].Count)
+		assert.Equal(t, 1, spec.CoveredFields["Group.members"].Count)
+		assert.Equal(t, 1, spec.CoveredFields["Named.name"].Count)
+		assert.Contains(t, spec.NonCoveredFields, "Address.street")
+		assert.Contains(t, spec.NonCoveredFields, "Group.name")
+
+		require.Len(t, spec.InvalidQueries, 2)
+		assert.Equal(t, `{ users { unknown } }`, spec.InvalidQueries[0].Query)
+		assert.Error(t, spec.InvalidQueries[0].Error)
+		assert.Equal(t, `{ users {`, spec.InvalidQueries[1].Query)
+		assert.Error(t, spec.InvalidQueries[1].Error)
+	})
+}
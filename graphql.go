@@ -0,0 +1,179 @@
+package marrow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-andiamo/marrow/framing"
+	"strings"
+)
+
+// GraphQLBodyValue is the type returned by GraphQL - a GraphQL request body
+type GraphQLBodyValue struct {
+	Query         string
+	Variables     any
+	OperationName string
+}
+
+// GraphQL creates a GraphQLBodyValue for use as Method_.RequestBody
+//
+// the request body is the standard GraphQL json request - i.e. {"query": ..., "variables": ..., "operationName": ...}
+// and the request content type is "application/json"
+//
+// the variables (or resolved variables - including nested Var, TemplateString etc.) can be nil (and the operationName can be "")
+//
+// the query is also reported to coverage - so that, when a GraphQL schema is supplied (see with.GraphQLSchema),
+// coverage can report which operations and fields were exercised (see coverage.Coverage.GraphQLCoverage)
+func GraphQL(query string, variables any, operationName string) GraphQLBodyValue {
+	return GraphQLBodyValue{
+		Query:         query,
+		Variables:     variables,
+		OperationName: operationName,
+	}
+}
+
+func (v GraphQLBodyValue) encodeBody(ctx Context) (data []byte, contentType string, err error) {
+	body := map[string]any{"query": v.Query}
+	if v.Variables != nil {
+		var av any
+		if av, err = ResolveValue(v.Variables, ctx); err != nil {
+			return nil, "", err
+		}
+		body["variables"] = av
+	}
+	if v.OperationName != "" {
+		body["operationName"] = v.OperationName
+	}
+	data, err = json.Marshal(body)
+	return data, "application/json", err
+}
+
+// reportGraphQL reports the query of a GraphQL request body to coverage (once per method run - rather than each time
+// the body is encoded, e.g. for retries and auth cases)
+func (m *method) reportGraphQL(ctx Context) {
+	if m.body != nil && !m.authCase {
+		if av, err := ResolveValue(m.body, ctx); err == nil {
+			if v, ok := av.(GraphQLBodyValue); ok {
+				ctx.reportGraphQL(v.Query, v.OperationName)
+			}
+		}
+	}
+}
+
+// GraphQLDataValue is the type returned by GraphQLData
+type GraphQLDataValue struct {
+	Path string
+}
+
+// GraphQLData resolves to the value at the path in the "data" of the current Context (GraphQL) response body
+//
+// the path is a json path (see JsonPath) - an empty path resolves to the whole "data"
+func GraphQLData(path string) GraphQLDataValue {
+	return GraphQLDataValue{Path: path}
+}
+
+func (v GraphQLDataValue) String() string {
+	return fmt.Sprintf("GraphQLData(%q)", v.Path)
+}
+
+func (v GraphQLDataValue) ResolveValue(ctx Context) (av any, err error) {
+	if m, ok := ctx.CurrentBody().(map[string]any); ok {
+		return resolveJsonPath(m["data"], v.Path)
+	}
+	return nil, errors.New("response body is not a graphql response")
+}
+
+// GraphQLErrorsValue is the type for GraphQLErrors
+type GraphQLErrorsValue string
+
+const (
+	// GraphQLErrors resolves to the "errors" of the current Context (GraphQL) response body - an empty slice if there are no errors
+	GraphQLErrors GraphQLErrorsValue = "GraphQLErrors"
+)
+
+func (GraphQLErrorsValue) ResolveValue(ctx Context) (any, error) {
+	if m, ok := ctx.CurrentBody().(map[string]any); ok {
+		if errs, ok := m["errors"].([]any); ok {
+			return errs, nil
+		}
+		return []any{}, nil
+	}
+	return nil, errors.New("response body is not a graphql response")
+}
+
+func (GraphQLErrorsValue) String() string {
+	return string(GraphQLErrors)
+}
+
+// GraphQLErrorMessages is the list of error messages reported (as the unmet error actual value) by ExpectNoGraphQLErrors
+type GraphQLErrorMessages []string
+
+func (m GraphQLErrorMessages) stringify() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d errors", len(m)))
+	for _, line := range m {
+		b.WriteString("\n\t          \t" + line)
+	}
+	return b.String()
+}
+
+type expectNoGraphQLErrors struct {
+	frame *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*expectNoGraphQLErrors)(nil)
+
+// ExpectNoGraphQLErrors asserts that the current Context (GraphQL) response body has no "errors"
+//
+// if unmet, the unmet error actual value is a GraphQLErrorMessages (listing the error messages - with paths, where present)
+//
+//go:noinline
+func ExpectNoGraphQLErrors() Expectation {
+	return &expectNoGraphQLErrors{
+		frame: framing.NewFrame(0),
+	}
+}
+
+func (e *expectNoGraphQLErrors) Name() string {
+	return "Expect No GraphQL Errors"
+}
+
+func (e *expectNoGraphQLErrors) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *expectNoGraphQLErrors) Met(ctx Context) (unmet error, err error) {
+	var av any
+	if av, err = GraphQLErrors.ResolveValue(ctx); err == nil {
+		if errs := av.([]any); len(errs) > 0 {
+			msgs := make(GraphQLErrorMessages, 0, len(errs))
+			for _, ge := range errs {
+				msgs = append(msgs, graphQLErrorMessage(ge))
+			}
+			unmet = &unmetError{
+				msg:      fmt.Sprintf("expected no graphql errors - found %d", len(errs)),
+				name:     e.Name(),
+				expected: OperandValue{Original: nil},
+				actual:   OperandValue{Original: GraphQLErrors, Resolved: msgs},
+				frame:    e.frame,
+			}
+		}
+	}
+	return
+}
+
+func graphQLErrorMessage(ge any) string {
+	if m, ok := ge.(map[string]any); ok {
+		msg := fmt.Sprintf("%v", m["message"])
+		if path, ok := m["path"].([]any); ok && len(path) > 0 {
+			parts := make([]string, len(path))
+			for i, p := range path {
+				parts[i] = fmt.Sprintf("%v", p)
+			}
+			msg += " (path: " + strings.Join(parts, ".") + ")"
+		}
+		return msg
+	}
+	return fmt.Sprintf("%v", ge)
+}
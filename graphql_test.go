@@ -0,0 +1,149 @@
+package marrow
+
+import (
+	"github.com/go-andiamo/marrow/coverage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestGraphQL_EncodeBody(t *testing.T) {
+	cov := coverage.NewCoverage()
+	ctx := newTestContext(map[Var]any{"id": "123"})
+	ctx.coverage = cov
+	data, ct, err := GraphQL("query GetUser($id: ID!) { user(id: $id) { name } }", JSON{"id": Var("id")}, "GetUser").encodeBody(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", ct)
+	assert.JSONEq(t, `{"query":"query GetUser($id: ID!) { user(id: $id) { name } }","variables":{"id":"123"},"operationName":"GetUser"}`, string(data))
+	// encoding the body does not report to coverage (see method.reportGraphQL)...
+	assert.Empty(t, cov.GraphQLQueries)
+
+	data, _, err = GraphQL("{ users { name } }", nil, "").encodeBody(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"query":"{ users { name } }"}`, string(data))
+
+	_, _, err = GraphQL("{ users { name } }", Var("missing"), "").encodeBody(ctx)
+	require.Error(t, err)
+}
+
+func TestGraphQL_Method(t *testing.T) {
+	var reqBody []byte
+	ctx := newTestContext(nil)
+	cov := coverage.NewCoverage()
+	ctx.coverage = cov
+	ctx.httpDo = doFunc(func(req *http.Request) (*http.Response, error) {
+		reqBody, _ = io.ReadAll(req.Body)
+		return (&dummyDo{
+			status: http.StatusOK,
+			body:   []byte(`{"data":{"users":[{"name":"Bilbo"}]}}`),
+			hdrs:   map[string]string{"Content-Type": "application/json"},
+		}).Do(req)
+	})
+	ctx.currEndpoint = Endpoint("/graphql", "")
+	m := Method(POST, "").
+		RequestBody(GraphQL("{ users { name } }", nil, "")).
+		AssertNoGraphQLErrors().
+		AssertEqual(GraphQLData("users[0].name"), "Bilbo")
+	err := m.Run(ctx)
+	require.NoError(t, err)
+	assert.False(t, ctx.failed)
+	assert.Len(t, cov.Met, 2)
+	assert.Len(t, cov.GraphQLQueries, 1)
+	assert.Equal(t, `{"query":"{ users { name } }"}`, string(reqBody))
+}
+
+func TestGraphQL_ReportedOncePerMethodRun(t *testing.T) {
+	ctx := newTestContext(map[Var]any{"body": GraphQL("query GetUsers { users { name } }", nil, "GetUsers")})
+	cov := coverage.NewCoverage()
+	ctx.coverage = cov
+	ctx.httpDo = &dummyDo{
+		status: http.StatusOK,
+		body:   []byte(`{"data":{"users":[]}}`),
+		hdrs:   map[string]string{"Content-Type": "application/json"},
+	}
+	ctx.currEndpoint = Endpoint("/graphql", "")
+	m := Method(POST, "").
+		RequestBody(Var("body")).
+		AuthMatrix(AuthCase{Name: "anon", Status: http.StatusOK}, AuthCase{Name: "other", Auth: "other", Status: http.StatusOK}).
+		AssertOK()
+	err := m.Run(ctx)
+	require.NoError(t, err)
+	assert.False(t, ctx.failed)
+	assert.Len(t, cov.Met, 3)
+	require.Len(t, cov.GraphQLQueries, 1)
+	assert.Equal(t, "GetUsers", cov.GraphQLQueries[0].OperationName)
+}
+
+func TestGraphQLData(t *testing.T) {
+	ctx := newTestContext(nil)
+	_, err := GraphQLData("user").ResolveValue(ctx)
+	require.Error(t, err)
+	assert.Equal(t, "response body is not a graphql response", err.Error())
+
+	ctx.currBody = map[string]any{"data": map[string]any{"user": map[string]any{"name": "Bilbo"}}}
+	av, err := GraphQLData("user.name").ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Bilbo", av)
+	av, err = GraphQLData("").ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"user": map[string]any{"name": "Bilbo"}}, av)
+	_, err = GraphQLData("user.age").ResolveValue(ctx)
+	require.Error(t, err)
+
+	ctx.currBody = map[string]any{"data": nil}
+	_, err = GraphQLData("user").ResolveValue(ctx)
+	require.Error(t, err)
+	assert.Equal(t, `GraphQLData("user")`, GraphQLData("user").String())
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	ctx := newTestContext(nil)
+	_, err := GraphQLErrors.ResolveValue(ctx)
+	require.Error(t, err)
+
+	ctx.currBody = map[string]any{"data": nil}
+	av, err := GraphQLErrors.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []any{}, av)
+
+	ctx.currBody = map[string]any{"errors": []any{map[string]any{"message": "foo"}}}
+	av, err = GraphQLErrors.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Len(t, av, 1)
+	assert.Equal(t, "GraphQLErrors", GraphQLErrors.String())
+}
+
+func TestExpectNoGraphQLErrors(t *testing.T) {
+	ctx := newTestContext(nil)
+	ctx.currBody = map[string]any{"data": map[string]any{}}
+	exp := ExpectNoGraphQLErrors()
+	assert.Equal(t, "Expect No GraphQL Errors", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	assert.False(t, exp.IsRequired())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	ctx.currBody = map[string]any{
+		"data": nil,
+		"errors": []any{
+			map[string]any{"message": "user not found", "path": []any{"user", 0, "name"}},
+			map[string]any{"message": "forbidden"},
+			"odd error",
+		},
+	}
+	unmet, err = exp.Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected no graphql errors - found 3", unmet.Error())
+	msgs, ok := unmet.(UnmetError).Actual().Resolved.(GraphQLErrorMessages)
+	require.True(t, ok)
+	assert.Equal(t, GraphQLErrorMessages{"user not found (path: user.0.name)", "forbidden", "odd error"}, msgs)
+	assert.Equal(t, "3 errors\n\t          \tuser not found (path: user.0.name)\n\t          \tforbidden\n\t          \todd error", msgs.stringify())
+
+	ctx.currBody = "not a graphql response"
+	_, err = exp.Met(ctx)
+	require.Error(t, err)
+}
@@ -0,0 +1,458 @@
+package marrow
+
+import (
+	"bytes"
+	gctx "context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GrpcMethod instantiates a new gRPC method test
+//
+// the fullMethod arg is the gRPC service and method, e.g. "mypackage.MyService/GetThing" - if the fullMethod
+// arg does not contain a service (e.g. just "GetThing"), the service is taken from the current Endpoint url
+// (i.e. Endpoint("/mypackage.MyService", "", GrpcMethod("GetThing", "")))
+//
+// desc arg is the description of the method test
+//
+// ops args are any before/after operations to be run as part of the method test
+//
+// the gRPC method test works like any other method test:
+//   - the request message is built from the request body (see Method_.RequestBody) - e.g. a JSON value - using the protobuf json mapping
+//   - request headers (see Method_.RequestHeader, Method_.AuthHeader etc.) are sent as request metadata
+//   - the response message is available as Body (using the protobuf json mapping)
+//   - the gRPC status is available as GrpcStatus and GrpcMessage, and response metadata as GrpcMetadata
+//   - the StatusCode is the http equivalent of the gRPC status (e.g. codes.NotFound is 404)
+//
+// the service and message descriptors are found in the descriptors supplied to the suite (see with.Grpc), or
+// the global proto registry or, failing those, by server reflection
+//
+// Note: only unary gRPC methods are supported
+//
+//go:noinline
+func GrpcMethod(fullMethod string, desc string, ops ...BeforeAfter) Method_ {
+	result := newMethod(POST, desc, ops...).(*method)
+	result.grpc = fullMethod
+	return result
+}
+
+const (
+	hdrGrpcStatus  = "Grpc-Status"
+	hdrGrpcMessage = "Grpc-Message"
+)
+
+// grpcFullMethod returns the full method path (e.g. "/mypackage.MyService/GetThing") for a gRPC method
+func (m *method) grpcFullMethod(ctx Context) string {
+	fm := strings.TrimPrefix(m.grpc, "/")
+	if svc := strings.Trim(ctx.CurrentUrl(), "/"); svc != "" && !strings.Contains(fm, "/") {
+		fm = svc + "/" + fm
+	}
+	return "/" + fm
+}
+
+type grpcClient struct {
+	target      string
+	tlsConfig   *tls.Config
+	files       *protoregistry.Files
+	conns       map[string]*grpc.ClientConn
+	reflections map[string]*protoregistry.Files
+	mutex       sync.Mutex
+}
+
+func newGrpcClient(target string, tlsConfig *tls.Config, descriptors []protoreflect.FileDescriptor) *grpcClient {
+	result := &grpcClient{
+		target:      target,
+		tlsConfig:   tlsConfig,
+		files:       new(protoregistry.Files),
+		conns:       make(map[string]*grpc.ClientConn),
+		reflections: make(map[string]*protoregistry.Files),
+	}
+	for _, fd := range descriptors {
+		if fd != nil {
+			_ = result.files.RegisterFile(fd)
+		}
+	}
+	return result
+}
+
+func (gc *grpcClient) close() {
+	if gc != nil {
+		gc.mutex.Lock()
+		defer gc.mutex.Unlock()
+		for k, conn := range gc.conns {
+			_ = conn.Close()
+			delete(gc.conns, k)
+		}
+	}
+}
+
+// conn returns a (cached) client connection - the target is the suite gRPC target or, if that is not set, the request host
+func (gc *grpcClient) conn(req *http.Request) (*grpc.ClientConn, error) {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+	target := gc.target
+	secure := false
+	if target == "" {
+		target = req.URL.Host
+		secure = req.URL.Scheme == "https"
+	} else if after, ok := strings.CutPrefix(target, "https://"); ok {
+		target = after
+		secure = true
+	} else {
+		target = strings.TrimPrefix(target, "http://")
+	}
+	if conn, ok := gc.conns[target]; ok {
+		return conn, nil
+	}
+	creds := insecure.NewCredentials()
+	if secure {
+		tlsConfig := &tls.Config{}
+		if gc.tlsConfig != nil {
+			tlsConfig = gc.tlsConfig.Clone()
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err == nil {
+		gc.conns[target] = conn
+	}
+	return conn, err
+}
+
+// methodDescriptor finds the descriptor for a full method - looking in the supplied descriptors, the global registry and then server reflection
+func (gc *grpcClient) methodDescriptor(ctx gctx.Context, conn *grpc.ClientConn, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	svcName, methName, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok || svcName == "" || methName == "" {
+		return nil, fmt.Errorf("invalid grpc method %q", fullMethod)
+	}
+	var sd protoreflect.ServiceDescriptor
+	if d, err := (grpcResolver{gc.files, protoregistry.GlobalFiles}).FindDescriptorByName(protoreflect.FullName(svcName)); err == nil {
+		sd, _ = d.(protoreflect.ServiceDescriptor)
+	}
+	if sd == nil {
+		files, err := gc.reflect(ctx, conn, svcName)
+		if err != nil {
+			return nil, err
+		}
+		d, err := files.FindDescriptorByName(protoreflect.FullName(svcName))
+		if err != nil {
+			return nil, err
+		}
+		if sd, ok = d.(protoreflect.ServiceDescriptor); !ok {
+			return nil, fmt.Errorf("grpc %q is not a service", svcName)
+		}
+	}
+	md := sd.Methods().ByName(protoreflect.Name(methName))
+	if md == nil {
+		return nil, fmt.Errorf("grpc service %q has no method %q", svcName, methName)
+	} else if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("grpc streaming method %q not supported", fullMethod)
+	}
+	return md, nil
+}
+
+// reflect obtains the file descriptors for a service using server reflection
+func (gc *grpcClient) reflect(ctx gctx.Context, conn *grpc.ClientConn, svcName string) (*protoregistry.Files, error) {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+	if files, ok := gc.reflections[svcName]; ok {
+		return files, nil
+	}
+	rctx, cancel := gctx.WithCancel(ctx)
+	defer cancel()
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(rctx)
+	if err != nil {
+		return nil, err
+	}
+	protos := make(map[string]*descriptorpb.FileDescriptorProto)
+	request := func(req *rpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return err
+		}
+		res, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if er := res.GetErrorResponse(); er != nil {
+			return fmt.Errorf("grpc reflection error: %s", er.GetErrorMessage())
+		}
+		for _, data := range res.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fdp := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(data, fdp); err != nil {
+				return err
+			}
+			protos[fdp.GetName()] = fdp
+		}
+		return nil
+	}
+	if err = request(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: svcName},
+	}); err != nil {
+		return nil, err
+	}
+	// fetch any missing dependencies...
+	for missing := true; missing; {
+		missing = false
+		for _, fdp := range protos {
+			for _, dep := range fdp.GetDependency() {
+				if _, ok := protos[dep]; !ok {
+					if _, err := protoregistry.GlobalFiles.FindFileByPath(dep); err != nil {
+						if err = request(&rpb.ServerReflectionRequest{
+							MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+						}); err != nil {
+							return nil, err
+						} else if _, ok = protos[dep]; !ok {
+							return nil, fmt.Errorf("grpc reflection did not provide file %q", dep)
+						}
+						missing = true
+						break
+					}
+				}
+			}
+			if missing {
+				break
+			}
+		}
+	}
+	_ = stream.CloseSend()
+	files := new(protoregistry.Files)
+	var register func(name string) error
+	register = func(name string) error {
+		fdp, ok := protos[name]
+		if !ok {
+			return nil
+		}
+		if _, err := files.FindFileByPath(name); err == nil {
+			return nil
+		}
+		for _, dep := range fdp.GetDependency() {
+			if err := register(dep); err != nil {
+				return err
+			}
+		}
+		fd, err := protodesc.NewFile(fdp, grpcResolver{files, protoregistry.GlobalFiles})
+		if err == nil {
+			err = files.RegisterFile(fd)
+		}
+		return err
+	}
+	for name := range protos {
+		if err = register(name); err != nil {
+			return nil, err
+		}
+	}
+	gc.reflections[svcName] = files
+	return files, nil
+}
+
+// grpcResolver resolves descriptors from multiple registries (first found wins)
+type grpcResolver []*protoregistry.Files
+
+var _ protodesc.Resolver = grpcResolver{}
+
+func (r grpcResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	for _, files := range r {
+		if fd, err := files.FindFileByPath(path); err == nil {
+			return fd, nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+func (r grpcResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	for _, files := range r {
+		if d, err := files.FindDescriptorByName(name); err == nil {
+			return d, nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+// invoke performs the gRPC call for the (built) request - the response is a http equivalent of the gRPC response
+func (gc *grpcClient) invoke(req *http.Request) (*http.Response, error) {
+	conn, err := gc.conn(req)
+	if err != nil {
+		return nil, err
+	}
+	md, err := gc.methodDescriptor(req.Context(), conn, req.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+	reqMsg := dynamicpb.NewMessage(md.Input())
+	if req.Body != nil {
+		var data []byte
+		if data, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		} else if len(bytes.TrimSpace(data)) > 0 {
+			if err = protojson.Unmarshal(data, reqMsg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	outMd := metadata.MD{}
+	for k, vs := range req.Header {
+		switch k {
+		case hdrContentType, "Content-Length", "User-Agent":
+		default:
+			outMd.Append(strings.ToLower(k), vs...)
+		}
+	}
+	resMsg := dynamicpb.NewMessage(md.Output())
+	var hdrMd, trlMd metadata.MD
+	callCtx := metadata.NewOutgoingContext(req.Context(), outMd)
+	err = conn.Invoke(callCtx, req.URL.Path, reqMsg, resMsg, grpc.Header(&hdrMd), grpc.Trailer(&trlMd))
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil, err
+	}
+	var body []byte
+	if st.Code() == codes.OK {
+		if body, err = (protojson.MarshalOptions{EmitUnpopulated: true}).Marshal(resMsg); err != nil {
+			return nil, err
+		}
+	}
+	httpStatus := grpcHttpStatus(st.Code())
+	res := &http.Response{
+		Status:        strconv.Itoa(httpStatus) + " " + http.StatusText(httpStatus),
+		StatusCode:    httpStatus,
+		Proto:         "HTTP/2.0",
+		ProtoMajor:    2,
+		Header:        grpcMetadataHeader(hdrMd),
+		Trailer:       grpcMetadataHeader(trlMd),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+	res.Header.Set(hdrContentType, "application/json")
+	res.Trailer.Set(hdrGrpcStatus, strconv.Itoa(int(st.Code())))
+	res.Trailer.Set(hdrGrpcMessage, st.Message())
+	return res, nil
+}
+
+func grpcMetadataHeader(md metadata.MD) http.Header {
+	result := make(http.Header, len(md))
+	for k, vs := range md {
+		for _, v := range vs {
+			result.Add(k, v)
+		}
+	}
+	return result
+}
+
+// grpcHttpStatus maps a gRPC status code to the equivalent http status code
+func grpcHttpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
+// GrpcStatusValue is the type for GrpcStatus
+type GrpcStatusValue string
+
+const (
+	// GrpcStatus resolves to the gRPC status code (as an int) of the current Context response
+	//
+	// Note: only available for gRPC method tests (see GrpcMethod)
+	GrpcStatus GrpcStatusValue = "GrpcStatus"
+)
+
+func (GrpcStatusValue) ResolveValue(ctx Context) (any, error) {
+	if v, err := grpcTrailer(ctx, hdrGrpcStatus); err != nil {
+		return nil, err
+	} else {
+		return strconv.Atoi(v)
+	}
+}
+
+func (GrpcStatusValue) String() string {
+	return string(GrpcStatus)
+}
+
+// GrpcMessageValue is the type for GrpcMessage
+type GrpcMessageValue string
+
+const (
+	// GrpcMessage resolves to the gRPC status message of the current Context response
+	//
+	// Note: only available for gRPC method tests (see GrpcMethod)
+	GrpcMessage GrpcMessageValue = "GrpcMessage"
+)
+
+func (GrpcMessageValue) ResolveValue(ctx Context) (any, error) {
+	return grpcTrailer(ctx, hdrGrpcMessage)
+}
+
+func (GrpcMessageValue) String() string {
+	return string(GrpcMessage)
+}
+
+// GrpcMetadata is a type that will resolve to the specified metadata value in the current Context (gRPC) response
+//
+// example:
+//
+//	GrpcMetadata("x-request-id")
+//
+// will resolve to the "x-request-id" header (or, if not in the header, trailer) metadata value in the current Context response
+type GrpcMetadata string
+
+func (v GrpcMetadata) ResolveValue(ctx Context) (av any, err error) {
+	if response := ctx.CurrentResponse(); response == nil {
+		return nil, errors.New("response is nil")
+	} else if vs := response.Header.Values(string(v)); len(vs) > 0 {
+		return vs[0], nil
+	} else {
+		return response.Trailer.Get(string(v)), nil
+	}
+}
+
+func grpcTrailer(ctx Context, name string) (string, error) {
+	if response := ctx.CurrentResponse(); response == nil {
+		return "", errors.New("response is nil")
+	} else if vs := response.Trailer.Values(name); len(vs) == 0 {
+		return "", errors.New("response is not a grpc response")
+	} else {
+		return vs[0], nil
+	}
+}
@@ -0,0 +1,401 @@
+package marrow
+
+import (
+	gctx "context"
+	"crypto/tls"
+	"github.com/go-andiamo/marrow/coverage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGrpcMethod(t *testing.T) {
+	host := startTestGrpcServer(t, true)
+	t.Run("reflection", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, map[Var]any{"id": "123"})
+		m := GrpcMethod("marrow.test.Things/GetThing", "get thing").
+			RequestBody(JSON{"id": Var("id")}).
+			RequestHeader("Authorization", "Bearer foo").
+			AssertOK().
+			AssertEqual(GrpcStatus, 0).
+			AssertEqual(GrpcMessage, "").
+			AssertEqual(JsonPath(Body, "id"), "123").
+			AssertEqual(JsonPath(Body, "name"), "Bearer foo").
+			AssertEqual(JsonPath(Body, "count"), 0).
+			AssertEqual(GrpcMetadata("x-request-id"), "req-123").
+			AssertEqual(GrpcMetadata("x-trailer"), "trailed")
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		err := m.Run(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, cov.Failures)
+		assert.Empty(t, cov.Unmet)
+		assert.Len(t, cov.Met, 8)
+		assert.Len(t, cov.Timings, 1)
+		assert.Equal(t, "/marrow.test.Things/GetThing", cov.Timings[0].Request.URL.Path)
+	})
+	t.Run("error status", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, nil)
+		m := GrpcMethod("/marrow.test.Things/GetThing", "").
+			RequestBody(JSON{"id": "missing"}).
+			Timeout(5*time.Second).
+			AssertNotFound().
+			AssertEqual(GrpcStatus, int(codes.NotFound)).
+			AssertEqual(GrpcMessage, "thing not found").
+			AssertNil(Body)
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		err := m.Run(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, cov.Failures)
+		assert.Empty(t, cov.Unmet)
+		assert.Len(t, cov.Met, 4)
+	})
+	t.Run("endpoint service", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, nil)
+		e := Endpoint("/marrow.test.Things", "things",
+			GrpcMethod("GetThing", "").
+				RequestBody(JSON{"id": "abc"}).
+				AssertEqual(JsonPath(Body, "id"), "abc"),
+		)
+		err := e.Run(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, cov.Failures)
+		assert.Len(t, cov.Met, 1)
+		require.Len(t, cov.Endpoints, 1)
+		covE := cov.Endpoints["/marrow.test.Things"]
+		require.NotNil(t, covE)
+		_, ok := covE.Methods["GRPC GetThing"]
+		assert.True(t, ok)
+	})
+	t.Run("auth matrix", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, nil)
+		m := GrpcMethod("marrow.test.Things/GetThing", "").
+			RequestBody(JSON{"id": "auth"}).
+			AuthMatrix(
+				AuthCase{Name: "none", Status: http.StatusUnauthorized},
+				AuthCase{Name: "forbidden", Auth: "forbidden", Status: http.StatusForbidden},
+			).
+			AuthHeader(BearerAuth, "foo").
+			AssertOK()
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		err := m.Run(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, cov.Failures)
+		assert.Empty(t, cov.Unmet)
+		assert.Len(t, cov.Met, 3)
+	})
+	t.Run("unknown method", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, nil)
+		m := GrpcMethod("marrow.test.Things/Unknown", "").AssertOK()
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		err := m.Run(ctx)
+		require.NoError(t, err)
+		require.Len(t, cov.Failures, 1)
+		assert.Equal(t, `grpc service "marrow.test.Things" has no method "Unknown"`, cov.Failures[0].Error.Error())
+	})
+	t.Run("unknown service", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, nil)
+		m := GrpcMethod("marrow.test.Unknown/GetThing", "").AssertOK()
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		err := m.Run(ctx)
+		require.NoError(t, err)
+		require.Len(t, cov.Failures, 1)
+	})
+	t.Run("invalid method", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, nil)
+		m := GrpcMethod("GetThing", "").AssertOK()
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		err := m.Run(ctx)
+		require.NoError(t, err)
+		require.Len(t, cov.Failures, 1)
+		assert.Equal(t, `invalid grpc method "/GetThing"`, cov.Failures[0].Error.Error())
+	})
+	t.Run("invalid request body", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, nil)
+		m := GrpcMethod("marrow.test.Things/GetThing", "").
+			RequestBody(JSON{"unknown": "abc"}).
+			AssertOK()
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		err := m.Run(ctx)
+		require.NoError(t, err)
+		require.Len(t, cov.Failures, 1)
+	})
+}
+
+func TestGrpcMethod_SuppliedDescriptors(t *testing.T) {
+	host := startTestGrpcServer(t, false)
+	t.Run("supplied", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, nil)
+		ctx.grpc = newGrpcClient(host, nil, []protoreflect.FileDescriptor{testGrpcFile(t)})
+		m := GrpcMethod("marrow.test.Things/GetThing", "").
+			RequestBody(JSON{"id": "abc"}).
+			AssertOK().
+			AssertEqual(JsonPath(Body, "id"), "abc")
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		err := m.Run(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, cov.Failures)
+		assert.Len(t, cov.Met, 2)
+		ctx.grpc.close()
+		assert.Empty(t, ctx.grpc.conns)
+	})
+	t.Run("not supplied (no reflection)", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, nil)
+		m := GrpcMethod("marrow.test.Things/GetThing", "").AssertOK()
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		err := m.Run(ctx)
+		require.NoError(t, err)
+		require.Len(t, cov.Failures, 1)
+	})
+}
+
+func TestGrpcMethod_TLS(t *testing.T) {
+	// borrow the httptest self-signed certificate (valid for 127.0.0.1)...
+	hs := httptest.NewTLSServer(http.NotFoundHandler())
+	cert := hs.TLS.Certificates[0]
+	roots := hs.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	hs.Close()
+	host := startTestGrpcServer(t, false, grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+	descs := []protoreflect.FileDescriptor{testGrpcFile(t)}
+	t.Run("tls config", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, nil)
+		ctx.grpc = newGrpcClient("https://"+host, &tls.Config{RootCAs: roots}, descs)
+		defer ctx.grpc.close()
+		m := GrpcMethod("marrow.test.Things/GetThing", "").
+			RequestBody(JSON{"id": "abc"}).
+			AssertOK()
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		err := m.Run(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, cov.Failures)
+		assert.Len(t, cov.Met, 1)
+	})
+	t.Run("untrusted certificate", func(t *testing.T) {
+		cov := coverage.NewCoverage()
+		ctx := newTestGrpcContext(host, cov, nil)
+		ctx.grpc = newGrpcClient("https://"+host, nil, descs)
+		defer ctx.grpc.close()
+		m := GrpcMethod("marrow.test.Things/GetThing", "").
+			RequestBody(JSON{"id": "abc"}).
+			AssertEqual(GrpcStatus, int(codes.Unavailable))
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		err := m.Run(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, cov.Failures)
+		assert.Len(t, cov.Met, 1)
+	})
+}
+
+func TestGrpcMethod_Names(t *testing.T) {
+	m := GrpcMethod("marrow.test.Things/GetThing", "get thing")
+	assert.Equal(t, "GRPC marrow.test.Things/GetThing", m.MethodName())
+	assert.Equal(t, `GRPC marrow.test.Things/GetThing "get thing"`, m.String())
+	assert.Equal(t, "get thing", m.Description())
+	assert.NotNil(t, m.Frame())
+}
+
+func TestGrpcHttpStatus(t *testing.T) {
+	testCases := map[codes.Code]int{
+		codes.OK:                 http.StatusOK,
+		codes.Canceled:           499,
+		codes.Unknown:            http.StatusInternalServerError,
+		codes.InvalidArgument:    http.StatusBadRequest,
+		codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+		codes.NotFound:           http.StatusNotFound,
+		codes.AlreadyExists:      http.StatusConflict,
+		codes.PermissionDenied:   http.StatusForbidden,
+		codes.ResourceExhausted:  http.StatusTooManyRequests,
+		codes.FailedPrecondition: http.StatusBadRequest,
+		codes.Aborted:            http.StatusConflict,
+		codes.OutOfRange:         http.StatusBadRequest,
+		codes.Unimplemented:      http.StatusNotImplemented,
+		codes.Internal:           http.StatusInternalServerError,
+		codes.Unavailable:        http.StatusServiceUnavailable,
+		codes.DataLoss:           http.StatusInternalServerError,
+		codes.Unauthenticated:    http.StatusUnauthorized,
+	}
+	for code, expect := range testCases {
+		t.Run(code.String(), func(t *testing.T) {
+			assert.Equal(t, expect, grpcHttpStatus(code))
+		})
+	}
+}
+
+func TestGrpcResolvables(t *testing.T) {
+	ctx := newTestContext(nil)
+	_, err := GrpcStatus.ResolveValue(ctx)
+	require.Error(t, err)
+	assert.Equal(t, "response is nil", err.Error())
+	_, err = GrpcMessage.ResolveValue(ctx)
+	require.Error(t, err)
+	_, err = GrpcMetadata("foo").ResolveValue(ctx)
+	require.Error(t, err)
+	ctx.currResponse = &http.Response{Header: http.Header{}}
+	_, err = GrpcStatus.ResolveValue(ctx)
+	require.Error(t, err)
+	assert.Equal(t, "response is not a grpc response", err.Error())
+	ctx.currResponse = &http.Response{
+		Header:  http.Header{"Foo": {"foo"}},
+		Trailer: http.Header{hdrGrpcStatus: {"5"}, hdrGrpcMessage: {"not found"}, "Bar": {"bar"}},
+	}
+	av, err := GrpcStatus.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, av)
+	av, err = GrpcMessage.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "not found", av)
+	av, err = GrpcMetadata("foo").ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", av)
+	av, err = GrpcMetadata("bar").ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", av)
+	assert.Equal(t, "GrpcStatus", GrpcStatus.String())
+	assert.Equal(t, "GrpcMessage", GrpcMessage.String())
+}
+
+func newTestGrpcContext(host string, cov coverage.Collector, vars map[Var]any) *context {
+	ctx := newTestContext(vars)
+	ctx.coverage = cov
+	ctx.host = "http://" + host
+	return ctx
+}
+
+// testGrpcFile builds a file descriptor for a test service (not registered in the global registry):
+//
+//	package marrow.test;
+//	message GetThingRequest { string id = 1; }
+//	message Thing { string id = 1; string name = 2; int32 count = 3; }
+//	service Things { rpc GetThing(GetThingRequest) returns (Thing); }
+func testGrpcFile(t *testing.T) protoreflect.FileDescriptor {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(num),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+		}
+	}
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("marrow/test/things.proto"),
+		Package: proto.String("marrow.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:  proto.String("GetThingRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)},
+			},
+			{
+				Name: proto.String("Thing"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					field("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					field("count", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("Things"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("GetThing"),
+						InputType:  proto.String(".marrow.test.GetThingRequest"),
+						OutputType: proto.String(".marrow.test.Thing"),
+					},
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	require.NoError(t, err)
+	return fd
+}
+
+// startTestGrpcServer starts an in-process grpc server for the test service - returning the host address
+//
+// the test service responds with the request id and, as the name, the "authorization" request metadata
+func startTestGrpcServer(t *testing.T, withReflection bool, opts ...grpc.ServerOption) string {
+	fd := testGrpcFile(t)
+	sd := fd.Services().Get(0)
+	md := sd.Methods().Get(0)
+	svr := grpc.NewServer(opts...)
+	svr.RegisterService(&grpc.ServiceDesc{
+		ServiceName: string(sd.FullName()),
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{
+			{
+				MethodName: string(md.Name()),
+				Handler: func(_ any, ctx gctx.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+					in := dynamicpb.NewMessage(md.Input())
+					if err := dec(in); err != nil {
+						return nil, err
+					}
+					auth := ""
+					if inMd, ok := metadata.FromIncomingContext(ctx); ok && len(inMd.Get("authorization")) > 0 {
+						auth = inMd.Get("authorization")[0]
+					}
+					id := in.Get(md.Input().Fields().ByName("id")).String()
+					switch {
+					case id == "missing":
+						return nil, status.Error(codes.NotFound, "thing not found")
+					case id == "auth" && auth == "":
+						return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+					case auth == "Bearer forbidden":
+						return nil, status.Error(codes.PermissionDenied, "forbidden")
+					}
+					_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", "req-"+id))
+					_ = grpc.SetTrailer(ctx, metadata.Pairs("x-trailer", "trailed"))
+					out := dynamicpb.NewMessage(md.Output())
+					out.Set(md.Output().Fields().ByName("id"), protoreflect.ValueOfString(id))
+					out.Set(md.Output().Fields().ByName("name"), protoreflect.ValueOfString(auth))
+					return out, nil
+				},
+			},
+		},
+		Metadata: fd.Path(),
+	}, struct{}{})
+	if withReflection {
+		files := new(protoregistry.Files)
+		require.NoError(t, files.RegisterFile(fd))
+		rpb.RegisterServerReflectionServer(svr, reflection.NewServerV1(reflection.ServerOptions{
+			Services:           svr,
+			DescriptorResolver: files,
+		}))
+	}
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	go func() {
+		_ = svr.Serve(lis)
+	}()
+	t.Cleanup(svr.Stop)
+	return lis.Addr().String()
+}
@@ -0,0 +1,516 @@
+package marrow
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-andiamo/marrow/framing"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	hdrContentType                = "Content-Type"
+	hdrCacheControl               = "Cache-Control"
+	hdrContentEncoding            = "Content-Encoding"
+	hdrOrigin                     = "Origin"
+	hdrAccessControlRequestMethod = "Access-Control-Request-Method"
+	hdrAccessControlAllowOrigin   = "Access-Control-Allow-Origin"
+	hdrAccessControlAllowMethods  = "Access-Control-Allow-Methods"
+	hdrStrictTransportSecurity    = "Strict-Transport-Security"
+	hdrContentSecurityPolicy      = "Content-Security-Policy"
+	hdrContentTypeOptions         = "X-Content-Type-Options"
+	hdrFrameOptions               = "X-Frame-Options"
+	hdrReferrerPolicy             = "Referrer-Policy"
+)
+
+type expectHeader struct {
+	header  string
+	matcher any
+	frame   *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*expectHeader)(nil)
+
+// ExpectHeader asserts that the named response header is present and that (any of) its value(s) match the matcher
+//
+// the matcher can be:
+//   - nil - the header need only be present
+//   - func(string) bool - called with each header value
+//   - *regexp.Regexp - each header value is matched against the regex
+//   - JsonMatcher - e.g. AnyUUID()
+//   - any other value (or resolvable) - the header value must equal it (compared as a string)
+//
+//go:noinline
+func ExpectHeader(name string, matcher any) Expectation {
+	return &expectHeader{
+		header:  name,
+		matcher: matcher,
+		frame:   framing.NewFrame(0),
+	}
+}
+
+func (e *expectHeader) Name() string {
+	return "Expect Header " + http.CanonicalHeaderKey(e.header)
+}
+
+func (e *expectHeader) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *expectHeader) Met(ctx Context) (unmet error, err error) {
+	var values []string
+	if values, err = responseHeaderValues(ctx, e.header); err == nil {
+		expected := OperandValue{Original: e.matcher}
+		if expected.Resolved, err = e.resolveMatcher(ctx); err == nil {
+			if len(values) == 0 {
+				unmet = e.unmet(fmt.Sprintf("expected header %q present", http.CanonicalHeaderKey(e.header)), expected, nil)
+			} else if !e.match(expected.Resolved, values) {
+				unmet = e.unmet(fmt.Sprintf("expected header %q to match", http.CanonicalHeaderKey(e.header)), expected, values)
+			}
+		}
+	}
+	return
+}
+
+func (e *expectHeader) resolveMatcher(ctx Context) (any, error) {
+	switch e.matcher.(type) {
+	case nil, func(string) bool, *regexp.Regexp, JsonMatcher:
+		return e.matcher, nil
+	}
+	return ResolveValue(e.matcher, ctx)
+}
+
+func (e *expectHeader) match(matcher any, values []string) bool {
+	for _, v := range values {
+		switch mt := matcher.(type) {
+		case nil:
+			return true
+		case func(string) bool:
+			if mt(v) {
+				return true
+			}
+		case *regexp.Regexp:
+			if mt.MatchString(v) {
+				return true
+			}
+		case JsonMatcher:
+			if mt.Match(v) {
+				return true
+			}
+		default:
+			if v == fmt.Sprintf("%v", mt) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *expectHeader) unmet(msg string, expected OperandValue, values []string) error {
+	return &unmetError{
+		msg:      msg,
+		name:     e.Name(),
+		expected: expected,
+		actual:   OperandValue{Original: ResponseHeader(e.header), Resolved: values},
+		frame:    e.frame,
+	}
+}
+
+func responseHeaderValues(ctx Context, name string) ([]string, error) {
+	if response := ctx.CurrentResponse(); response != nil {
+		return response.Header.Values(name), nil
+	}
+	return nil, errors.New("response is nil")
+}
+
+type expectContentType struct {
+	mediaType string
+	frame     *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*expectContentType)(nil)
+
+// ExpectContentType asserts that the response "Content-Type" header matches the media type
+//
+// the comparison is parameter-aware - the media types are compared case-insensitively, and only parameters
+// specified in the expected media type are checked - e.g.
+//
+//	ExpectContentType("application/json")
+//
+// is met by a response content type of "application/json; charset=utf-8", whereas
+//
+//	ExpectContentType("application/json; charset=iso-8859-1")
+//
+// is not
+//
+// the expected media type may use a wildcard subtype (e.g. "text/*")
+//
+//go:noinline
+func ExpectContentType(mediaType string) Expectation {
+	return &expectContentType{
+		mediaType: mediaType,
+		frame:     framing.NewFrame(0),
+	}
+}
+
+func (e *expectContentType) Name() string {
+	return "Expect Content Type " + e.mediaType
+}
+
+func (e *expectContentType) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *expectContentType) Met(ctx Context) (unmet error, err error) {
+	var values []string
+	if values, err = responseHeaderValues(ctx, hdrContentType); err == nil {
+		var emt string
+		var eps map[string]string
+		if emt, eps, err = mime.ParseMediaType(e.mediaType); err != nil {
+			return nil, fmt.Errorf("invalid expected content type %q: %w", e.mediaType, err)
+		}
+		actual := ""
+		if len(values) > 0 {
+			actual = values[0]
+		}
+		if amt, aps, aerr := mime.ParseMediaType(actual); aerr != nil || !mediaTypeMatches(emt, amt) || !mediaTypeParamsMatch(eps, aps) {
+			unmet = &unmetError{
+				msg:      fmt.Sprintf("expected content type %q", e.mediaType),
+				name:     e.Name(),
+				expected: OperandValue{Original: e.mediaType, Resolved: e.mediaType},
+				actual:   OperandValue{Original: ResponseHeader(hdrContentType), Resolved: actual},
+				frame:    e.frame,
+			}
+		}
+	}
+	return
+}
+
+func mediaTypeMatches(expected string, actual string) bool {
+	if expected == actual || expected == "*/*" {
+		return true
+	}
+	if et, ok := strings.CutSuffix(expected, "/*"); ok {
+		at, _, _ := strings.Cut(actual, "/")
+		return et == at
+	}
+	return false
+}
+
+func mediaTypeParamsMatch(expected map[string]string, actual map[string]string) bool {
+	for k, v := range expected {
+		if av, ok := actual[k]; !ok || !strings.EqualFold(v, av) {
+			return false
+		}
+	}
+	return true
+}
+
+type expectCacheControl struct {
+	directives []string
+	frame      *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*expectCacheControl)(nil)
+
+// ExpectCacheControl asserts that the response "Cache-Control" header contains all the directives
+//
+// a directive without a value (e.g. "no-store" or "max-age") need only be present, a directive with a value
+// (e.g. "max-age=60") must be present with that value
+//
+// if unmet, the unmet error message lists the missing (or mismatched) directives
+//
+//go:noinline
+func ExpectCacheControl(directives ...string) Expectation {
+	return &expectCacheControl{
+		directives: directives,
+		frame:      framing.NewFrame(0),
+	}
+}
+
+func (e *expectCacheControl) Name() string {
+	return "Expect Cache Control " + strings.Join(e.directives, ", ")
+}
+
+func (e *expectCacheControl) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *expectCacheControl) Met(ctx Context) (unmet error, err error) {
+	var values []string
+	if values, err = responseHeaderValues(ctx, hdrCacheControl); err == nil {
+		actual := parseCacheControl(values)
+		var missing []string
+		for _, d := range e.directives {
+			k, v, hasValue := strings.Cut(strings.TrimSpace(d), "=")
+			k = strings.ToLower(strings.TrimSpace(k))
+			if av, ok := actual[k]; !ok || (hasValue && av != strings.Trim(strings.TrimSpace(v), `"`)) {
+				missing = append(missing, d)
+			}
+		}
+		if len(missing) > 0 {
+			unmet = &unmetError{
+				msg:      fmt.Sprintf("expected cache control directives %s", strings.Join(missing, ", ")),
+				name:     e.Name(),
+				expected: OperandValue{Original: e.directives, Resolved: e.directives},
+				actual:   OperandValue{Original: ResponseHeader(hdrCacheControl), Resolved: strings.Join(values, ", ")},
+				frame:    e.frame,
+			}
+		}
+	}
+	return
+}
+
+func parseCacheControl(values []string) map[string]string {
+	result := make(map[string]string)
+	for _, hv := range values {
+		for _, d := range strings.Split(hv, ",") {
+			if d = strings.TrimSpace(d); d != "" {
+				k, v, _ := strings.Cut(d, "=")
+				result[strings.ToLower(strings.TrimSpace(k))] = strings.Trim(strings.TrimSpace(v), `"`)
+			}
+		}
+	}
+	return result
+}
+
+type expectCORS struct {
+	origin  string
+	methods []MethodName
+	frame   *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*expectCORS)(nil)
+
+// ExpectCORS asserts that the endpoint allows cross-origin requests from the origin for the methods
+//
+// a preflight "OPTIONS" request (with "Origin" and "Access-Control-Request-Method" headers) is made to the current request url
+// for each method - if no methods are specified, the method of the current request is used
+//
+// each preflight response must have a 2xx status, an "Access-Control-Allow-Origin" header of the origin (or "*") and
+// an "Access-Control-Allow-Methods" header that includes the method (or "*")
+//
+//go:noinline
+func ExpectCORS(origin string, methods ...MethodName) Expectation {
+	return &expectCORS{
+		origin:  origin,
+		methods: methods,
+		frame:   framing.NewFrame(0),
+	}
+}
+
+func (e *expectCORS) Name() string {
+	return "Expect CORS " + e.origin
+}
+
+func (e *expectCORS) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *expectCORS) Met(ctx Context) (unmet error, err error) {
+	request := ctx.CurrentRequest()
+	if request == nil {
+		return nil, errors.New("request is nil")
+	}
+	methods := e.methods
+	if len(methods) == 0 {
+		methods = []MethodName{MethodName(request.Method)}
+	}
+	for _, m := range methods {
+		m = m.Normalize()
+		var preflight *http.Request
+		if preflight, err = http.NewRequestWithContext(ctx.Ctx(), http.MethodOptions, request.URL.String(), nil); err != nil {
+			return
+		}
+		preflight.Header.Set(hdrOrigin, e.origin)
+		preflight.Header.Set(hdrAccessControlRequestMethod, string(m))
+		var res *http.Response
+		if res, err = ctx.DoRequest(preflight); err != nil {
+			return
+		}
+		_ = res.Body.Close()
+		if msg := e.check(res, m); msg != "" {
+			unmet = &unmetError{
+				msg:      msg,
+				name:     e.Name(),
+				expected: OperandValue{Original: e.origin, Resolved: string(m) + " " + e.origin},
+				actual:   OperandValue{Original: res.Header, Resolved: corsHeaders(res)},
+				frame:    e.frame,
+			}
+			return
+		}
+	}
+	return
+}
+
+func (e *expectCORS) check(res *http.Response, m MethodName) string {
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Sprintf("expected CORS preflight for %s - status code %s", m, Status(res.StatusCode).stringify())
+	}
+	if ao := res.Header.Get(hdrAccessControlAllowOrigin); ao != "*" && ao != e.origin {
+		return fmt.Sprintf("expected CORS preflight for %s - origin %q not allowed", m, e.origin)
+	}
+	allowed := false
+	if ams := res.Header.Values(hdrAccessControlAllowMethods); len(ams) > 0 {
+		for _, hv := range ams {
+			for _, am := range strings.Split(hv, ",") {
+				if am = strings.TrimSpace(am); am == "*" || strings.EqualFold(am, string(m)) {
+					allowed = true
+				}
+			}
+		}
+	} else {
+		// without allow methods, only simple methods are allowed...
+		allowed = m == GET || m == HEAD || m == POST
+	}
+	if !allowed {
+		return fmt.Sprintf("expected CORS preflight for %s - method not allowed", m)
+	}
+	return ""
+}
+
+func corsHeaders(res *http.Response) map[string]any {
+	return map[string]any{
+		"status":                     res.StatusCode,
+		hdrAccessControlAllowOrigin:  res.Header.Get(hdrAccessControlAllowOrigin),
+		hdrAccessControlAllowMethods: strings.Join(res.Header.Values(hdrAccessControlAllowMethods), ", "),
+	}
+}
+
+// SecurityHeadersProfile defines the security headers checked by ExpectSecurityHeaders
+type SecurityHeadersProfile struct {
+	// StrictTransportSecurity requires a "Strict-Transport-Security" header with a max-age of at least HSTSMinMaxAge
+	StrictTransportSecurity bool
+	// HSTSMinMaxAge is the minimum max-age (in seconds) of the "Strict-Transport-Security" header
+	HSTSMinMaxAge int
+	// ContentSecurityPolicy requires a "Content-Security-Policy" header
+	ContentSecurityPolicy bool
+	// ContentTypeOptions requires a "X-Content-Type-Options" header of "nosniff"
+	ContentTypeOptions bool
+	// FrameOptions requires a "X-Frame-Options" header of "DENY" or "SAMEORIGIN" (or a "Content-Security-Policy" with "frame-ancestors")
+	FrameOptions bool
+	// ReferrerPolicy requires a "Referrer-Policy" header
+	ReferrerPolicy bool
+	// Forbidden are headers that must not be present (e.g. "Server", "X-Powered-By")
+	Forbidden []string
+}
+
+var (
+	// SecurityHeadersBaseline is a SecurityHeadersProfile that checks for the minimal security headers
+	SecurityHeadersBaseline = SecurityHeadersProfile{
+		ContentTypeOptions: true,
+		FrameOptions:       true,
+		ReferrerPolicy:     true,
+	}
+	// SecurityHeadersStrict is a SecurityHeadersProfile that checks for all security headers (HSTS with max-age of at least one year)
+	// and that the "X-Powered-By" header is not present
+	SecurityHeadersStrict = SecurityHeadersProfile{
+		StrictTransportSecurity: true,
+		HSTSMinMaxAge:           31536000,
+		ContentSecurityPolicy:   true,
+		ContentTypeOptions:      true,
+		FrameOptions:            true,
+		ReferrerPolicy:          true,
+		Forbidden:               []string{"X-Powered-By"},
+	}
+)
+
+// SecurityHeaderFailures is the list of failures reported (as the unmet error actual value) by ExpectSecurityHeaders
+type SecurityHeaderFailures []string
+
+func (f SecurityHeaderFailures) stringify() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d failures", len(f)))
+	for _, line := range f {
+		b.WriteString("\n\t          \t" + line)
+	}
+	return b.String()
+}
+
+type expectSecurityHeaders struct {
+	profile SecurityHeadersProfile
+	frame   *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*expectSecurityHeaders)(nil)
+
+// ExpectSecurityHeaders asserts that the response has the security headers required by the profile
+//
+// see SecurityHeadersBaseline and SecurityHeadersStrict for predefined profiles
+//
+// if unmet, the unmet error actual value is a SecurityHeaderFailures
+//
+//go:noinline
+func ExpectSecurityHeaders(profile SecurityHeadersProfile) Expectation {
+	return &expectSecurityHeaders{
+		profile: profile,
+		frame:   framing.NewFrame(0),
+	}
+}
+
+func (e *expectSecurityHeaders) Name() string {
+	return "Expect Security Headers"
+}
+
+func (e *expectSecurityHeaders) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *expectSecurityHeaders) Met(ctx Context) (unmet error, err error) {
+	response := ctx.CurrentResponse()
+	if response == nil {
+		return nil, errors.New("response is nil")
+	}
+	if failures := e.check(response.Header); len(failures) > 0 {
+		unmet = &unmetError{
+			msg:      "expected security headers",
+			name:     e.Name(),
+			expected: OperandValue{Original: e.profile},
+			actual:   OperandValue{Original: response.Header, Resolved: failures},
+			frame:    e.frame,
+		}
+	}
+	return
+}
+
+func (e *expectSecurityHeaders) check(hdrs http.Header) (failures SecurityHeaderFailures) {
+	p := e.profile
+	csp := hdrs.Get(hdrContentSecurityPolicy)
+	if p.StrictTransportSecurity {
+		if hsts := hdrs.Get(hdrStrictTransportSecurity); hsts == "" {
+			failures = append(failures, fmt.Sprintf("%s: missing", hdrStrictTransportSecurity))
+		} else if maxAge, err := strconv.Atoi(parseCacheControl([]string{strings.ReplaceAll(hsts, ";", ",")})["max-age"]); err != nil || maxAge < max(p.HSTSMinMaxAge, 1) {
+			failures = append(failures, fmt.Sprintf("%s: max-age less than %d - %q", hdrStrictTransportSecurity, max(p.HSTSMinMaxAge, 1), hsts))
+		}
+	}
+	if p.ContentSecurityPolicy && csp == "" {
+		failures = append(failures, fmt.Sprintf("%s: missing", hdrContentSecurityPolicy))
+	}
+	if p.ContentTypeOptions {
+		if v := hdrs.Get(hdrContentTypeOptions); !strings.EqualFold(v, "nosniff") {
+			failures = append(failures, fmt.Sprintf("%s: expected \"nosniff\" - %q", hdrContentTypeOptions, v))
+		}
+	}
+	if p.FrameOptions && !strings.Contains(strings.ToLower(csp), "frame-ancestors") {
+		if v := hdrs.Get(hdrFrameOptions); !strings.EqualFold(v, "DENY") && !strings.EqualFold(v, "SAMEORIGIN") {
+			failures = append(failures, fmt.Sprintf("%s: expected \"DENY\" or \"SAMEORIGIN\" - %q", hdrFrameOptions, v))
+		}
+	}
+	if p.ReferrerPolicy && hdrs.Get(hdrReferrerPolicy) == "" {
+		failures = append(failures, fmt.Sprintf("%s: missing", hdrReferrerPolicy))
+	}
+	for _, h := range p.Forbidden {
+		if len(hdrs.Values(h)) > 0 {
+			failures = append(failures, fmt.Sprintf("%s: not allowed", http.CanonicalHeaderKey(h)))
+		}
+	}
+	return
+}
@@ -0,0 +1,259 @@
+package marrow
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func newHeadersTestContext(hdrs map[string][]string) *context {
+	ctx := newTestContext(nil)
+	ctx.currResponse = &http.Response{StatusCode: http.StatusOK, Header: hdrs}
+	return ctx
+}
+
+func TestExpectHeader(t *testing.T) {
+	ctx := newHeadersTestContext(map[string][]string{
+		"X-Request-Id": {"a9b2e9a4-6e0a-4c2b-9d43-2c1f0a6b8e71"},
+		"Vary":         {"Accept", "Origin"},
+	})
+	ctx.SetVar("vary", "Origin")
+	testCases := []struct {
+		name      string
+		matcher   any
+		expectMet bool
+		expectMsg string
+	}{
+		{name: "x-request-id", matcher: nil, expectMet: true},
+		{name: "X-Request-Id", matcher: AnyUUID(), expectMet: true},
+		{name: "X-Request-Id", matcher: AnyNumber(), expectMsg: `expected header "X-Request-Id" to match`},
+		{name: "Vary", matcher: "Accept", expectMet: true},
+		{name: "Vary", matcher: Var("vary"), expectMet: true},
+		{name: "Vary", matcher: "Cookie", expectMsg: `expected header "Vary" to match`},
+		{name: "Vary", matcher: regexp.MustCompile(`^Orig`), expectMet: true},
+		{name: "Vary", matcher: func(s string) bool { return s == "Origin" }, expectMet: true},
+		{name: "Vary", matcher: func(s string) bool { return false }, expectMsg: `expected header "Vary" to match`},
+		{name: "Etag", matcher: nil, expectMsg: `expected header "Etag" present`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			exp := ExpectHeader(tc.name, tc.matcher)
+			assert.Equal(t, "Expect Header "+http.CanonicalHeaderKey(tc.name), exp.Name())
+			assert.NotNil(t, exp.Frame())
+			unmet, err := exp.Met(ctx)
+			require.NoError(t, err)
+			if tc.expectMet {
+				require.NoError(t, unmet)
+			} else {
+				require.Error(t, unmet)
+				assert.Equal(t, tc.expectMsg, unmet.Error())
+			}
+		})
+	}
+	_, err := ExpectHeader("Vary", Var("missing")).Met(ctx)
+	require.Error(t, err)
+	_, err = ExpectHeader("Vary", nil).Met(newTestContext(nil))
+	require.Error(t, err)
+}
+
+func TestExpectContentType(t *testing.T) {
+	testCases := []struct {
+		expected  string
+		actual    string
+		expectMet bool
+	}{
+		{"application/json", "application/json", true},
+		{"application/json", "Application/JSON; charset=utf-8", true},
+		{"application/json; charset=UTF-8", "application/json; charset=utf-8", true},
+		{"application/json; charset=iso-8859-1", "application/json; charset=utf-8", false},
+		{"application/json; charset=utf-8", "application/json", false},
+		{"application/json", "application/problem+json", false},
+		{"text/*", "text/plain", true},
+		{"text/*", "application/json", false},
+		{"*/*", "image/png", true},
+		{"application/json", "", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expected+" : "+tc.actual, func(t *testing.T) {
+			ctx := newHeadersTestContext(map[string][]string{"Content-Type": {tc.actual}})
+			if tc.actual == "" {
+				ctx.currResponse.Header = http.Header{}
+			}
+			exp := ExpectContentType(tc.expected)
+			assert.Equal(t, "Expect Content Type "+tc.expected, exp.Name())
+			assert.NotNil(t, exp.Frame())
+			unmet, err := exp.Met(ctx)
+			require.NoError(t, err)
+			if tc.expectMet {
+				require.NoError(t, unmet)
+			} else {
+				require.Error(t, unmet)
+				assert.Equal(t, `expected content type "`+tc.expected+`"`, unmet.Error())
+			}
+		})
+	}
+	_, err := ExpectContentType("not a media type;;").Met(newHeadersTestContext(nil))
+	require.Error(t, err)
+	_, err = ExpectContentType("application/json").Met(newTestContext(nil))
+	require.Error(t, err)
+}
+
+func TestExpectCacheControl(t *testing.T) {
+	ctx := newHeadersTestContext(map[string][]string{"Cache-Control": {"public, Max-Age=60", `no-cache="Set-Cookie"`}})
+	exp := ExpectCacheControl("public", "max-age=60", "no-cache")
+	assert.Equal(t, "Expect Cache Control public, max-age=60, no-cache", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectCacheControl("max-age", `no-cache="Set-Cookie"`).Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectCacheControl("no-store", "max-age=30", "public").Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected cache control directives no-store, max-age=30", unmet.Error())
+
+	_, err = ExpectCacheControl("no-store").Met(newTestContext(nil))
+	require.Error(t, err)
+}
+
+func TestExpectCORS(t *testing.T) {
+	newCtx := func(status int, allowOrigin string, allowMethods string) (*context, *[]*http.Request) {
+		requests := make([]*http.Request, 0)
+		ctx := newTestContext(nil)
+		ctx.currRequest, _ = http.NewRequest(http.MethodPut, "http://localhost/foos", nil)
+		ctx.httpDo = doFunc(func(req *http.Request) (*http.Response, error) {
+			requests = append(requests, req)
+			hdrs := http.Header{}
+			if allowOrigin != "" {
+				hdrs.Set("Access-Control-Allow-Origin", allowOrigin)
+			}
+			if allowMethods != "" {
+				hdrs.Set("Access-Control-Allow-Methods", allowMethods)
+			}
+			return &http.Response{StatusCode: status, Header: hdrs, Body: io.NopCloser(strings.NewReader(""))}, nil
+		})
+		return ctx, &requests
+	}
+	t.Run("met", func(t *testing.T) {
+		ctx, requests := newCtx(http.StatusNoContent, "https://example.com", "GET, PUT")
+		exp := ExpectCORS("https://example.com")
+		assert.Equal(t, "Expect CORS https://example.com", exp.Name())
+		assert.NotNil(t, exp.Frame())
+		unmet, err := exp.Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+		require.Len(t, *requests, 1)
+		req := (*requests)[0]
+		assert.Equal(t, http.MethodOptions, req.Method)
+		assert.Equal(t, "http://localhost/foos", req.URL.String())
+		assert.Equal(t, "https://example.com", req.Header.Get("Origin"))
+		assert.Equal(t, "PUT", req.Header.Get("Access-Control-Request-Method"))
+
+		unmet, err = ExpectCORS("https://example.com", "get", PUT).Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+		require.Len(t, *requests, 3)
+	})
+	t.Run("wildcards", func(t *testing.T) {
+		ctx, _ := newCtx(http.StatusOK, "*", "*")
+		unmet, err := ExpectCORS("https://example.com", DELETE).Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+	})
+	t.Run("simple methods", func(t *testing.T) {
+		ctx, _ := newCtx(http.StatusOK, "*", "")
+		unmet, err := ExpectCORS("https://example.com", GET, POST).Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+		unmet, err = ExpectCORS("https://example.com").Met(ctx)
+		require.NoError(t, err)
+		require.Error(t, unmet)
+		assert.Equal(t, "expected CORS preflight for PUT - method not allowed", unmet.Error())
+	})
+	t.Run("origin not allowed", func(t *testing.T) {
+		ctx, _ := newCtx(http.StatusOK, "https://other.com", "PUT")
+		unmet, err := ExpectCORS("https://example.com").Met(ctx)
+		require.NoError(t, err)
+		require.Error(t, unmet)
+		assert.Equal(t, `expected CORS preflight for PUT - origin "https://example.com" not allowed`, unmet.Error())
+	})
+	t.Run("bad status", func(t *testing.T) {
+		ctx, _ := newCtx(http.StatusMethodNotAllowed, "", "")
+		unmet, err := ExpectCORS("https://example.com").Met(ctx)
+		require.NoError(t, err)
+		require.Error(t, unmet)
+		assert.Equal(t, `expected CORS preflight for PUT - status code 405 "Method Not Allowed"`, unmet.Error())
+	})
+	t.Run("errors", func(t *testing.T) {
+		_, err := ExpectCORS("https://example.com").Met(newTestContext(nil))
+		require.Error(t, err)
+		ctx, _ := newCtx(http.StatusOK, "*", "*")
+		ctx.httpDo = doFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("fooey")
+		})
+		_, err = ExpectCORS("https://example.com").Met(ctx)
+		require.Error(t, err)
+	})
+}
+
+func TestExpectSecurityHeaders(t *testing.T) {
+	secure := map[string][]string{
+		"Strict-Transport-Security": {"max-age=31536000; includeSubDomains"},
+		"Content-Security-Policy":   {"default-src 'self'"},
+		"X-Content-Type-Options":    {"nosniff"},
+		"X-Frame-Options":           {"DENY"},
+		"Referrer-Policy":           {"no-referrer"},
+	}
+	exp := ExpectSecurityHeaders(SecurityHeadersStrict)
+	assert.Equal(t, "Expect Security Headers", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	unmet, err := exp.Met(newHeadersTestContext(secure))
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	ctx := newHeadersTestContext(map[string][]string{
+		"Strict-Transport-Security": {"max-age=60"},
+		"X-Content-Type-Options":    {"sniff"},
+		"X-Powered-By":              {"Express"},
+	})
+	unmet, err = exp.Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected security headers", unmet.Error())
+	failures, ok := unmet.(UnmetError).Actual().Resolved.(SecurityHeaderFailures)
+	require.True(t, ok)
+	assert.Equal(t, SecurityHeaderFailures{
+		`Strict-Transport-Security: max-age less than 31536000 - "max-age=60"`,
+		"Content-Security-Policy: missing",
+		`X-Content-Type-Options: expected "nosniff" - "sniff"`,
+		`X-Frame-Options: expected "DENY" or "SAMEORIGIN" - ""`,
+		"Referrer-Policy: missing",
+		"X-Powered-By: not allowed",
+	}, failures)
+	assert.Contains(t, failures.stringify(), "6 failures\n\t          \tStrict-Transport-Security")
+
+	unmet, err = ExpectSecurityHeaders(SecurityHeadersBaseline).Met(newHeadersTestContext(map[string][]string{
+		"Content-Security-Policy": {"frame-ancestors 'none'"},
+		"X-Content-Type-Options":  {"nosniff"},
+		"Referrer-Policy":         {"no-referrer"},
+	}))
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectSecurityHeaders(SecurityHeadersProfile{StrictTransportSecurity: true}).Met(newHeadersTestContext(nil))
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, SecurityHeaderFailures{"Strict-Transport-Security: missing"}, unmet.(UnmetError).Actual().Resolved)
+
+	_, err = exp.Met(newTestContext(nil))
+	require.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package marrow
+
+import (
+	"bytes"
+	gctx "context"
+	"errors"
+	"github.com/go-andiamo/marrow/common"
+	"io"
+	"net/http"
+	"time"
+)
+
+// withTimeout returns the http do with the timeout applied
+//
+// for a *http.Client, a copy of the client with the timeout is used (so that the timeout overrides any client timeout) -
+// otherwise, the request context is given the timeout deadline
+func withTimeout(do common.HttpDo, timeout time.Duration) common.HttpDo {
+	if timeout > 0 {
+		if hc, ok := do.(*http.Client); ok {
+			cpy := *hc
+			cpy.Timeout = timeout
+			return &cpy
+		}
+		return &timeoutDo{do: do, timeout: timeout}
+	}
+	return do
+}
+
+type timeoutDo struct {
+	do      common.HttpDo
+	timeout time.Duration
+}
+
+func (t *timeoutDo) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := gctx.WithTimeout(req.Context(), t.timeout)
+	defer cancel()
+	res, err := t.do.Do(req.WithContext(ctx))
+	if err == nil && res.Body != nil {
+		// the response body must be read before the deadline context is cancelled...
+		var data []byte
+		data, err = io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(data))
+	}
+	return res, err
+}
+
+// RedirectChainValue is the type for RedirectChain
+type RedirectChainValue string
+
+const (
+	// RedirectChain resolves to the chain of responses for the current Context request - where redirects were followed
+	//
+	// the resolved value is a slice, each item being a map with "url" (the request url) and "status" (the response status code) -
+	// the last item is the final response (so, if no redirects were followed, the slice has only one item)
+	//
+	// see with.HttpClientOptions for setting the redirect policy
+	RedirectChain RedirectChainValue = "RedirectChain"
+)
+
+func (RedirectChainValue) ResolveValue(ctx Context) (any, error) {
+	res := ctx.CurrentResponse()
+	if res == nil {
+		return nil, errors.New("response is nil")
+	}
+	chain := make([]any, 0)
+	for r := res; r != nil; {
+		item := map[string]any{"status": r.StatusCode}
+		if r.Request != nil && r.Request.URL != nil {
+			item["url"] = r.Request.URL.String()
+		}
+		chain = append([]any{item}, chain...)
+		if r.Request != nil {
+			r = r.Request.Response
+		} else {
+			r = nil
+		}
+	}
+	return chain, nil
+}
+
+func (RedirectChainValue) String() string {
+	return string(RedirectChain)
+}
@@ -0,0 +1,133 @@
+package marrow
+
+import (
+	"github.com/go-andiamo/marrow/coverage"
+	"github.com/go-andiamo/marrow/with"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	do := &dummyDo{status: http.StatusOK}
+	assert.Equal(t, do, withTimeout(do, 0))
+	td, ok := withTimeout(do, time.Second).(*timeoutDo)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, td.timeout)
+
+	hc := &http.Client{Timeout: time.Minute}
+	assert.Equal(t, hc, withTimeout(hc, 0))
+	cpy, ok := withTimeout(hc, time.Second).(*http.Client)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, cpy.Timeout)
+	assert.Equal(t, time.Minute, hc.Timeout)
+}
+
+func TestTimeoutDo(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		do := &timeoutDo{do: &dummyDo{status: http.StatusOK, body: []byte(`{"foo":"bar"}`)}, timeout: time.Second}
+		res, err := do.Do(httptest.NewRequest(http.MethodGet, "/", nil))
+		require.NoError(t, err)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"foo":"bar"}`, string(body))
+	})
+	t.Run("timed out", func(t *testing.T) {
+		do := &timeoutDo{do: doFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}), timeout: 10 * time.Millisecond}
+		_, err := do.Do(httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Error(t, err)
+	})
+}
+
+func TestMethod_Timeout(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+	testCases := []struct {
+		clientTimeout time.Duration
+		methodTimeout time.Duration
+		expectFailed  bool
+	}{
+		{},
+		{clientTimeout: 10 * time.Millisecond, expectFailed: true},
+		{methodTimeout: 10 * time.Millisecond, expectFailed: true},
+		{clientTimeout: 10 * time.Millisecond, methodTimeout: 5 * time.Second},
+		{clientTimeout: 5 * time.Second, methodTimeout: 10 * time.Millisecond, expectFailed: true},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx := newTestContext(nil)
+			cov := coverage.NewCoverage()
+			ctx.coverage = cov
+			ctx.host = svr.URL
+			ctx.httpDo = &http.Client{Timeout: tc.clientTimeout}
+			ctx.currEndpoint = Endpoint("/slow", "")
+			m := Method(GET, "").AssertOK()
+			if tc.methodTimeout > 0 {
+				m.Timeout(tc.methodTimeout)
+			}
+			err := m.Run(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectFailed, ctx.failed)
+			if tc.expectFailed {
+				assert.Len(t, cov.Failures, 1)
+			} else {
+				assert.Len(t, cov.Met, 1)
+			}
+		})
+	}
+}
+
+func TestRedirectChain(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		if n > 0 {
+			http.Redirect(w, r, "/?n="+strconv.Itoa(n-1), http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+
+	ctx := newTestContext(nil)
+	_, err := RedirectChain.ResolveValue(ctx)
+	require.Error(t, err)
+	assert.Equal(t, "RedirectChain", RedirectChain.String())
+
+	ctx.host = svr.URL
+	ctx.currEndpoint = Endpoint("/", "")
+	ctx.httpDo = http.DefaultClient
+	m := Method(GET, "").QueryParam("n", 2).
+		AssertOK().
+		AssertEqual(JsonPath(RedirectChain, LEN), 3).
+		AssertEqual(RedirectChain, []any{
+			map[string]any{"url": svr.URL + "/?n=2", "status": http.StatusFound},
+			map[string]any{"url": svr.URL + "/?n=1", "status": http.StatusFound},
+			map[string]any{"url": svr.URL + "/?n=0", "status": http.StatusOK},
+		})
+	err = m.Run(ctx)
+	require.NoError(t, err)
+	assert.False(t, ctx.failed)
+
+	client, err := with.HttpClientOptions{Redirects: with.RedirectNone}.Client()
+	require.NoError(t, err)
+	ctx.httpDo = client
+	m = Method(GET, "").QueryParam("n", 2).
+		AssertStatus(http.StatusFound).
+		AssertEqual(RedirectChain, []any{
+			map[string]any{"url": svr.URL + "/?n=2", "status": http.StatusFound},
+		})
+	err = m.Run(ctx)
+	require.NoError(t, err)
+	assert.False(t, ctx.failed)
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/go-redis/redis/v7"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -32,6 +34,50 @@ type Client interface {
 	Delete(key string) (bool, error)
 	// QueueLength returns the queue length for the given queue name
 	QueueLength(queueName string) (int, error)
+	// TTL returns the remaining time-to-live of a specific key
+	//
+	// returns -1 if the key exists but has no expiry, and -2 if the key does not exist
+	TTL(key string) (time.Duration, error)
+	// HashGet retrieves a specific field of a hash
+	HashGet(key string, field string) (string, error)
+	// HashGetAll retrieves all fields of a hash
+	HashGetAll(key string) (map[string]string, error)
+	// HashSet sets fields of a hash
+	HashSet(key string, values map[string]any) error
+	// SetMembers retrieves all members of a set (sorted)
+	SetMembers(key string) ([]string, error)
+	// SetAdd adds members to a set
+	SetAdd(key string, members ...any) error
+	// SortedSetRange retrieves members (with scores) of a sorted set - by rank, from start to stop (inclusive)
+	//
+	// start and stop can be negative - which means offset from last, i.e. -1 is last
+	SortedSetRange(key string, start, stop int64) ([]SortedSetMember, error)
+	// SortedSetAdd adds members to a sorted set
+	SortedSetAdd(key string, members ...SortedSetMember) error
+	// StreamAdd adds an entry to a stream (and returns the id of the added entry)
+	StreamAdd(stream string, values map[string]any) (string, error)
+	// StreamRange retrieves all entries on a stream
+	StreamRange(stream string) ([]StreamEntry, error)
+	// StreamConsume consumes entries on a stream as a consumer within a consumer group
+	//
+	// the consumer group is created (from the start of the stream) if it does not already exist
+	//
+	// consumed entries are acknowledged after the handler is called
+	//
+	// returns a func to use to close/end the consumer - or an error if the consumer group could not be created
+	StreamConsume(stream string, group string, consumer string, handler func(entry StreamEntry)) (close func(), err error)
+}
+
+// SortedSetMember is a member (and score) of a sorted set
+type SortedSetMember struct {
+	Member string
+	Score  float64
+}
+
+// StreamEntry is an entry on a stream
+type StreamEntry struct {
+	ID     string
+	Values map[string]any
 }
 
 func newClient(host, port string) (Client, *redis.Client) {
@@ -163,3 +209,137 @@ func (c *client) QueueLength(queueName string) (int, error) {
 	length, err := c.rc.LLen(queueName).Result()
 	return int(length), err
 }
+
+func (c *client) TTL(key string) (time.Duration, error) {
+	return c.rc.TTL(key).Result()
+}
+
+func (c *client) HashGet(key string, field string) (string, error) {
+	val, err := c.rc.HGet(key, field).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", NotFound
+		}
+		return "", err
+	}
+	return val, nil
+}
+
+func (c *client) HashGetAll(key string) (map[string]string, error) {
+	return c.rc.HGetAll(key).Result()
+}
+
+func (c *client) HashSet(key string, values map[string]any) error {
+	if len(values) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(values)*2)
+	for k, v := range values {
+		args = append(args, k, v)
+	}
+	return c.rc.HSet(key, args...).Err()
+}
+
+func (c *client) SetMembers(key string) ([]string, error) {
+	members, err := c.rc.SMembers(key).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+func (c *client) SetAdd(key string, members ...any) error {
+	if len(members) == 0 {
+		return nil
+	}
+	return c.rc.SAdd(key, members...).Err()
+}
+
+func (c *client) SortedSetRange(key string, start, stop int64) ([]SortedSetMember, error) {
+	zs, err := c.rc.ZRangeWithScores(key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SortedSetMember, 0, len(zs))
+	for _, z := range zs {
+		result = append(result, SortedSetMember{
+			Member: fmt.Sprintf("%v", z.Member),
+			Score:  z.Score,
+		})
+	}
+	return result, nil
+}
+
+func (c *client) SortedSetAdd(key string, members ...SortedSetMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+	zs := make([]*redis.Z, 0, len(members))
+	for _, m := range members {
+		zs = append(zs, &redis.Z{Score: m.Score, Member: m.Member})
+	}
+	return c.rc.ZAdd(key, zs...).Err()
+}
+
+func (c *client) StreamAdd(stream string, values map[string]any) (string, error) {
+	return c.rc.XAdd(&redis.XAddArgs{
+		Stream: stream,
+		Values: values,
+	}).Result()
+}
+
+func (c *client) StreamRange(stream string) ([]StreamEntry, error) {
+	msgs, err := c.rc.XRange(stream, "-", "+").Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]StreamEntry, 0, len(msgs))
+	for _, msg := range msgs {
+		result = append(result, StreamEntry{ID: msg.ID, Values: msg.Values})
+	}
+	return result, nil
+}
+
+func (c *client) StreamConsume(stream string, group string, consumer string, handler func(entry StreamEntry)) (func(), error) {
+	if err := c.rc.XGroupCreateMkStream(stream, group, "0").Err(); err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, fmt.Errorf("unable to create consumer group %q on stream %q: %w", group, stream, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			streams, err := c.rc.WithContext(ctx).XReadGroup(&redis.XReadGroupArgs{
+				Group:    group,
+				Consumer: consumer,
+				Streams:  []string{stream, ">"},
+				Count:    10,
+				Block:    time.Second,
+			}).Result()
+			if err != nil {
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return
+				}
+				if err == redis.Nil {
+					continue
+				}
+				time.Sleep(500 * time.Millisecond)
+				continue
+			}
+			for _, s := range streams {
+				for _, msg := range s.Messages {
+					func() {
+						defer func() { _ = recover() }()
+						handler(StreamEntry{ID: msg.ID, Values: msg.Values})
+					}()
+					_ = c.rc.XAck(stream, group, msg.ID).Err()
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
@@ -3,6 +3,7 @@ package dragonfly
 import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"sync"
 	"testing"
 	"time"
 )
@@ -95,4 +96,77 @@ func TestImage_Client(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, 1, l)
 	})
+	t.Run("ttl", func(t *testing.T) {
+		ttl, err := c.TTL("ttl_missing")
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(-2), ttl)
+		err = c.Set("ttl_foo", "bar", 0)
+		require.NoError(t, err)
+		ttl, err = c.TTL("ttl_foo")
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(-1), ttl)
+		err = c.Set("ttl_foo", "bar", time.Minute)
+		require.NoError(t, err)
+		ttl, err = c.TTL("ttl_foo")
+		require.NoError(t, err)
+		assert.Greater(t, ttl, 50*time.Second)
+	})
+	t.Run("hashes", func(t *testing.T) {
+		_, err := c.HashGet("hash_foo", "bar")
+		require.Error(t, err)
+		assert.Equal(t, err, NotFound)
+		err = c.HashSet("hash_foo", map[string]any{"bar": "baz", "qux": 42})
+		require.NoError(t, err)
+		v, err := c.HashGet("hash_foo", "bar")
+		require.NoError(t, err)
+		assert.Equal(t, "baz", v)
+		all, err := c.HashGetAll("hash_foo")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"bar": "baz", "qux": "42"}, all)
+	})
+	t.Run("sets", func(t *testing.T) {
+		err := c.SetAdd("set_foo", "b", "a", "c", "a")
+		require.NoError(t, err)
+		members, err := c.SetMembers("set_foo")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, members)
+	})
+	t.Run("sorted sets", func(t *testing.T) {
+		err := c.SortedSetAdd("zset_foo", SortedSetMember{Member: "b", Score: 2}, SortedSetMember{Member: "a", Score: 1})
+		require.NoError(t, err)
+		members, err := c.SortedSetRange("zset_foo", 0, -1)
+		require.NoError(t, err)
+		assert.Equal(t, []SortedSetMember{{Member: "a", Score: 1}, {Member: "b", Score: 2}}, members)
+	})
+	t.Run("streams", func(t *testing.T) {
+		var received []StreamEntry
+		var mu sync.Mutex
+		closeFn, err := c.StreamConsume("stream_foo", "group_foo", "consumer_foo", func(entry StreamEntry) {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, entry)
+		})
+		require.NoError(t, err)
+		defer closeFn()
+		id, err := c.StreamAdd("stream_foo", map[string]any{"foo": "bar"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, id)
+		entries, err := c.StreamRange("stream_foo")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, id, entries[0].ID)
+		assert.Equal(t, map[string]any{"foo": "bar"}, entries[0].Values)
+		time.Sleep(2 * time.Second)
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, received, 1)
+		assert.Equal(t, id, received[0].ID)
+	})
+	t.Run("stream consume error", func(t *testing.T) {
+		err := c.Set("not_a_stream", "bar", 0)
+		require.NoError(t, err)
+		_, err = c.StreamConsume("not_a_stream", "group_foo", "consumer_foo", func(entry StreamEntry) {})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to create consumer group")
+	})
 }
@@ -91,6 +91,39 @@ func QueueListener(name string, queue string, options Receiver, imgName ...strin
 	return result
 }
 
+// StreamListener is a before operation that starts a stream listener - consuming the stream as a member of a consumer group
+//
+// the name identifies the listener - for use in marrow.Events and marrow.EventsClear
+//
+// the consumer group is created (from the start of the stream) if it does not already exist - and consumed entries are acknowledged
+//
+// each event is a `map[string]any` with properties "id" and "values" (unless the options specify an Unmarshaler - in which case,
+// the entry values are passed to it as json)
+//
+// if a listener with that name has previously been created, it is cleared
+//
+//go:noinline
+func StreamListener(name string, stream string, group string, options Receiver, imgName ...string) marrow.BeforeAfter {
+	name, stream = nameAndDest(name, stream)
+	if group == "" {
+		group = name
+	}
+	result := &streamListener{
+		capture: capture{
+			name:    fmt.Sprintf("StreamListener(%q, %q)", stream, group),
+			when:    marrow.Before,
+			imgName: imgName,
+			frame:   framing.NewFrame(0),
+		},
+		listenerName: name,
+		stream:       stream,
+		group:        group,
+		options:      options,
+	}
+	result.run = result.runListener
+	return result
+}
+
 func nameAndDest(name string, dst string) (string, string) {
 	if name == "" && dst != "" {
 		return dst, dst
@@ -157,6 +190,37 @@ func (q *queueListener) runListener(ctx marrow.Context, img *image) (err error)
 	return err
 }
 
+type streamListener struct {
+	capture
+	listenerName string
+	stream       string
+	group        string
+	options      Receiver
+}
+
+const streamConsumerName = "marrow"
+
+func (s *streamListener) runListener(ctx marrow.Context, img *image) (err error) {
+	if existing := ctx.Listener(s.listenerName); existing == nil {
+		ln := s.options.MaxMessages
+		if ln <= 0 {
+			ln = math.MaxInt
+		}
+		l := &listener{
+			max:         ln,
+			unmarshaler: s.options.Unmarshaler,
+		}
+		if l.close, err = img.Client().StreamConsume(s.stream, s.group, streamConsumerName, l.receiveEntry); err == nil {
+			ctx.RegisterListener(s.listenerName, l)
+		}
+	} else if _, ok := existing.(*listener); !ok {
+		err = fmt.Errorf("expected streamListener but got %T", existing)
+	} else {
+		existing.Clear()
+	}
+	return err
+}
+
 type listener struct {
 	count       int64
 	msgs        []any
@@ -194,14 +258,7 @@ func (l *listener) Stop() {
 }
 
 func (l *listener) receive(msg string) {
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
-	if l.count == math.MaxInt64 {
-		l.count = 1
-	} else {
-		l.count++
-	}
-	if l.max > 0 {
+	l.add(func() any {
 		var v any = msg
 		if l.unmarshaler != nil {
 			v = l.unmarshaler(msg)
@@ -213,6 +270,29 @@ func (l *listener) receive(msg string) {
 		} else if l.json {
 			v = nil
 		}
+		return v
+	})
+}
+
+func (l *listener) receiveEntry(entry StreamEntry) {
+	l.add(func() any {
+		if l.unmarshaler != nil {
+			return l.unmarshaler(jsonMarshal(entry.Values))
+		}
+		return streamEntryValue(entry)
+	})
+}
+
+func (l *listener) add(value func() any) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.count == math.MaxInt64 {
+		l.count = 1
+	} else {
+		l.count++
+	}
+	if l.max > 0 {
+		v := value()
 		if len(l.msgs) < l.max {
 			l.msgs = append(l.msgs, v)
 		} else {
@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"crypto/tls"
 	"database/sql"
 	"fmt"
 	"github.com/go-andiamo/marrow"
@@ -10,6 +11,7 @@ import (
 	"github.com/go-andiamo/marrow/with"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"io"
 	"net/http"
 	"testing"
@@ -130,3 +132,23 @@ func (d *mockInit) ResolveEnv(v any) (string, error) {
 func (d *mockInit) SetTraceTimings(collect bool) {
 	d.called["SetTraceTimings"] = struct{}{}
 }
+
+func (d *mockInit) AddCodec(mediaType string, codec common.Codec) {
+	d.called["AddCodec:"+mediaType] = struct{}{}
+}
+
+func (d *mockInit) SetGrpc(target string, descriptors ...protoreflect.FileDescriptor) {
+	d.called["SetGrpc"] = struct{}{}
+}
+
+func (d *mockInit) SetGrpcTLS(config *tls.Config) {
+	d.called["SetGrpcTLS"] = struct{}{}
+}
+
+func (d *mockInit) SetGraphQLSchema(r io.Reader) {
+	d.called["SetGraphQLSchema"] = struct{}{}
+}
+
+func (d *mockInit) SetRecordTraffic(path string, redactHeaders ...string) {
+	d.called["SetRecordTraffic"] = struct{}{}
+}
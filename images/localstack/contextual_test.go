@@ -131,6 +131,11 @@ func TestResolvablesAndBeforeAfters(t *testing.T) {
 			AssertEqual("bar", TemplateString("{$svc:secrets-service:value:foo}")).
 			AssertEqual(`{"foo":"bar4"}`, TemplateString("{$svc:secrets-service:value:foo4}")),
 		Method("GET", "again").AssertOK().
+			Authorize(SignAwsV4("execute-api", Region(), Credentials())).
+			AssertEqual(defaultRegion, Region()).
+			AssertEqual(defaultAccessKey, Credentials().AccessKey).
+			AssertEqual(defaultSecretKey, Credentials().SecretKey).
+			AssertEqual(defaultSessionToken, Credentials().SessionToken).
 			Do(S3CreateBucket(Before, "foo-bucket")).
 			AssertEqual(0, DynamoItemsCount("TestTable")).
 			AssertEqual(0, S3ObjectsCount(testBucket, "")).
@@ -0,0 +1,44 @@
+package localstack
+
+import (
+	"github.com/go-andiamo/marrow"
+	"github.com/go-andiamo/marrow/framing"
+)
+
+// Credentials returns the localstack AWS credentials (see Options.AccessKey, Options.SecretKey & Options.SessionToken)
+// for use with marrow.SignAwsV4
+//
+// example:
+//
+//	Method(GET, "do get").
+//	    Authorize(marrow.SignAwsV4("execute-api", localstack.Region(), localstack.Credentials()))
+//
+//go:noinline
+func Credentials(imgName ...string) marrow.AwsCredentials {
+	return marrow.AwsCredentials{
+		AccessKey:    envResolvable("AccessKey", "accesskey", imgName),
+		SecretKey:    envResolvable("SecretKey", "secretkey", imgName),
+		SessionToken: envResolvable("SessionToken", "sessiontoken", imgName),
+	}
+}
+
+// Region can be used as a resolvable value (e.g. with marrow.SignAwsV4)
+// and resolves to the localstack AWS region (see Options.Region)
+//
+//go:noinline
+func Region(imgName ...string) marrow.Resolvable {
+	return envResolvable("Region", "region", imgName)
+}
+
+func envResolvable(name string, token string, imgName []string) marrow.Resolvable {
+	return &resolvable[*image]{
+		name:     name + "()",
+		defImage: ImageName,
+		imgName:  imgName,
+		run: func(ctx marrow.Context, img *image) (result any, err error) {
+			result, _ = img.ResolveEnv(token)
+			return result, nil
+		},
+		frame: framing.NewFrame(1),
+	}
+}
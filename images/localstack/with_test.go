@@ -2,6 +2,7 @@ package localstack
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,6 +13,7 @@ import (
 	"github.com/go-andiamo/marrow/with"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"io"
 	"net/http"
 	"sync"
@@ -223,3 +225,23 @@ func (d *mockInit) SetTraceTimings(collect bool) {
 	defer d.mutex.Unlock()
 	d.called["SetTraceTimings"] = struct{}{}
 }
+
+func (d *mockInit) AddCodec(mediaType string, codec common.Codec) {
+	d.called["AddCodec:"+mediaType] = struct{}{}
+}
+
+func (d *mockInit) SetGrpc(target string, descriptors ...protoreflect.FileDescriptor) {
+	d.called["SetGrpc"] = struct{}{}
+}
+
+func (d *mockInit) SetGrpcTLS(config *tls.Config) {
+	d.called["SetGrpcTLS"] = struct{}{}
+}
+
+func (d *mockInit) SetGraphQLSchema(r io.Reader) {
+	d.called["SetGraphQLSchema"] = struct{}{}
+}
+
+func (d *mockInit) SetRecordTraffic(path string, redactHeaders ...string) {
+	d.called["SetRecordTraffic"] = struct{}{}
+}
@@ -0,0 +1,186 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type snapshot struct {
+	schema string
+	// ddl is the "CREATE TABLE" statement (see SHOW CREATE TABLE) of each table - so that dropped tables can be
+	// re-created with their foreign key constraints (which CREATE TABLE ... LIKE does not copy)
+	ddl map[string]string
+}
+
+// TakeSnapshot takes a named snapshot of the database
+//
+// the snapshot is held as a copy of the database tables in a separate schema (along with the table definitions) - if a
+// snapshot with the same name already exists, it is replaced
+func (i *image) TakeSnapshot(snapshotName string) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("take snapshot %q: %w", snapshotName, err)
+		}
+	}()
+	if i.options.Database == "" {
+		return errors.New("database name is required to snapshot")
+	}
+	snap := &snapshot{
+		schema: i.snapshotSchemaName(snapshotName),
+		ddl:    make(map[string]string),
+	}
+	err = i.withConn(func(ctx context.Context, conn *sql.Conn) (err error) {
+		if _, err = conn.ExecContext(ctx, "DROP SCHEMA IF EXISTS "+quoteIdentifier(snap.schema)); err == nil {
+			if _, err = conn.ExecContext(ctx, "CREATE SCHEMA "+quoteIdentifier(snap.schema)); err == nil {
+				var tables []string
+				if tables, err = schemaTables(ctx, conn, i.options.Database); err == nil {
+					for t := 0; t < len(tables) && err == nil; t++ {
+						if snap.ddl[tables[t]], err = showCreateTable(ctx, conn, i.options.Database, tables[t]); err == nil {
+							err = copyTable(ctx, conn, i.options.Database, snap.schema, tables[t], true)
+						}
+					}
+				}
+			}
+		}
+		if err == nil {
+			if i.snapshots == nil {
+				i.snapshots = make(map[string]*snapshot)
+			}
+			i.snapshots[snapshotName] = snap
+		}
+		return err
+	})
+	return err
+}
+
+// RestoreSnapshot restores the database to a previously taken named snapshot
+//
+// tables created since the snapshot was taken are dropped - existing tables are truncated and re-populated, and tables
+// dropped since the snapshot was taken are re-created from their snapshot definition (retaining foreign key constraints)
+func (i *image) RestoreSnapshot(snapshotName string) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("restore snapshot %q: %w", snapshotName, err)
+		}
+	}()
+	snap, ok := i.snapshots[snapshotName]
+	if !ok {
+		return errors.New("snapshot not found")
+	}
+	snapSchema := snap.schema
+	err = i.withConn(func(ctx context.Context, conn *sql.Conn) (err error) {
+		var tables []string
+		var snapTables []string
+		if tables, err = schemaTables(ctx, conn, i.options.Database); err == nil {
+			if snapTables, err = schemaTables(ctx, conn, snapSchema); err == nil {
+				existing := make(map[string]bool, len(tables))
+				inSnap := make(map[string]bool, len(snapTables))
+				for _, t := range snapTables {
+					inSnap[t] = true
+				}
+				for t := 0; t < len(tables) && err == nil; t++ {
+					// existing tables are truncated (rather than re-created) so that their constraints are retained...
+					if inSnap[tables[t]] {
+						existing[tables[t]] = true
+						_, err = conn.ExecContext(ctx, "TRUNCATE TABLE "+qualifiedTable(i.options.Database, tables[t]))
+					} else {
+						_, err = conn.ExecContext(ctx, "DROP TABLE "+qualifiedTable(i.options.Database, tables[t]))
+					}
+				}
+				for t := 0; t < len(snapTables) && err == nil; t++ {
+					create := !existing[snapTables[t]]
+					if ddl, ok := snap.ddl[snapTables[t]]; ok && create {
+						// re-create from the original definition (CREATE TABLE ... LIKE does not copy foreign keys)...
+						_, err = conn.ExecContext(ctx, ddl)
+						create = false
+					}
+					if err == nil {
+						err = copyTable(ctx, conn, snapSchema, i.options.Database, snapTables[t], create)
+					}
+				}
+			}
+		}
+		return err
+	})
+	return err
+}
+
+func (i *image) initialSnapshot() error {
+	if i.options.InitialSnapshot != "" {
+		return i.TakeSnapshot(i.options.InitialSnapshot)
+	}
+	return nil
+}
+
+func (i *image) snapshotSchemaName(snapshotName string) string {
+	return i.options.Database + "_snapshot_" + snapshotName
+}
+
+// withConn calls the fn with a single connection that has foreign key checks disabled
+func (i *image) withConn(fn func(ctx context.Context, conn *sql.Conn) error) (err error) {
+	ctx := context.Background()
+	var conn *sql.Conn
+	if conn, err = i.db.Conn(ctx); err == nil {
+		defer func() {
+			_ = conn.Close()
+		}()
+		if _, err = conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err == nil {
+			defer func() {
+				_, _ = conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+			}()
+			err = fn(ctx, conn)
+		}
+	}
+	return err
+}
+
+func schemaTables(ctx context.Context, conn *sql.Conn, schema string) (result []string, err error) {
+	var rows *sql.Rows
+	if rows, err = conn.QueryContext(ctx, "SELECT table_name FROM information_schema.tables WHERE table_schema = ? AND table_type = 'BASE TABLE'", schema); err == nil {
+		defer func() {
+			_ = rows.Close()
+		}()
+		for rows.Next() && err == nil {
+			var name string
+			if err = rows.Scan(&name); err == nil {
+				result = append(result, name)
+			}
+		}
+		if err == nil {
+			err = rows.Err()
+		}
+	}
+	return result, err
+}
+
+// showCreateTable obtains the "CREATE TABLE" statement for a table
+//
+// the statement is unqualified - so, when executed, creates the table in the connection's default database
+func showCreateTable(ctx context.Context, conn *sql.Conn, schema string, table string) (ddl string, err error) {
+	var name string
+	err = conn.QueryRowContext(ctx, "SHOW CREATE TABLE "+qualifiedTable(schema, table)).Scan(&name, &ddl)
+	return ddl, err
+}
+
+func copyTable(ctx context.Context, conn *sql.Conn, fromSchema string, toSchema string, table string, create bool) (err error) {
+	from := qualifiedTable(fromSchema, table)
+	to := qualifiedTable(toSchema, table)
+	if create {
+		_, err = conn.ExecContext(ctx, "CREATE TABLE "+to+" LIKE "+from)
+	}
+	if err == nil {
+		_, err = conn.ExecContext(ctx, "INSERT INTO "+to+" SELECT * FROM "+from)
+	}
+	return err
+}
+
+func qualifiedTable(schema string, table string) string {
+	return quoteIdentifier(schema) + "." + quoteIdentifier(table)
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestImage_Snapshots(t *testing.T) {
+	img := &image{
+		options: Options{
+			Database: "foo",
+			Migrations: []Migration{
+				{
+					Filesystem: migrationFiles,
+					Path:       "_testdata",
+				},
+			},
+			InitialSnapshot: "initial",
+		},
+	}
+	err := img.Start()
+	defer func() {
+		img.shutdown()
+	}()
+	require.NoError(t, err)
+
+	count := func() (n int) {
+		err := img.db.QueryRow(`SELECT COUNT(*) FROM people`).Scan(&n)
+		require.NoError(t, err)
+		return n
+	}
+	_, err = img.db.Exec(`INSERT INTO people (id, given_name) VALUES ('1', 'Bilbo')`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count())
+	err = img.TakeSnapshot("one-person")
+	require.NoError(t, err)
+	_, err = img.db.Exec(`INSERT INTO people (id, given_name) VALUES ('2', 'Frodo')`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count())
+
+	err = img.RestoreSnapshot("one-person")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count())
+	err = img.RestoreSnapshot("initial")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count())
+
+	err = img.RestoreSnapshot("unknown")
+	require.Error(t, err)
+	assert.Equal(t, `restore snapshot "unknown": snapshot not found`, err.Error())
+}
+
+func TestImage_Snapshots_RetainsForeignKeys(t *testing.T) {
+	img := &image{
+		options: Options{
+			Database: "foo",
+		},
+	}
+	err := img.Start()
+	defer func() {
+		img.shutdown()
+	}()
+	require.NoError(t, err)
+
+	_, err = img.db.Exec(`CREATE TABLE parents (id VARCHAR(36) PRIMARY KEY)`)
+	require.NoError(t, err)
+	_, err = img.db.Exec(`CREATE TABLE children (id VARCHAR(36) PRIMARY KEY, parent_id VARCHAR(36), CONSTRAINT fk_parent FOREIGN KEY (parent_id) REFERENCES parents (id))`)
+	require.NoError(t, err)
+	_, err = img.db.Exec(`INSERT INTO parents (id) VALUES ('p1')`)
+	require.NoError(t, err)
+	_, err = img.db.Exec(`INSERT INTO children (id, parent_id) VALUES ('c1', 'p1')`)
+	require.NoError(t, err)
+	fkCount := func() (n int) {
+		err := img.db.QueryRow(`SELECT COUNT(*) FROM information_schema.referential_constraints WHERE constraint_schema = 'foo' AND table_name = 'children'`).Scan(&n)
+		require.NoError(t, err)
+		return n
+	}
+	assert.Equal(t, 1, fkCount())
+
+	err = img.TakeSnapshot("with-fks")
+	require.NoError(t, err)
+	_, err = img.db.Exec(`DROP TABLE children`)
+	require.NoError(t, err)
+	assert.Equal(t, 0, fkCount())
+
+	err = img.RestoreSnapshot("with-fks")
+	require.NoError(t, err)
+	assert.Equal(t, 1, fkCount())
+	var n int
+	err = img.db.QueryRow(`SELECT COUNT(*) FROM children`).Scan(&n)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, err = img.db.Exec(`INSERT INTO children (id, parent_id) VALUES ('c2', 'unknown')`)
+	require.Error(t, err)
+}
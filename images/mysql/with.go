@@ -27,6 +27,7 @@ func With(name string, options Options) Image {
 var _ with.With = (*image)(nil)
 var _ with.Image = (*image)(nil)
 var _ Image = (*image)(nil)
+var _ with.ImageDatabaseSnapshots = (*image)(nil)
 
 func (i *image) Init(init with.SuiteInit) error {
 	if err := i.Start(); err != nil {
@@ -29,10 +29,21 @@ func TestResolvablesAndBeforeAfters(t *testing.T) {
 				TTL: 24 * time.Hour,
 			},
 		},
+		CreateStreams: map[string]Stream{
+			"ORDERS": {
+				Subjects: []string{"orders.>"},
+				Consumers: map[string]Consumer{
+					"processor": {},
+				},
+			},
+		},
 	}
 	endpoint := Endpoint("/api", "",
 		BucketWatch("bucket-watch", myBucket),
 		Subscribe("subscription", mySubject),
+		StreamListener("orders", "ORDERS", "processor"),
+		StreamListener("orders-adhoc", "ORDERS", nil),
+		RequestReply("responder", "svc.lookup", JSON{"found": true}),
 		Method("GET", "").AssertOK().
 			Do(Publish(Before, mySubject, JSON{"foo": "bar"})).
 			Do(PutKey(Before, myBucket, "foo", "some-value")).
@@ -51,7 +62,18 @@ func TestResolvablesAndBeforeAfters(t *testing.T) {
 			Capture(After, EventsClear("bucket-watch")).
 			Capture(After, EventsClear("subscription")).
 			AssertEqual(0, EventsCount("bucket-watch")).
-			AssertEqual(0, EventsCount("subscription")),
+			AssertEqual(0, EventsCount("subscription")).
+			Do(Publish(After, "orders.created", JSON{"id": 1})).
+			Do(Publish(After, "orders.cancelled", JSON{"id": 2})).
+			Do(Publish(After, "svc.lookup", JSON{"id": 1})).
+			Wait(After, 1500).
+			AssertLen(StreamMessages("ORDERS", nil), 2).
+			AssertLen(StreamMessages("ORDERS", "orders.created"), 1).
+			AssertLen(StreamMessages("ORDERS", "orders.*"), 2).
+			AssertEqual(2, EventsCount("orders")).
+			AssertEqual(2, EventsCount("orders-adhoc")).
+			AssertEqual(`{"id":1}`, First(Events("orders"))).
+			AssertEqual(1, EventsCount("responder")),
 	)
 	var cov *coverage.Coverage
 	s := Suite(endpoint).Init(
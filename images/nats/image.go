@@ -38,7 +38,9 @@ func (i *image) Start() (err error) {
 	if i.container, err = tc.Run(ctx, i.options.useImage(), opts...); err == nil {
 		if err = i.mapPorts(ctx); err == nil {
 			if err = i.createClient(ctx); err == nil {
-				err = i.createBuckets()
+				if err = i.createBuckets(); err == nil {
+					err = i.createStreams()
+				}
 			}
 		}
 	}
@@ -106,6 +108,42 @@ func (i *image) createBuckets() (err error) {
 	return err
 }
 
+func (i *image) createStreams() (err error) {
+	if len(i.options.CreateStreams) > 0 {
+		var js nc.JetStreamContext
+		if js, err = i.client.JetStream(); err == nil {
+			for k, v := range i.options.CreateStreams {
+				if _, err = js.AddStream(&nc.StreamConfig{
+					Name:        k,
+					Description: v.Description,
+					Subjects:    v.Subjects,
+					Retention:   nc.RetentionPolicy(v.Retention),
+					MaxMsgs:     v.MaxMsgs,
+					MaxBytes:    v.MaxBytes,
+					MaxAge:      v.MaxAge,
+					Storage:     nc.StorageType(v.Storage),
+				}); err != nil {
+					return err
+				}
+				for cn, c := range v.Consumers {
+					if _, err = js.AddConsumer(k, &nc.ConsumerConfig{
+						Durable:       cn,
+						Description:   c.Description,
+						FilterSubject: c.FilterSubject,
+						DeliverPolicy: nc.DeliverPolicy(c.DeliverPolicy),
+						AckPolicy:     nc.AckExplicitPolicy,
+						AckWait:       c.AckWait,
+						MaxDeliver:    c.MaxDeliver,
+					}); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return err
+}
+
 func (i *image) shutdown() {
 	i.client.Close()
 	if i.container != nil && !i.options.LeaveRunning {
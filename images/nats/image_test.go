@@ -18,6 +18,16 @@ func TestImage_Start(t *testing.T) {
 					TTL:         24 * time.Hour,
 				},
 			},
+			CreateStreams: map[string]Stream{
+				"bar": {
+					Subjects: []string{"bar.>"},
+					Consumers: map[string]Consumer{
+						"baz": {
+							FilterSubject: "bar.baz",
+						},
+					},
+				},
+			},
 		},
 	}
 	err := img.Start()
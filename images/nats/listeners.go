@@ -30,10 +30,38 @@ func Subscribe(name string, subject any, imgName ...string) marrow.BeforeAfter {
 	return result
 }
 
+// RequestReply is a before operation that starts a Nats responder on a specified subject
+//
+// each request received is answered with the reply (which can be a resolvable - resolved when the responder is started)
+// and is recorded - the name identifies the listener (for use in marrow.Events, marrow.EventsCount and marrow.EventsClear)
+//
+// if a listener with that name has previously been created, it is cleared
+//
+//go:noinline
+func RequestReply(name string, subject any, reply any, imgName ...string) marrow.BeforeAfter {
+	result := &subjectListener{
+		capture: capture{
+			name:    name,
+			when:    marrow.Before,
+			imgName: imgName,
+			frame:   framing.NewFrame(0),
+		},
+		listenerName: name,
+		subject:      subject,
+		reply:        reply,
+		replies:      true,
+	}
+	result.run = result.runListener
+	return result
+}
+
 type subjectListener struct {
 	capture
 	listenerName string
 	subject      any
+	reply        any
+	replies      bool
+	replyData    []byte
 	msgs         []any
 	mutex        sync.RWMutex
 	sub          *nc.Subscription
@@ -45,9 +73,14 @@ func (l *subjectListener) runListener(ctx marrow.Context, img *image) (err error
 	if existing := ctx.Listener(l.listenerName); existing == nil {
 		var sv any
 		if sv, err = marrow.ResolveValue(l.subject, ctx); err == nil {
-			subject := fmt.Sprintf("%v", sv)
-			if l.sub, err = img.client.Subscribe(subject, l.handler); err == nil {
-				ctx.RegisterListener(l.listenerName, l)
+			if l.replies {
+				l.replyData, err = marrow.ResolveData(l.reply, ctx)
+			}
+			if err == nil {
+				subject := fmt.Sprintf("%v", sv)
+				if l.sub, err = img.client.Subscribe(subject, l.handler); err == nil {
+					ctx.RegisterListener(l.listenerName, l)
+				}
 			}
 		}
 	} else if _, ok := existing.(*subjectListener); !ok {
@@ -62,6 +95,9 @@ func (l *subjectListener) handler(msg *nc.Msg) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 	l.msgs = append(l.msgs, msg.Data)
+	if l.replies {
+		_ = msg.Respond(l.replyData)
+	}
 }
 
 func (l *subjectListener) Events() []any {
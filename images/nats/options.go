@@ -10,6 +10,7 @@ type Options struct {
 	SecretToken           string // defaults to "nats"
 	LeaveRunning          bool   // if set, the container is not shutdown
 	CreateKeyValueBuckets map[string]KeyValueBucket
+	CreateStreams         map[string]Stream
 }
 
 type KeyValueBucket struct {
@@ -22,6 +23,25 @@ type KeyValueBucket struct {
 	Compression  bool
 }
 
+type Stream struct {
+	Description string
+	Subjects    []string
+	Retention   int
+	MaxMsgs     int64
+	MaxBytes    int64
+	MaxAge      time.Duration
+	Storage     int
+	Consumers   map[string]Consumer // durable consumers to create on the stream
+}
+
+type Consumer struct {
+	Description   string
+	FilterSubject string
+	DeliverPolicy int
+	AckWait       time.Duration
+	MaxDeliver    int
+}
+
 const (
 	defaultImage    = "nats"
 	defaultVersion  = "latest"
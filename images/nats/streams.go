@@ -0,0 +1,213 @@
+package nats
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-andiamo/marrow"
+	"github.com/go-andiamo/marrow/framing"
+	nc "github.com/nats-io/nats.go"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StreamListener is a before operation that starts a durable JetStream consumer on a specified stream
+//
+// the name identifies the listener - for use in marrow.Events, marrow.EventsCount and marrow.EventsClear
+//
+// the consumer is the name of the durable consumer - if the consumer does not exist on the stream it is created
+// (if the consumer is nil or empty, the listener name is used as the consumer name)
+//
+// messages received are captured (as their data) and acknowledged
+//
+// if a listener with that name has previously been created, it is cleared
+//
+//go:noinline
+func StreamListener(name string, stream any, consumer any, imgName ...string) marrow.BeforeAfter {
+	result := &streamListener{
+		capture: capture{
+			name:    name,
+			when:    marrow.Before,
+			imgName: imgName,
+			frame:   framing.NewFrame(0),
+		},
+		listenerName: name,
+		stream:       stream,
+		consumer:     consumer,
+	}
+	result.run = result.runListener
+	return result
+}
+
+type streamListener struct {
+	capture
+	listenerName string
+	stream       any
+	consumer     any
+	msgs         []any
+	mutex        sync.RWMutex
+	sub          *nc.Subscription
+	stop         chan struct{}
+	stopOnce     sync.Once
+}
+
+var _ marrow.Listener = (*streamListener)(nil)
+
+func (l *streamListener) runListener(ctx marrow.Context, img *image) (err error) {
+	if existing := ctx.Listener(l.listenerName); existing == nil {
+		var sv any
+		var cv any
+		if sv, cv, err = marrow.ResolveValues(l.stream, l.consumer, ctx); err == nil {
+			sn := fmt.Sprintf("%v", sv)
+			cn := l.listenerName
+			if cv != nil && cv != "" {
+				cn = fmt.Sprintf("%v", cv)
+			}
+			var js nc.JetStreamContext
+			if js, err = img.client.JetStream(); err == nil {
+				if err = ensureConsumer(js, sn, cn); err == nil {
+					if l.sub, err = js.PullSubscribe("", cn, nc.Bind(sn, cn)); err == nil {
+						l.stop = make(chan struct{})
+						go l.receive(l.sub, l.stop)
+						ctx.RegisterListener(l.listenerName, l)
+					}
+				}
+			}
+		}
+	} else if _, ok := existing.(*streamListener); !ok {
+		err = fmt.Errorf("expected streamListener but got %T", existing)
+	} else {
+		existing.Clear()
+	}
+	return err
+}
+
+func ensureConsumer(js nc.JetStreamContext, stream string, consumer string) (err error) {
+	if _, err = js.ConsumerInfo(stream, consumer); errors.Is(err, nc.ErrConsumerNotFound) {
+		_, err = js.AddConsumer(stream, &nc.ConsumerConfig{
+			Durable:   consumer,
+			AckPolicy: nc.AckExplicitPolicy,
+		})
+	}
+	return err
+}
+
+// fetchErrorBackoff is the delay before fetching again after a (non-timeout) fetch error
+const fetchErrorBackoff = 100 * time.Millisecond
+
+func (l *streamListener) receive(sub *nc.Subscription, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		msgs, err := sub.Fetch(10, nc.MaxWait(time.Second))
+		if err != nil && !errors.Is(err, nc.ErrTimeout) {
+			if !sub.IsValid() {
+				return
+			}
+			select {
+			case <-stop:
+				return
+			case <-time.After(fetchErrorBackoff):
+			}
+		}
+		for _, msg := range msgs {
+			l.mutex.Lock()
+			l.msgs = append(l.msgs, msg.Data)
+			l.mutex.Unlock()
+			_ = msg.Ack()
+		}
+	}
+}
+
+func (l *streamListener) Events() []any {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	cp := make([]any, len(l.msgs))
+	copy(cp, l.msgs)
+	return cp
+}
+
+func (l *streamListener) EventsCount() int {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return len(l.msgs)
+}
+
+func (l *streamListener) Clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.msgs = make([]any, 0)
+}
+
+func (l *streamListener) Stop() {
+	l.stopOnce.Do(func() {
+		if l.stop != nil {
+			close(l.stop)
+		}
+		if l.sub != nil {
+			_ = l.sub.Unsubscribe()
+		}
+	})
+}
+
+// StreamMessages can be used as a resolvable value (e.g. in marrow.Method .AssertEqual)
+// and resolves to the data of all messages currently held in the named stream
+//
+// if the subject is non-nil, only messages matching that subject (wildcards "*" and ">" are supported) are resolved
+//
+//go:noinline
+func StreamMessages(stream any, subject any, imgName ...string) marrow.Resolvable {
+	return &resolvable{
+		name:    fmt.Sprintf("StreamMessages(%v, %v)", stream, subject),
+		imgName: imgName,
+		run: func(ctx marrow.Context, img *image) (av any, err error) {
+			var sv any
+			var subv any
+			if sv, subv, err = marrow.ResolveValues(stream, subject, ctx); err == nil {
+				sn := fmt.Sprintf("%v", sv)
+				filter := ""
+				if subv != nil {
+					filter = fmt.Sprintf("%v", subv)
+				}
+				var js nc.JetStreamContext
+				if js, err = img.client.JetStream(); err == nil {
+					var si *nc.StreamInfo
+					if si, err = js.StreamInfo(sn); err == nil {
+						result := make([]any, 0, si.State.Msgs)
+						for seq := si.State.FirstSeq; seq > 0 && seq <= si.State.LastSeq; seq++ {
+							var msg *nc.RawStreamMsg
+							if msg, err = js.GetMsg(sn, seq); err == nil {
+								if filter == "" || subjectMatches(filter, msg.Subject) {
+									result = append(result, msg.Data)
+								}
+							} else if errors.Is(err, nc.ErrMsgNotFound) {
+								err = nil
+							} else {
+								return nil, err
+							}
+						}
+						av = result
+					}
+				}
+			}
+			return av, err
+		},
+		frame: framing.NewFrame(0),
+	}
+}
+
+func subjectMatches(pattern string, subject string) bool {
+	pts := strings.Split(pattern, ".")
+	sts := strings.Split(subject, ".")
+	for i, pt := range pts {
+		if pt == ">" {
+			return len(sts) > i
+		} else if i >= len(sts) || (pt != "*" && pt != sts[i]) {
+			return false
+		}
+	}
+	return len(pts) == len(sts)
+}
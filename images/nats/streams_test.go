@@ -0,0 +1,32 @@
+package nats
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSubjectMatches(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		subject string
+		expect  bool
+	}{
+		{"foo", "foo", true},
+		{"foo", "bar", false},
+		{"foo.bar", "foo.bar", true},
+		{"foo.bar", "foo", false},
+		{"foo", "foo.bar", false},
+		{"foo.*", "foo.bar", true},
+		{"foo.*", "foo.bar.baz", false},
+		{"*.bar", "foo.bar", true},
+		{"foo.>", "foo.bar", true},
+		{"foo.>", "foo.bar.baz", true},
+		{"foo.>", "foo", false},
+		{">", "foo", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.subject, func(t *testing.T) {
+			assert.Equal(t, tc.expect, subjectMatches(tc.pattern, tc.subject))
+		})
+	}
+}
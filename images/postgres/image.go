@@ -18,6 +18,7 @@ type image struct {
 	db         *sql.DB
 	mappedPort string
 	container  testcontainers.Container
+	snapshots  map[string]string
 }
 
 func (i *image) Start() (err error) {
@@ -26,7 +27,9 @@ func (i *image) Start() (err error) {
 	}
 	if err = i.startContainer(); err == nil {
 		if err = i.openDatabase(); err == nil {
-			err = i.migrateDatabase()
+			if err = i.migrateDatabase(); err == nil {
+				err = i.initialSnapshot()
+			}
 		}
 	}
 	return err
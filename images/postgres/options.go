@@ -14,6 +14,10 @@ type Options struct {
 	LeaveRunning        bool        // if set, the container is not shutdown
 	Migrations          []Migration // is a list of Migration's to be run on the database
 	DisableAutoShutdown bool        // Deprecated: use with.DisableReaperShutdowns instead
+	// InitialSnapshot is the name of a snapshot to take after migrations have been run
+	//
+	// If this is a non-empty string, tests can restore the database to its initial state using marrow.DbRestoreSnapshot
+	InitialSnapshot string
 }
 
 // Migration is an individual migration to be run in a database
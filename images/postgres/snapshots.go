@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+)
+
+// TakeSnapshot takes a named snapshot of the database
+//
+// the snapshot is held as a template database - if a snapshot with the same name already exists, it is replaced
+func (i *image) TakeSnapshot(snapshotName string) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("take snapshot %q: %w", snapshotName, err)
+		}
+	}()
+	snapDb := i.snapshotDbName(snapshotName)
+	err = i.withAdminDb(func(admin *sql.DB) (err error) {
+		if _, err = admin.Exec("DROP DATABASE IF EXISTS " + pq.QuoteIdentifier(snapDb)); err == nil {
+			if err = copyDatabase(admin, i.databaseName(), snapDb); err == nil {
+				if i.snapshots == nil {
+					i.snapshots = make(map[string]string)
+				}
+				i.snapshots[snapshotName] = snapDb
+			}
+		}
+		return err
+	})
+	return err
+}
+
+// RestoreSnapshot restores the database to a previously taken named snapshot
+func (i *image) RestoreSnapshot(snapshotName string) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("restore snapshot %q: %w", snapshotName, err)
+		}
+	}()
+	snapDb, ok := i.snapshots[snapshotName]
+	if !ok {
+		return errors.New("snapshot not found")
+	}
+	err = i.withAdminDb(func(admin *sql.DB) (err error) {
+		if _, err = admin.Exec("DROP DATABASE IF EXISTS " + pq.QuoteIdentifier(i.databaseName()) + " WITH (FORCE)"); err == nil {
+			err = copyDatabase(admin, snapDb, i.databaseName())
+		}
+		return err
+	})
+	return err
+}
+
+func (i *image) initialSnapshot() error {
+	if i.options.InitialSnapshot != "" {
+		return i.TakeSnapshot(i.options.InitialSnapshot)
+	}
+	return nil
+}
+
+func (i *image) databaseName() string {
+	if i.options.Database != "" {
+		return i.options.Database
+	}
+	return i.options.username()
+}
+
+func (i *image) snapshotDbName(snapshotName string) string {
+	return i.databaseName() + "_snapshot_" + snapshotName
+}
+
+// withAdminDb releases idle connections on the image database and calls the fn with a connection
+// to the maintenance database (databases cannot be copied or dropped whilst they have connections)
+func (i *image) withAdminDb(fn func(admin *sql.DB) error) (err error) {
+	i.db.SetMaxIdleConns(0)
+	defer i.db.SetMaxIdleConns(defaultMaxIdleConns)
+	var admin *sql.DB
+	if admin, err = sql.Open("postgres", i.dsn("localhost", maintenanceDatabase)); err == nil {
+		defer func() {
+			_ = admin.Close()
+		}()
+		if err = admin.Ping(); err == nil {
+			err = fn(admin)
+		}
+	}
+	return err
+}
+
+const (
+	maintenanceDatabase = "postgres"
+	defaultMaxIdleConns = 2
+	copyAttempts        = 5
+)
+
+func copyDatabase(admin *sql.DB, from string, to string) (err error) {
+	for attempt := 0; attempt < copyAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		// template databases must have no other connections...
+		if _, err = admin.Exec("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()", from); err == nil {
+			if _, err = admin.Exec("CREATE DATABASE " + pq.QuoteIdentifier(to) + " TEMPLATE " + pq.QuoteIdentifier(from)); err == nil {
+				break
+			}
+		}
+	}
+	return err
+}
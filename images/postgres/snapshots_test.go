@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestImage_Snapshots(t *testing.T) {
+	img := &image{
+		options: Options{
+			Database: "foo",
+			Migrations: []Migration{
+				{
+					Filesystem: migrationFiles,
+					Path:       "_testdata",
+				},
+			},
+			InitialSnapshot: "initial",
+		},
+	}
+	err := img.Start()
+	defer func() {
+		img.shutdown()
+	}()
+	require.NoError(t, err)
+
+	count := func() (n int) {
+		err := img.db.QueryRow(`SELECT COUNT(*) FROM people`).Scan(&n)
+		require.NoError(t, err)
+		return n
+	}
+	_, err = img.db.Exec(`INSERT INTO people (id, given_name) VALUES ('1', 'Bilbo')`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count())
+	err = img.TakeSnapshot("one-person")
+	require.NoError(t, err)
+	_, err = img.db.Exec(`INSERT INTO people (id, given_name) VALUES ('2', 'Frodo')`)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count())
+
+	err = img.RestoreSnapshot("one-person")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count())
+	err = img.RestoreSnapshot("initial")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count())
+
+	err = img.RestoreSnapshot("unknown")
+	require.Error(t, err)
+	assert.Equal(t, `restore snapshot "unknown": snapshot not found`, err.Error())
+}
@@ -177,27 +177,7 @@ func SetKey(when marrow.When, name string, value any, expiry time.Duration, imgN
 		run: func(ctx marrow.Context, img *image) (err error) {
 			var av any
 			if av, err = marrow.ResolveValue(value, ctx); err == nil {
-				sv := ""
-				switch avt := av.(type) {
-				case string:
-					sv = avt
-				case []byte:
-					sv = string(avt)
-				case []any:
-					sv = jsonMarshal(av)
-				case map[string]any:
-					sv = jsonMarshal(av)
-				default:
-					if av != nil {
-						to := reflect.ValueOf(av)
-						if to.Kind() == reflect.Slice || to.Kind() == reflect.Map || to.Kind() == reflect.Struct {
-							sv = jsonMarshal(av)
-						} else {
-							sv = fmt.Sprintf("%v", av)
-						}
-					}
-				}
-				err = img.Client().Set(name, sv, expiry)
+				err = img.Client().Set(name, stringifyValue(av), expiry)
 			}
 			return err
 		},
@@ -252,6 +232,284 @@ func KeyExists(name string, imgName ...string) marrow.Resolvable {
 	}
 }
 
+// KeyTTL can be used as a resolvable value (e.g. in marrow.Method .AssertEqual)
+// and resolves to the remaining time-to-live (in seconds, as int64) of the named key
+//
+// resolves to -1 if the key exists but has no expiry, and -2 if the key does not exist
+//
+//go:noinline
+func KeyTTL(name string, imgName ...string) marrow.Resolvable {
+	return &resolvable{
+		name:    fmt.Sprintf("KeyTTL(%q)", name),
+		imgName: imgName,
+		run: func(ctx marrow.Context, img *image) (any, error) {
+			ttl, err := img.Client().TTL(name)
+			if err != nil {
+				return nil, err
+			}
+			if ttl < 0 {
+				return int64(ttl), nil
+			}
+			return int64(ttl / time.Second), nil
+		},
+		frame: framing.NewFrame(0),
+	}
+}
+
+// HashField can be used as a resolvable value (e.g. in marrow.Method .AssertEqual)
+// and resolves to the value of a field in the named hash
+//
+//go:noinline
+func HashField(key string, field string, imgName ...string) marrow.Resolvable {
+	return &resolvable{
+		name:    fmt.Sprintf("HashField(%q, %q)", key, field),
+		imgName: imgName,
+		run: func(ctx marrow.Context, img *image) (any, error) {
+			return img.Client().HashGet(key, field)
+		},
+		frame: framing.NewFrame(0),
+	}
+}
+
+// HashAll can be used as a resolvable value (e.g. in marrow.Method .AssertEqual)
+// and resolves to all the fields of the named hash (as `map[string]any`)
+//
+//go:noinline
+func HashAll(key string, imgName ...string) marrow.Resolvable {
+	return &resolvable{
+		name:    fmt.Sprintf("HashAll(%q)", key),
+		imgName: imgName,
+		run: func(ctx marrow.Context, img *image) (any, error) {
+			fields, err := img.Client().HashGetAll(key)
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[string]any, len(fields))
+			for k, v := range fields {
+				result[k] = v
+			}
+			return result, nil
+		},
+		frame: framing.NewFrame(0),
+	}
+}
+
+// SetMembers can be used as a resolvable value (e.g. in marrow.Method .AssertEqual)
+// and resolves to the members of the named set (as `[]any` - sorted)
+//
+//go:noinline
+func SetMembers(key string, imgName ...string) marrow.Resolvable {
+	return &resolvable{
+		name:    fmt.Sprintf("SetMembers(%q)", key),
+		imgName: imgName,
+		run: func(ctx marrow.Context, img *image) (any, error) {
+			members, err := img.Client().SetMembers(key)
+			if err != nil {
+				return nil, err
+			}
+			result := make([]any, len(members))
+			for i, m := range members {
+				result[i] = m
+			}
+			return result, nil
+		},
+		frame: framing.NewFrame(0),
+	}
+}
+
+// SortedSetRange can be used as a resolvable value (e.g. in marrow.Method .AssertEqual)
+// and resolves to the members of the named sorted set - by rank, from start to stop (inclusive)
+//
+// start and stop can be negative - which means offset from last, i.e. -1 is last
+//
+// the resolved value is a `[]any` - where each item is a `map[string]any` with properties "member" and "score"
+//
+//go:noinline
+func SortedSetRange(key string, start, stop int, imgName ...string) marrow.Resolvable {
+	return &resolvable{
+		name:    fmt.Sprintf("SortedSetRange(%q, %d, %d)", key, start, stop),
+		imgName: imgName,
+		run: func(ctx marrow.Context, img *image) (any, error) {
+			members, err := img.Client().SortedSetRange(key, int64(start), int64(stop))
+			if err != nil {
+				return nil, err
+			}
+			result := make([]any, len(members))
+			for i, m := range members {
+				result[i] = map[string]any{
+					"member": m.Member,
+					"score":  m.Score,
+				}
+			}
+			return result, nil
+		},
+		frame: framing.NewFrame(0),
+	}
+}
+
+// StreamEntries can be used as a resolvable value (e.g. in marrow.Method .AssertEqual)
+// and resolves to the entries on the named stream
+//
+// the resolved value is a `[]any` - where each item is a `map[string]any` with properties "id" and "values"
+//
+//go:noinline
+func StreamEntries(stream string, imgName ...string) marrow.Resolvable {
+	return &resolvable{
+		name:    fmt.Sprintf("StreamEntries(%q)", stream),
+		imgName: imgName,
+		run: func(ctx marrow.Context, img *image) (any, error) {
+			entries, err := img.Client().StreamRange(stream)
+			if err != nil {
+				return nil, err
+			}
+			result := make([]any, len(entries))
+			for i, e := range entries {
+				result[i] = streamEntryValue(e)
+			}
+			return result, nil
+		},
+		frame: framing.NewFrame(0),
+	}
+}
+
+func streamEntryValue(e StreamEntry) map[string]any {
+	values := make(map[string]any, len(e.Values))
+	for k, v := range e.Values {
+		values[k] = v
+	}
+	return map[string]any{
+		"id":     e.ID,
+		"values": values,
+	}
+}
+
+func stringifyValue(av any) (sv string) {
+	switch avt := av.(type) {
+	case string:
+		sv = avt
+	case []byte:
+		sv = string(avt)
+	case []any:
+		sv = jsonMarshal(av)
+	case map[string]any:
+		sv = jsonMarshal(av)
+	default:
+		if av != nil {
+			to := reflect.ValueOf(av)
+			if to.Kind() == reflect.Slice || to.Kind() == reflect.Map || to.Kind() == reflect.Struct {
+				sv = jsonMarshal(av)
+			} else {
+				sv = fmt.Sprintf("%v", av)
+			}
+		}
+	}
+	return sv
+}
+
+func resolveFields(values map[string]any, ctx marrow.Context) (map[string]any, error) {
+	result := make(map[string]any, len(values))
+	for k, v := range values {
+		av, err := marrow.ResolveValue(v, ctx)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = stringifyValue(av)
+	}
+	return result, nil
+}
+
+// SetHash can be used as a before/after on marrow.Method .Capture
+// and sets fields of the named hash
+//
+// note: the field values can be resolvables
+//
+//go:noinline
+func SetHash(when marrow.When, key string, values map[string]any, imgName ...string) marrow.BeforeAfter {
+	return &capture{
+		name:    fmt.Sprintf("SetHash(%q)", key),
+		when:    when,
+		imgName: imgName,
+		run: func(ctx marrow.Context, img *image) (err error) {
+			var fields map[string]any
+			if fields, err = resolveFields(values, ctx); err == nil {
+				err = img.Client().HashSet(key, fields)
+			}
+			return err
+		},
+		frame: framing.NewFrame(0),
+	}
+}
+
+// AddSetMembers can be used as a before/after on marrow.Method .Capture
+// and adds members to the named set
+//
+// note: the members can be resolvables
+//
+//go:noinline
+func AddSetMembers(when marrow.When, key string, members []any, imgName ...string) marrow.BeforeAfter {
+	return &capture{
+		name:    fmt.Sprintf("AddSetMembers(%q)", key),
+		when:    when,
+		imgName: imgName,
+		run: func(ctx marrow.Context, img *image) (err error) {
+			actual := make([]any, 0, len(members))
+			for _, m := range members {
+				var av any
+				if av, err = marrow.ResolveValue(m, ctx); err != nil {
+					return err
+				}
+				actual = append(actual, stringifyValue(av))
+			}
+			return img.Client().SetAdd(key, actual...)
+		},
+		frame: framing.NewFrame(0),
+	}
+}
+
+// AddSortedSetMembers can be used as a before/after on marrow.Method .Capture
+// and adds members to the named sorted set
+//
+// the members arg is a map of member to score
+//
+//go:noinline
+func AddSortedSetMembers(when marrow.When, key string, members map[string]float64, imgName ...string) marrow.BeforeAfter {
+	return &capture{
+		name:    fmt.Sprintf("AddSortedSetMembers(%q)", key),
+		when:    when,
+		imgName: imgName,
+		run: func(ctx marrow.Context, img *image) error {
+			actual := make([]SortedSetMember, 0, len(members))
+			for m, score := range members {
+				actual = append(actual, SortedSetMember{Member: m, Score: score})
+			}
+			return img.Client().SortedSetAdd(key, actual...)
+		},
+		frame: framing.NewFrame(0),
+	}
+}
+
+// AddStreamEntry can be used as a before/after on marrow.Method .Capture
+// and adds an entry to the named stream
+//
+// note: the values can be resolvables
+//
+//go:noinline
+func AddStreamEntry(when marrow.When, stream string, values map[string]any, imgName ...string) marrow.BeforeAfter {
+	return &capture{
+		name:    fmt.Sprintf("AddStreamEntry(%q)", stream),
+		when:    when,
+		imgName: imgName,
+		run: func(ctx marrow.Context, img *image) (err error) {
+			var fields map[string]any
+			if fields, err = resolveFields(values, ctx); err == nil {
+				_, err = img.Client().StreamAdd(stream, fields)
+			}
+			return err
+		},
+		frame: framing.NewFrame(0),
+	}
+}
+
 type capture struct {
 	name    string
 	when    marrow.When
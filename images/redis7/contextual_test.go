@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	. "github.com/go-andiamo/marrow"
 	"github.com/go-andiamo/marrow/common"
@@ -84,6 +85,24 @@ func TestCapturesAndListeners(t *testing.T) {
 			AssertEqual(Key("bar"), "[42,43]").
 			Do(DeleteKey(After, "bar")).
 			AssertEqual(KeyExists("bar"), false).
+			Do(SetKey(After, "ttl", "v", time.Minute)).
+			AssertGreaterThan(KeyTTL("ttl"), 50).
+			AssertEqual(KeyTTL("no_such_key"), -2).
+			Do(SetHash(After, "hash", map[string]any{"foo": "bar", "baz": Var("qux")})).
+			AssertEqual(HashField("hash", "foo"), "bar").
+			AssertEqual(JsonPath(HashAll("hash"), "baz"), "42").
+			Do(AddSetMembers(After, "set", []any{"b", "a"})).
+			AssertEqual(SetMembers("set"), JSONArray{"a", "b"}).
+			Do(AddSortedSetMembers(After, "zset", map[string]float64{"b": 2, "a": 1})).
+			AssertEqual(JsonPath(First(SortedSetRange("zset", 0, -1)), "member"), "a").
+			AssertEqual(JsonPath(Last(SortedSetRange("zset", 0, -1)), "score"), 2.0).
+			Do(StreamListener("events", "stream", "", Receiver{})).
+			Do(AddStreamEntry(After, "stream", map[string]any{"foo": "bar"})).
+			AssertLen(StreamEntries("stream"), 1).
+			AssertEqual(JsonTraverse(StreamEntries("stream"), FIRST, "values", "foo"), "bar").
+			Wait(After, 1500).
+			AssertEqual(EventsCount("events"), 1).
+			AssertEqual(JsonTraverse(Events("events"), FIRST, "values", "foo"), "bar").
 			AssertEqual(QueueLen("some_queue"), 1).
 			AssertEqual(1, ReceivedQueueMessages("queue_foo")).
 			AssertEqual(5, ReceivedQueueMessages("queue_bar")).
@@ -121,6 +140,7 @@ func TestCapturesAndListeners(t *testing.T) {
 	s := Suite(endpoint).Init(
 		With(options),
 		with.HttpDo(do),
+		with.Var("qux", 42),
 		with.ReportCoverage(func(coverage *coverage.Coverage) {
 			cov = coverage
 		}),
@@ -157,6 +177,33 @@ func TestReceivedTopicMessages(t *testing.T) {
 	assert.Equal(t, "redis.ReceivedTopicMessages(\"topic_foo\")", fmt.Sprintf("%s", c))
 }
 
+func TestDataTypeResolvables(t *testing.T) {
+	assert.Equal(t, `redis.KeyTTL("foo")`, fmt.Sprintf("%s", KeyTTL("foo")))
+	assert.Equal(t, `redis.HashField("foo", "bar")`, fmt.Sprintf("%s", HashField("foo", "bar")))
+	assert.Equal(t, `redis.HashAll("foo")`, fmt.Sprintf("%s", HashAll("foo")))
+	assert.Equal(t, `redis.SetMembers("foo")`, fmt.Sprintf("%s", SetMembers("foo")))
+	assert.Equal(t, `redis.SortedSetRange("foo", 0, -1)`, fmt.Sprintf("%s", SortedSetRange("foo", 0, -1)))
+	assert.Equal(t, `redis.StreamEntries("foo")`, fmt.Sprintf("%s", StreamEntries("foo")))
+}
+
+func TestDataTypeCaptures(t *testing.T) {
+	w := SetHash(After, "foo", map[string]any{"bar": "baz"})
+	assert.Equal(t, After, w.When())
+	assert.NotNil(t, w.Frame())
+	w = AddSetMembers(Before, "foo", []any{"bar"})
+	assert.Equal(t, Before, w.When())
+	assert.NotNil(t, w.Frame())
+	w = AddSortedSetMembers(After, "foo", map[string]float64{"bar": 1})
+	assert.Equal(t, After, w.When())
+	assert.NotNil(t, w.Frame())
+	w = AddStreamEntry(Before, "foo", map[string]any{"bar": "baz"})
+	assert.Equal(t, Before, w.When())
+	assert.NotNil(t, w.Frame())
+	w = StreamListener("", "foo", "", Receiver{})
+	assert.Equal(t, Before, w.When())
+	assert.NotNil(t, w.Frame())
+}
+
 type dummyDo struct {
 	status int
 	body   []byte
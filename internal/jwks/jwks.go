@@ -0,0 +1,75 @@
+// Package jwks provides JWK helpers shared by marrow packages
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// PublicJwk returns the JWT signing algorithm and the public JWK for a private key
+func PublicJwk(key crypto.Signer) (alg string, jwk map[string]any, err error) {
+	switch kt := key.(type) {
+	case *rsa.PrivateKey:
+		alg = "RS256"
+		jwk = map[string]any{
+			"kty": "RSA",
+			"e":   b64(big.NewInt(int64(kt.E)).Bytes()),
+			"n":   b64(kt.N.Bytes()),
+		}
+	case *ecdsa.PrivateKey:
+		if kt.Curve != elliptic.P256() {
+			return "", nil, errors.New("only P-256 ecdsa keys are supported")
+		}
+		alg = "ES256"
+		jwk = map[string]any{
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   b64(kt.X.FillBytes(make([]byte, 32))),
+			"y":   b64(kt.Y.FillBytes(make([]byte, 32))),
+		}
+	case ed25519.PrivateKey:
+		alg = "EdDSA"
+		jwk = map[string]any{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"x":   b64(kt.Public().(ed25519.PublicKey)),
+		}
+	default:
+		return "", nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return alg, jwk, nil
+}
+
+// Thumbprint computes the RFC 7638 thumbprint of a public JWK (used as the key id)
+func Thumbprint(jwk map[string]any) string {
+	var members []string
+	switch jwk["kty"] {
+	case "RSA":
+		members = []string{"e", "kty", "n"}
+	case "EC":
+		members = []string{"crv", "kty", "x", "y"}
+	default:
+		members = []string{"crv", "kty", "x"}
+	}
+	// json.Marshal of a map orders keys - giving the required lexicographic ordering of required members...
+	required := make(map[string]any, len(members))
+	for _, m := range members {
+		required[m] = jwk[m]
+	}
+	data, _ := json.Marshal(required)
+	sum := sha256.Sum256(data)
+	return b64(sum[:])
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
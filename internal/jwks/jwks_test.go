@@ -0,0 +1,53 @@
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPublicJwk(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	alg, jwk, err := PublicJwk(rsaKey)
+	require.NoError(t, err)
+	assert.Equal(t, "RS256", alg)
+	assert.Equal(t, "RSA", jwk["kty"])
+	assert.Equal(t, "AQAB", jwk["e"])
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	alg, jwk, err = PublicJwk(ecKey)
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", alg)
+	assert.Equal(t, "P-256", jwk["crv"])
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	alg, jwk, err = PublicJwk(edKey)
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", alg)
+	assert.Equal(t, "OKP", jwk["kty"])
+
+	ecKey, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	_, _, err = PublicJwk(ecKey)
+	require.Error(t, err)
+}
+
+func TestThumbprint(t *testing.T) {
+	// RFC 7638 section 3.1 example...
+	jwk := map[string]any{
+		"kty": "RSA",
+		"n":   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		"e":   "AQAB",
+		"alg": "RS256",
+		"kid": "2011-04-29",
+	}
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", Thumbprint(jwk))
+}
@@ -0,0 +1,22 @@
+// Package netutil provides network helpers shared by marrow packages
+package netutil
+
+import "net"
+
+// LocalIP returns the first non-loopback IPv4 address of the host (or "127.0.0.1" if there is none) - i.e. an
+// address that is reachable from containers
+func LocalIP() (result string) {
+	result = "127.0.0.1"
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			// check if the address is an IP address and not a loopback...
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
+				if ipNet.IP.To4() != nil { // ensure it's an IPv4 address
+					result = ipNet.IP.String()
+					break
+				}
+			}
+		}
+	}
+	return result
+}
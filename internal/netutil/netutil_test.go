@@ -0,0 +1,13 @@
+package netutil
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestLocalIP(t *testing.T) {
+	ip := net.ParseIP(LocalIP())
+	assert.NotNil(t, ip)
+	assert.NotNil(t, ip.To4())
+}
@@ -0,0 +1,661 @@
+package marrow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-andiamo/marrow/framing"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JsonCompareOptions are the options used by ExpectJsonEqual and ExpectJsonSubset
+type JsonCompareOptions struct {
+	// IgnorePaths are the paths that are ignored when comparing (e.g. "$.createdAt", "$.items[*].id", "$.*.version")
+	//
+	// a path segment can be a property name (".name" or "['name']"), an array index ("[0]") or a wildcard (".*" or "[*]")
+	IgnorePaths []string
+	// UnorderedArrays when set, arrays are compared regardless of the order of elements
+	UnorderedArrays bool
+	// NumericTolerance is the absolute tolerance used when comparing numbers
+	NumericTolerance float64
+}
+
+// JsonDiff describes the differences found by ExpectJsonEqual and ExpectJsonSubset
+type JsonDiff []JsonDifference
+
+// JsonDifference describes a single difference found by ExpectJsonEqual and ExpectJsonSubset
+type JsonDifference struct {
+	// Path is the path of the difference (e.g. "$.items[1].name")
+	Path string
+	// Reason is the reason for the difference - one of "mismatch", "missing" or "unexpected"
+	Reason   string
+	Expected any
+	Actual   any
+}
+
+const (
+	jsonDiffMismatch   = "mismatch"
+	jsonDiffMissing    = "missing"
+	jsonDiffUnexpected = "unexpected"
+)
+
+func (d JsonDiff) stringify() string {
+	var b strings.Builder
+	if len(d) == 1 {
+		b.WriteString("1 difference")
+	} else {
+		b.WriteString(fmt.Sprintf("%d differences", len(d)))
+	}
+	for _, diff := range d {
+		b.WriteString("\n\t          \t" + diff.String())
+	}
+	return b.String()
+}
+
+func (d JsonDifference) String() string {
+	switch d.Reason {
+	case jsonDiffMissing:
+		return d.Path + ": missing, expected " + stringifyJsonValue(d.Expected)
+	case jsonDiffUnexpected:
+		return d.Path + ": unexpected " + stringifyJsonValue(d.Actual)
+	default:
+		return d.Path + ": expected " + stringifyJsonValue(d.Expected) + ", actual " + stringifyJsonValue(d.Actual)
+	}
+}
+
+func stringifyJsonValue(v any) string {
+	switch vt := v.(type) {
+	case nil:
+		return "null"
+	case JsonMatcher:
+		return vt.String()
+	case string:
+		return strconv.Quote(vt)
+	case map[string]any, []any:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(vt); err == nil {
+			return strings.TrimSuffix(buf.String(), "\n")
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// JsonMatcher is a placeholder matcher that can be used within the expected value of ExpectJsonEqual and ExpectJsonSubset
+//
+// example:
+//
+//	ExpectJsonEqual(Body, JSON{
+//	    "id":        CaptureVar("id", AnyUUID()),
+//	    "name":      "foo",
+//	    "createdAt": AnyTime(),
+//	})
+type JsonMatcher interface {
+	// Match returns whether the actual value matches
+	Match(v any) bool
+	fmt.Stringer
+}
+
+type jsonMatcher struct {
+	name  string
+	match func(v any) bool
+}
+
+func (m *jsonMatcher) Match(v any) bool {
+	return m.match(v)
+}
+
+func (m *jsonMatcher) String() string {
+	return m.name
+}
+
+// AnyValue is a JsonMatcher that matches any value (including null) - but the value must be present
+func AnyValue() JsonMatcher {
+	return &jsonMatcher{
+		name: "AnyValue()",
+		match: func(v any) bool {
+			return true
+		},
+	}
+}
+
+// AnyString is a JsonMatcher that matches any string value
+func AnyString() JsonMatcher {
+	return &jsonMatcher{
+		name: "AnyString()",
+		match: func(v any) bool {
+			_, ok := v.(string)
+			return ok
+		},
+	}
+}
+
+// AnyNumber is a JsonMatcher that matches any numeric value
+func AnyNumber() JsonMatcher {
+	return &jsonMatcher{
+		name: "AnyNumber()",
+		match: func(v any) bool {
+			_, ok := jsonNumber(v)
+			return ok
+		},
+	}
+}
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// AnyUUID is a JsonMatcher that matches any string value that is a UUID (e.g. "a9b2e9a4-6e0a-4c2b-9d43-2c1f0a6b8e71")
+func AnyUUID() JsonMatcher {
+	return &jsonMatcher{
+		name: "AnyUUID()",
+		match: func(v any) bool {
+			s, ok := v.(string)
+			return ok && uuidRegex.MatchString(s)
+		},
+	}
+}
+
+// AnyTime is a JsonMatcher that matches any string value that parses as a time using any of the supplied layouts
+//
+// if no layouts are supplied, time.RFC3339Nano is used (which also parses time.RFC3339)
+func AnyTime(layouts ...string) JsonMatcher {
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339Nano}
+	}
+	return &jsonMatcher{
+		name: "AnyTime()",
+		match: func(v any) bool {
+			if s, ok := v.(string); ok {
+				for _, layout := range layouts {
+					if _, err := time.Parse(layout, s); err == nil {
+						return true
+					}
+				}
+			}
+			return false
+		},
+	}
+}
+
+type captureVarMatcher struct {
+	name    Var
+	matcher JsonMatcher
+}
+
+// CaptureVar is a JsonMatcher that matches using the optional matcher (or any value if no matcher supplied) - and
+// when matched, sets the named variable to the actual value
+func CaptureVar(name Var, matcher ...JsonMatcher) JsonMatcher {
+	result := &captureVarMatcher{name: name}
+	if len(matcher) > 0 {
+		result.matcher = matcher[0]
+	}
+	return result
+}
+
+func (m *captureVarMatcher) Match(v any) bool {
+	return m.matcher == nil || m.matcher.Match(v)
+}
+
+func (m *captureVarMatcher) String() string {
+	if m.matcher == nil {
+		return fmt.Sprintf("CaptureVar(%s)", string(m.name))
+	}
+	return fmt.Sprintf("CaptureVar(%s, %s)", string(m.name), m.matcher.String())
+}
+
+type jsonCompare struct {
+	actual   any
+	expected any
+	subset   bool
+	options  JsonCompareOptions
+	frame    *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*jsonCompare)(nil)
+
+// ExpectJsonEqual asserts that the actual value is structurally equal to the expected JSON value
+//
+// the actual and expected values can be JSON, JSONArray, map[string]any, []any, []byte (json data), structs or anything
+// that resolves to these (e.g. Body or JsonPath) - the expected value can also contain placeholder matchers
+// (see JsonMatcher - e.g. AnyString, AnyUUID, AnyTime or CaptureVar)
+//
+// if the values are not equal, the unmet error actual value is a JsonDiff (giving the path-by-path differences)
+//
+//go:noinline
+func ExpectJsonEqual(actual any, expected any, options ...JsonCompareOptions) Expectation {
+	return &jsonCompare{
+		actual:   actual,
+		expected: expected,
+		options:  firstJsonCompareOptions(options),
+		frame:    framing.NewFrame(0),
+	}
+}
+
+// ExpectJsonSubset asserts that the expected JSON value is a structural subset of the actual value
+//
+// i.e. the same as ExpectJsonEqual, except that properties in the actual value not in the expected value are ignored
+// and actual arrays may contain additional elements
+//
+//go:noinline
+func ExpectJsonSubset(actual any, expected any, options ...JsonCompareOptions) Expectation {
+	return &jsonCompare{
+		actual:   actual,
+		expected: expected,
+		subset:   true,
+		options:  firstJsonCompareOptions(options),
+		frame:    framing.NewFrame(0),
+	}
+}
+
+func firstJsonCompareOptions(options []JsonCompareOptions) JsonCompareOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return JsonCompareOptions{}
+}
+
+func (e *jsonCompare) Name() string {
+	if e.subset {
+		return "Expect Json Subset"
+	}
+	return "Expect Json Equal"
+}
+
+func (e *jsonCompare) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *jsonCompare) Met(ctx Context) (unmet error, err error) {
+	var av, ev any
+	if av, ev, err = ResolveValues(e.actual, e.expected, ctx); err == nil {
+		if av, err = normalizeJsonValue(av); err == nil {
+			if ev, err = normalizeJsonValue(ev); err == nil {
+				var c *jsonComparer
+				if c, err = newJsonComparer(e.subset, e.options); err == nil {
+					diff := c.compare(jsonPath{}, ev, av)
+					for k, v := range c.captures {
+						ctx.SetVar(k, v)
+					}
+					if len(diff) > 0 {
+						msg := "expected json equal"
+						if e.subset {
+							msg = "expected json subset"
+						}
+						unmet = &unmetError{
+							msg:      msg,
+							name:     e.Name(),
+							expected: OperandValue{Original: e.expected, Resolved: ev},
+							actual:   OperandValue{Original: e.actual, Resolved: diff},
+							frame:    e.frame,
+						}
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+// normalizeJsonValue normalizes a value to a JSON representation (i.e. map[string]any / []any / primitives) - leaving
+// any JsonMatcher as is
+func normalizeJsonValue(v any) (any, error) {
+	switch vt := v.(type) {
+	case nil, string, bool, JsonMatcher:
+		return v, nil
+	case []byte:
+		decoder := json.NewDecoder(strings.NewReader(string(vt)))
+		decoder.UseNumber()
+		var jv any
+		if err := decoder.Decode(&jv); err != nil {
+			return nil, err
+		}
+		return normalizeBody(jv)
+	case json.Number:
+		return normalizeBodyJsonNumber(vt)
+	case JSON:
+		return normalizeJsonValue(map[string]any(vt))
+	case JSONArray:
+		return normalizeJsonValue([]any(vt))
+	case map[string]any:
+		result := make(map[string]any, len(vt))
+		for k, mv := range vt {
+			nv, err := normalizeJsonValue(mv)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = nv
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(vt))
+		for i, sv := range vt {
+			nv, err := normalizeJsonValue(sv)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = nv
+		}
+		return result, nil
+	}
+	if _, ok := jsonNumber(v); ok {
+		return v, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeJsonValue(data)
+}
+
+func jsonNumber(v any) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+type jsonPathSegment struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+type jsonPath []jsonPathSegment
+
+var jsonSimpleName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (p jsonPath) String() string {
+	var b strings.Builder
+	b.WriteRune('$')
+	for _, seg := range p {
+		switch {
+		case seg.wildcard && seg.isIndex:
+			b.WriteString("[*]")
+		case seg.wildcard:
+			b.WriteString(".*")
+		case seg.isIndex:
+			b.WriteString("[" + strconv.Itoa(seg.index) + "]")
+		case jsonSimpleName.MatchString(seg.name):
+			b.WriteString("." + seg.name)
+		default:
+			b.WriteString("['" + strings.ReplaceAll(strings.ReplaceAll(seg.name, `\`, `\\`), "'", `\'`) + "']")
+		}
+	}
+	return b.String()
+}
+
+func (p jsonPath) property(name string) jsonPath {
+	return append(p[:len(p):len(p)], jsonPathSegment{name: name})
+}
+
+func (p jsonPath) element(index int) jsonPath {
+	return append(p[:len(p):len(p)], jsonPathSegment{index: index, isIndex: true})
+}
+
+// matches returns whether the path matches the pattern path (pattern path segments can be wildcards)
+func (p jsonPath) matches(pattern jsonPath) bool {
+	if len(p) != len(pattern) {
+		return false
+	}
+	for i, ps := range pattern {
+		s := p[i]
+		if ps.wildcard {
+			if ps.isIndex && !s.isIndex {
+				return false
+			}
+		} else if ps.isIndex != s.isIndex || ps.index != s.index || ps.name != s.name {
+			return false
+		}
+	}
+	return true
+}
+
+// parseJsonPath parses a simple json path (e.g. "$.items[*].name" or "$['a.b'][0]") - the leading "$" is optional
+func parseJsonPath(path string) (jsonPath, error) {
+	result := jsonPath{}
+	s := strings.TrimPrefix(path, "$")
+	if s != "" && s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end == -1 {
+				end = len(s)
+			}
+			name := s[:end]
+			if name == "" {
+				return nil, fmt.Errorf("invalid json path %q", path)
+			}
+			if name == "*" {
+				result = append(result, jsonPathSegment{wildcard: true})
+			} else {
+				result = append(result, jsonPathSegment{name: name})
+			}
+			s = s[end:]
+		case '[':
+			if strings.HasPrefix(s, "['") {
+				var name strings.Builder
+				i := 2
+				closed := false
+				for ; i < len(s); i++ {
+					if s[i] == '\\' && i+1 < len(s) {
+						i++
+						name.WriteByte(s[i])
+					} else if s[i] == '\'' {
+						closed = true
+						break
+					} else {
+						name.WriteByte(s[i])
+					}
+				}
+				if !closed || i+1 >= len(s) || s[i+1] != ']' {
+					return nil, fmt.Errorf("invalid json path %q", path)
+				}
+				result = append(result, jsonPathSegment{name: name.String()})
+				s = s[i+2:]
+			} else {
+				end := strings.IndexByte(s, ']')
+				if end == -1 {
+					return nil, fmt.Errorf("invalid json path %q", path)
+				}
+				if idx := s[1:end]; idx == "*" {
+					result = append(result, jsonPathSegment{isIndex: true, wildcard: true})
+				} else if n, err := strconv.Atoi(idx); err == nil && n >= 0 {
+					result = append(result, jsonPathSegment{isIndex: true, index: n})
+				} else {
+					return nil, fmt.Errorf("invalid json path %q", path)
+				}
+				s = s[end+1:]
+			}
+		default:
+			return nil, fmt.Errorf("invalid json path %q", path)
+		}
+	}
+	return result, nil
+}
+
+type jsonComparer struct {
+	subset    bool
+	unordered bool
+	tolerance float64
+	ignores   []jsonPath
+	captures  map[Var]any
+}
+
+func newJsonComparer(subset bool, options JsonCompareOptions) (*jsonComparer, error) {
+	result := &jsonComparer{
+		subset:    subset,
+		unordered: options.UnorderedArrays,
+		tolerance: options.NumericTolerance,
+		ignores:   make([]jsonPath, 0, len(options.IgnorePaths)),
+		captures:  map[Var]any{},
+	}
+	for _, ip := range options.IgnorePaths {
+		p, err := parseJsonPath(ip)
+		if err != nil {
+			return nil, err
+		}
+		result.ignores = append(result.ignores, p)
+	}
+	return result, nil
+}
+
+func (c *jsonComparer) ignored(path jsonPath) bool {
+	for _, ip := range c.ignores {
+		if path.matches(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *jsonComparer) compare(path jsonPath, expected any, actual any) (diff JsonDiff) {
+	if c.ignored(path) {
+		return nil
+	}
+	switch et := expected.(type) {
+	case JsonMatcher:
+		if !et.Match(actual) {
+			diff = append(diff, JsonDifference{Path: path.String(), Reason: jsonDiffMismatch, Expected: et, Actual: actual})
+		} else if cv, ok := et.(*captureVarMatcher); ok {
+			c.captures[cv.name] = actual
+		}
+	case map[string]any:
+		if am, ok := actual.(map[string]any); ok {
+			diff = c.compareObjects(path, et, am)
+		} else {
+			diff = append(diff, JsonDifference{Path: path.String(), Reason: jsonDiffMismatch, Expected: et, Actual: actual})
+		}
+	case []any:
+		if aa, ok := actual.([]any); !ok {
+			diff = append(diff, JsonDifference{Path: path.String(), Reason: jsonDiffMismatch, Expected: et, Actual: actual})
+		} else if c.unordered {
+			diff = c.compareUnorderedArrays(path, et, aa)
+		} else {
+			diff = c.compareArrays(path, et, aa)
+		}
+	default:
+		if !c.equalValues(expected, actual) {
+			diff = append(diff, JsonDifference{Path: path.String(), Reason: jsonDiffMismatch, Expected: expected, Actual: actual})
+		}
+	}
+	return diff
+}
+
+func (c *jsonComparer) compareObjects(path jsonPath, expected map[string]any, actual map[string]any) (diff JsonDiff) {
+	for _, k := range sortedKeys(expected) {
+		pp := path.property(k)
+		if av, ok := actual[k]; ok {
+			diff = append(diff, c.compare(pp, expected[k], av)...)
+		} else if !c.ignored(pp) {
+			diff = append(diff, JsonDifference{Path: pp.String(), Reason: jsonDiffMissing, Expected: expected[k]})
+		}
+	}
+	if !c.subset {
+		for _, k := range sortedKeys(actual) {
+			if _, ok := expected[k]; !ok {
+				if pp := path.property(k); !c.ignored(pp) {
+					diff = append(diff, JsonDifference{Path: pp.String(), Reason: jsonDiffUnexpected, Actual: actual[k]})
+				}
+			}
+		}
+	}
+	return diff
+}
+
+func (c *jsonComparer) compareArrays(path jsonPath, expected []any, actual []any) (diff JsonDiff) {
+	for i, ev := range expected {
+		ep := path.element(i)
+		if i < len(actual) {
+			diff = append(diff, c.compare(ep, ev, actual[i])...)
+		} else if !c.ignored(ep) {
+			diff = append(diff, JsonDifference{Path: ep.String(), Reason: jsonDiffMissing, Expected: ev})
+		}
+	}
+	if !c.subset {
+		for i := len(expected); i < len(actual); i++ {
+			if ep := path.element(i); !c.ignored(ep) {
+				diff = append(diff, JsonDifference{Path: ep.String(), Reason: jsonDiffUnexpected, Actual: actual[i]})
+			}
+		}
+	}
+	return diff
+}
+
+func (c *jsonComparer) compareUnorderedArrays(path jsonPath, expected []any, actual []any) (diff JsonDiff) {
+	used := make([]bool, len(actual))
+	for i, ev := range expected {
+		found := false
+		for j, av := range actual {
+			if !used[j] {
+				trial := &jsonComparer{
+					subset:    c.subset,
+					unordered: c.unordered,
+					tolerance: c.tolerance,
+					ignores:   c.ignores,
+					captures:  map[Var]any{},
+				}
+				if len(trial.compare(path.element(j), ev, av)) == 0 {
+					used[j] = true
+					found = true
+					for k, v := range trial.captures {
+						c.captures[k] = v
+					}
+					break
+				}
+			}
+		}
+		if !found {
+			diff = append(diff, JsonDifference{Path: path.element(i).String(), Reason: jsonDiffMissing, Expected: ev})
+		}
+	}
+	if !c.subset {
+		for j, av := range actual {
+			if !used[j] {
+				if ep := path.element(j); !c.ignored(ep) {
+					diff = append(diff, JsonDifference{Path: ep.String(), Reason: jsonDiffUnexpected, Actual: av})
+				}
+			}
+		}
+	}
+	return diff
+}
+
+func (c *jsonComparer) equalValues(expected any, actual any) bool {
+	if en, ok := jsonNumber(expected); ok {
+		if an, ok := jsonNumber(actual); ok {
+			return en == an || math.Abs(en-an) <= c.tolerance
+		}
+		return false
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+func sortedKeys(m map[string]any) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
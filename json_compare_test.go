@@ -0,0 +1,235 @@
+package marrow
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestExpectJsonEqual(t *testing.T) {
+	body := map[string]any{
+		"id":        "a9b2e9a4-6e0a-4c2b-9d43-2c1f0a6b8e71",
+		"name":      "Bilbo",
+		"age":       int64(111),
+		"height":    1.07,
+		"createdAt": "2025-01-02T03:04:05Z",
+		"tags":      []any{"hobbit", "burglar"},
+		"address":   map[string]any{"street": "Bagshot Row", "town": "Hobbiton"},
+	}
+	t.Run("met", func(t *testing.T) {
+		exp := ExpectJsonEqual(Body, JSON{
+			"id":        CaptureVar("id", AnyUUID()),
+			"name":      "Bilbo",
+			"age":       111,
+			"height":    1.07,
+			"createdAt": AnyTime(),
+			"tags":      JSONArray{"hobbit", AnyString()},
+			"address":   JSON{"street": "Bagshot Row", "town": Var("town")},
+		})
+		assert.Equal(t, "Expect Json Equal", exp.Name())
+		assert.NotNil(t, exp.Frame())
+		assert.False(t, exp.IsRequired())
+		ctx := newTestContext(map[Var]any{"town": "Hobbiton"})
+		ctx.currBody = body
+		unmet, err := exp.Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+		assert.Equal(t, "a9b2e9a4-6e0a-4c2b-9d43-2c1f0a6b8e71", ctx.vars["id"])
+	})
+	t.Run("unmet", func(t *testing.T) {
+		exp := ExpectJsonEqual(Body, JSON{
+			"id":        AnyUUID(),
+			"name":      "Frodo",
+			"age":       "111",
+			"createdAt": AnyTime(),
+			"tags":      JSONArray{"hobbit"},
+			"address":   JSON{"street": "Bagshot Row", "town": "Hobbiton", "postcode": "SH1"},
+			"extra":     true,
+		})
+		ctx := newTestContext(nil)
+		ctx.currBody = body
+		unmet, err := exp.Met(ctx)
+		require.NoError(t, err)
+		require.Error(t, unmet)
+		assert.Equal(t, "expected json equal", unmet.Error())
+		uerr, ok := unmet.(UnmetError)
+		require.True(t, ok)
+		diff, ok := uerr.Actual().Resolved.(JsonDiff)
+		require.True(t, ok)
+		paths := make([]string, len(diff))
+		for i, d := range diff {
+			paths[i] = d.Path
+		}
+		assert.Equal(t, []string{"$.address.postcode", "$.age", "$.extra", "$.name", "$.tags[1]", "$.height"}, paths)
+		out := uerr.TestFormat()
+		assert.Contains(t, out, "6 differences")
+		assert.Contains(t, out, `$.address.postcode: missing, expected "SH1"`)
+		assert.Contains(t, out, `$.age: expected "111", actual 111`)
+		assert.Contains(t, out, `$.name: expected "Frodo", actual "Bilbo"`)
+		assert.Contains(t, out, `$.tags[1]: unexpected "burglar"`)
+		assert.Contains(t, out, `$.height: unexpected 1.07`)
+	})
+	t.Run("ignore paths", func(t *testing.T) {
+		exp := ExpectJsonEqual(Body, JSON{
+			"name":    "Bilbo",
+			"age":     111,
+			"tags":    JSONArray{"hobbit", "thief"},
+			"address": JSON{"street": "Bagshot Row"},
+		}, JsonCompareOptions{IgnorePaths: []string{"$.id", "height", "$['createdAt']", "$.tags[*]", "$.*.town"}})
+		ctx := newTestContext(nil)
+		ctx.currBody = body
+		unmet, err := exp.Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+	})
+	t.Run("unordered arrays", func(t *testing.T) {
+		expected := JSONArray{
+			JSON{"id": 2, "name": CaptureVar("second")},
+			JSON{"id": 1, "name": "Bilbo"},
+		}
+		actual := []any{
+			map[string]any{"id": 1, "name": "Bilbo"},
+			map[string]any{"id": 2, "name": "Frodo"},
+		}
+		ctx := newTestContext(nil)
+		unmet, err := ExpectJsonEqual(actual, expected).Met(ctx)
+		require.NoError(t, err)
+		require.Error(t, unmet)
+		unmet, err = ExpectJsonEqual(actual, expected, JsonCompareOptions{UnorderedArrays: true}).Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+		assert.Equal(t, "Frodo", ctx.vars["second"])
+
+		unmet, err = ExpectJsonEqual(append(actual, map[string]any{"id": 3}), expected, JsonCompareOptions{UnorderedArrays: true}).Met(ctx)
+		require.NoError(t, err)
+		require.Error(t, unmet)
+		diff := unmet.(UnmetError).Actual().Resolved.(JsonDiff)
+		require.Len(t, diff, 1)
+		assert.Equal(t, JsonDifference{Path: "$[2]", Reason: "unexpected", Actual: map[string]any{"id": 3}}, diff[0])
+	})
+	t.Run("numeric tolerance", func(t *testing.T) {
+		ctx := newTestContext(nil)
+		unmet, err := ExpectJsonEqual(JSON{"v": 1.0001}, JSON{"v": 1}).Met(ctx)
+		require.NoError(t, err)
+		require.Error(t, unmet)
+		unmet, err = ExpectJsonEqual(JSON{"v": 1.0001}, JSON{"v": 1}, JsonCompareOptions{NumericTolerance: 0.001}).Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+	})
+	t.Run("json data and structs", func(t *testing.T) {
+		type person struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		}
+		ctx := newTestContext(nil)
+		unmet, err := ExpectJsonEqual([]byte(`{"name":"Bilbo","age":111}`), person{Name: "Bilbo", Age: 111}).Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+		_, err = ExpectJsonEqual([]byte(`{`), JSON{}).Met(ctx)
+		require.Error(t, err)
+	})
+	t.Run("bad ignore path", func(t *testing.T) {
+		_, err := ExpectJsonEqual(JSON{}, JSON{}, JsonCompareOptions{IgnorePaths: []string{"$..foo"}}).Met(newTestContext(nil))
+		require.Error(t, err)
+	})
+	t.Run("unresolved", func(t *testing.T) {
+		_, err := ExpectJsonEqual(Var("missing"), JSON{}).Met(newTestContext(nil))
+		require.Error(t, err)
+	})
+}
+
+func TestExpectJsonSubset(t *testing.T) {
+	actual := map[string]any{
+		"name":  "Bilbo",
+		"age":   int64(111),
+		"tags":  []any{"hobbit", "burglar", "ring-bearer"},
+		"extra": map[string]any{"foo": "bar"},
+	}
+	exp := ExpectJsonSubset(actual, JSON{
+		"name": "Bilbo",
+		"tags": JSONArray{"hobbit", "burglar"},
+	})
+	assert.Equal(t, "Expect Json Subset", exp.Name())
+	unmet, err := exp.Met(newTestContext(nil))
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectJsonSubset(actual, JSON{"tags": JSONArray{"ring-bearer"}}, JsonCompareOptions{UnorderedArrays: true}).Met(newTestContext(nil))
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	unmet, err = ExpectJsonSubset(actual, JSON{"extra": JSONArray{}, "tags": JSONArray{"elf"}}, JsonCompareOptions{UnorderedArrays: true}).Met(newTestContext(nil))
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected json subset", unmet.Error())
+	diff := unmet.(UnmetError).Actual().Resolved.(JsonDiff)
+	require.Len(t, diff, 2)
+	assert.Equal(t, `$.extra: expected [], actual {"foo":"bar"}`, diff[0].String())
+	assert.Equal(t, `$.tags[0]: missing, expected "elf"`, diff[1].String())
+	assert.Equal(t, "2 differences\n\t          \t"+diff[0].String()+"\n\t          \t"+diff[1].String(), diff.stringify())
+	assert.Equal(t, "1 difference\n\t          \t"+diff[0].String(), diff[:1].stringify())
+}
+
+func TestJsonMatchers(t *testing.T) {
+	testCases := []struct {
+		matcher JsonMatcher
+		value   any
+		expect  bool
+	}{
+		{AnyValue(), nil, true},
+		{AnyString(), "", true},
+		{AnyString(), 1, false},
+		{AnyNumber(), int64(1), true},
+		{AnyNumber(), 1.5, true},
+		{AnyNumber(), "1", false},
+		{AnyNumber(), nil, false},
+		{AnyUUID(), "A9B2E9A4-6E0A-4C2B-9D43-2C1F0A6B8E71", true},
+		{AnyUUID(), "not-a-uuid", false},
+		{AnyUUID(), 1, false},
+		{AnyTime(), "2025-01-02T03:04:05.123Z", true},
+		{AnyTime(), "2025-01-02", false},
+		{AnyTime("2006-01-02"), "2025-01-02", true},
+		{AnyTime(), 1, false},
+		{CaptureVar("v"), nil, true},
+		{CaptureVar("v", AnyString()), 1, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.matcher.String(), func(t *testing.T) {
+			assert.Equal(t, tc.expect, tc.matcher.Match(tc.value))
+		})
+	}
+	assert.Equal(t, "CaptureVar(v, AnyString())", CaptureVar("v", AnyString()).String())
+	assert.Equal(t, "CaptureVar(v)", CaptureVar("v").String())
+}
+
+func TestParseJsonPath(t *testing.T) {
+	testCases := []struct {
+		path   string
+		expect string
+		err    bool
+	}{
+		{path: "$", expect: "$"},
+		{path: "", expect: "$"},
+		{path: "$.foo.bar", expect: "$.foo.bar"},
+		{path: "foo.bar", expect: "$.foo.bar"},
+		{path: ".foo[0][*].*", expect: "$.foo[0][*].*"},
+		{path: `$['a.b']['it\'s']`, expect: `$['a.b']['it\'s']`},
+		{path: "$['a']", expect: "$.a"},
+		{path: "$['a'", err: true},
+		{path: "$[", err: true},
+		{path: "$[-1]", err: true},
+		{path: "$[x]", err: true},
+		{path: "$..foo", err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			p, err := parseJsonPath(tc.path)
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expect, p.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,954 @@
+package marrow
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type JsonQueryValue struct {
+	Value any
+	Query string
+}
+
+// JsonQuery resolves to the result of an RFC 9535 JSONPath query against the supplied value
+//
+// the query supports child and descendant segments, name/wildcard/index/slice selectors and filter selectors
+// (including the functions length, count, match, search and value)
+//
+// if the query is a singular query (i.e. only uses name and index selectors - e.g. "$.pets[0].name") it resolves
+// to the single value (and errors if the value does not exist) - otherwise it resolves to a []any of the selected values
+//
+// example:
+//
+//	JsonQuery(Body, `$.pets[?@.name == "Felix"].id`)
+//
+// resolves to the ids of all pets named "Felix"
+func JsonQuery(v any, query string) JsonQueryValue {
+	return JsonQueryValue{
+		Value: v,
+		Query: query,
+	}
+}
+
+func (v JsonQueryValue) String() string {
+	return fmt.Sprintf("JsonQuery(%s, %q)", stringifyValue(v.Value), v.Query)
+}
+
+func (v JsonQueryValue) ResolveValue(ctx Context) (av any, err error) {
+	var q *jqQuery
+	if q, err = parseJsonQuery(v.Query); err == nil {
+		if av, err = ResolveValue(v.Value, ctx); err == nil {
+			if av, err = normalizeJsonValue(av); err == nil {
+				nodes := q.eval(av, av)
+				if q.singular() {
+					if len(nodes) == 1 {
+						av = nodes[0]
+					} else {
+						av = nil
+						err = fmt.Errorf("json query %q does not exist", v.Query)
+					}
+				} else {
+					av = nodes
+				}
+			}
+		}
+	}
+	return av, err
+}
+
+// jqNothing represents the absence of a value (as distinct from null) in filter expressions
+type jqNothing struct{}
+
+type jqQuery struct {
+	relative bool
+	segments []*jqSegment
+}
+
+type jqSegment struct {
+	descendant bool
+	selectors  []jqSelector
+}
+
+type jqSelector interface {
+	selectFrom(root any, v any, nodes []any) []any
+}
+
+func (q *jqQuery) singular() bool {
+	for _, seg := range q.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		switch seg.selectors[0].(type) {
+		case jqName, jqIndex:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (q *jqQuery) eval(root any, current any) []any {
+	nodes := []any{root}
+	if q.relative {
+		nodes = []any{current}
+	}
+	for _, seg := range q.segments {
+		next := make([]any, 0)
+		for _, n := range nodes {
+			if seg.descendant {
+				jqDescend(n, func(dv any) {
+					for _, sel := range seg.selectors {
+						next = sel.selectFrom(root, dv, next)
+					}
+				})
+			} else {
+				for _, sel := range seg.selectors {
+					next = sel.selectFrom(root, n, next)
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// jqDescend visits the value and all its descendants (in document order)
+func jqDescend(v any, visit func(v any)) {
+	visit(v)
+	jqChildren(v, func(cv any) {
+		jqDescend(cv, visit)
+	})
+}
+
+func jqChildren(v any, visit func(v any)) {
+	switch vt := v.(type) {
+	case map[string]any:
+		for _, k := range sortedKeys(vt) {
+			visit(vt[k])
+		}
+	case []any:
+		for _, sv := range vt {
+			visit(sv)
+		}
+	}
+}
+
+type jqName string
+
+func (s jqName) selectFrom(_ any, v any, nodes []any) []any {
+	if m, ok := v.(map[string]any); ok {
+		if mv, ok := m[string(s)]; ok {
+			nodes = append(nodes, mv)
+		}
+	}
+	return nodes
+}
+
+type jqWildcard struct{}
+
+func (s jqWildcard) selectFrom(_ any, v any, nodes []any) []any {
+	jqChildren(v, func(cv any) {
+		nodes = append(nodes, cv)
+	})
+	return nodes
+}
+
+type jqIndex int
+
+func (s jqIndex) selectFrom(_ any, v any, nodes []any) []any {
+	if sl, ok := v.([]any); ok {
+		i := int(s)
+		if i < 0 {
+			i += len(sl)
+		}
+		if i >= 0 && i < len(sl) {
+			nodes = append(nodes, sl[i])
+		}
+	}
+	return nodes
+}
+
+type jqSlice struct {
+	start *int
+	end   *int
+	step  int
+}
+
+func (s jqSlice) selectFrom(_ any, v any, nodes []any) []any {
+	sl, ok := v.([]any)
+	if !ok || s.step == 0 {
+		return nodes
+	}
+	l := len(sl)
+	normalize := func(i int) int {
+		if i < 0 {
+			return l + i
+		}
+		return i
+	}
+	if s.step > 0 {
+		start, end := 0, l
+		if s.start != nil {
+			start = normalize(*s.start)
+		}
+		if s.end != nil {
+			end = normalize(*s.end)
+		}
+		lower, upper := min(max(start, 0), l), min(max(end, 0), l)
+		for i := lower; i < upper; i += s.step {
+			nodes = append(nodes, sl[i])
+		}
+	} else {
+		start, end := l-1, -l-1
+		if s.start != nil {
+			start = normalize(*s.start)
+		}
+		if s.end != nil {
+			end = normalize(*s.end)
+		}
+		upper, lower := min(max(start, -1), l-1), min(max(end, -1), l-1)
+		for i := upper; lower < i; i += s.step {
+			nodes = append(nodes, sl[i])
+		}
+	}
+	return nodes
+}
+
+type jqFilter struct {
+	expr jqLogical
+}
+
+func (s jqFilter) selectFrom(root any, v any, nodes []any) []any {
+	jqChildren(v, func(cv any) {
+		if s.expr.test(root, cv) {
+			nodes = append(nodes, cv)
+		}
+	})
+	return nodes
+}
+
+type jqLogical interface {
+	test(root any, current any) bool
+}
+
+type jqOr []jqLogical
+
+func (x jqOr) test(root any, current any) bool {
+	for _, l := range x {
+		if l.test(root, current) {
+			return true
+		}
+	}
+	return false
+}
+
+type jqAnd []jqLogical
+
+func (x jqAnd) test(root any, current any) bool {
+	for _, l := range x {
+		if !l.test(root, current) {
+			return false
+		}
+	}
+	return true
+}
+
+type jqNot struct {
+	expr jqLogical
+}
+
+func (x jqNot) test(root any, current any) bool {
+	return !x.expr.test(root, current)
+}
+
+type jqExistence struct {
+	query *jqQuery
+}
+
+func (x jqExistence) test(root any, current any) bool {
+	return len(x.query.eval(root, current)) > 0
+}
+
+type jqFunctionTest struct {
+	fn *jqFunction
+}
+
+func (x jqFunctionTest) test(root any, current any) bool {
+	switch rt := x.fn.eval(root, current).(type) {
+	case bool:
+		return rt
+	case jqNothing:
+		return false
+	}
+	return true
+}
+
+type jqComparison struct {
+	left  jqComparable
+	op    string
+	right jqComparable
+}
+
+func (x jqComparison) test(root any, current any) bool {
+	l, r := x.left.value(root, current), x.right.value(root, current)
+	switch x.op {
+	case "==":
+		return jqEqual(l, r)
+	case "!=":
+		return !jqEqual(l, r)
+	case "<":
+		return jqLess(l, r)
+	case "<=":
+		return jqLess(l, r) || jqEqual(l, r)
+	case ">":
+		return jqLess(r, l)
+	default: // ">="
+		return jqLess(r, l) || jqEqual(l, r)
+	}
+}
+
+type jqComparable interface {
+	value(root any, current any) any
+}
+
+type jqLiteral struct {
+	v any
+}
+
+func (c jqLiteral) value(any, any) any {
+	return c.v
+}
+
+type jqSingularQuery struct {
+	query *jqQuery
+}
+
+func (c jqSingularQuery) value(root any, current any) any {
+	if nodes := c.query.eval(root, current); len(nodes) == 1 {
+		return nodes[0]
+	}
+	return jqNothing{}
+}
+
+type jqFunction struct {
+	name string
+	args []any // each arg is a jqComparable, *jqQuery or jqLogical
+}
+
+func (c *jqFunction) value(root any, current any) any {
+	return c.eval(root, current)
+}
+
+func (c *jqFunction) eval(root any, current any) any {
+	switch c.name {
+	case "length":
+		switch vt := c.argValue(0, root, current).(type) {
+		case string:
+			return float64(utf8.RuneCountInString(vt))
+		case []any:
+			return float64(len(vt))
+		case map[string]any:
+			return float64(len(vt))
+		}
+		return jqNothing{}
+	case "count":
+		return float64(len(c.argNodes(0, root, current)))
+	case "value":
+		if nodes := c.argNodes(0, root, current); len(nodes) == 1 {
+			return nodes[0]
+		}
+		return jqNothing{}
+	default: // "match", "search"
+		s, ok1 := c.argValue(0, root, current).(string)
+		expr, ok2 := c.argValue(1, root, current).(string)
+		if ok1 && ok2 {
+			if c.name == "match" {
+				expr = "^(?:" + expr + ")$"
+			}
+			if rx, err := regexp.Compile(expr); err == nil {
+				return rx.MatchString(s)
+			}
+		}
+		return false
+	}
+}
+
+func (c *jqFunction) argValue(i int, root any, current any) any {
+	switch at := c.args[i].(type) {
+	case *jqQuery:
+		if nodes := at.eval(root, current); len(nodes) == 1 {
+			return nodes[0]
+		}
+		return jqNothing{}
+	case jqComparable:
+		return at.value(root, current)
+	case jqLogical:
+		return at.test(root, current)
+	}
+	return jqNothing{}
+}
+
+func (c *jqFunction) argNodes(i int, root any, current any) []any {
+	if q, ok := c.args[i].(*jqQuery); ok {
+		return q.eval(root, current)
+	}
+	v := c.argValue(i, root, current)
+	if _, ok := v.(jqNothing); ok {
+		return nil
+	}
+	return []any{v}
+}
+
+var jqFunctionArgs = map[string]int{
+	"length": 1,
+	"count":  1,
+	"value":  1,
+	"match":  2,
+	"search": 2,
+}
+
+func jqEqual(a any, b any) bool {
+	if an, ok := jsonNumber(a); ok {
+		bn, ok := jsonNumber(b)
+		return ok && an == bn
+	}
+	switch at := a.(type) {
+	case jqNothing:
+		_, ok := b.(jqNothing)
+		return ok
+	case nil:
+		return b == nil
+	case string:
+		bs, ok := b.(string)
+		return ok && at == bs
+	case bool:
+		bb, ok := b.(bool)
+		return ok && at == bb
+	case []any:
+		bs, ok := b.([]any)
+		if !ok || len(at) != len(bs) {
+			return false
+		}
+		for i := range at {
+			if !jqEqual(at[i], bs[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		bm, ok := b.(map[string]any)
+		if !ok || len(at) != len(bm) {
+			return false
+		}
+		for k, av := range at {
+			if bv, ok := bm[k]; !ok || !jqEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func jqLess(a any, b any) bool {
+	if an, ok := jsonNumber(a); ok {
+		bn, ok := jsonNumber(b)
+		return ok && an < bn
+	}
+	if as, ok := a.(string); ok {
+		bs, ok := b.(string)
+		return ok && as < bs
+	}
+	return false
+}
+
+// parseJsonQuery parses an RFC 9535 JSONPath query
+func parseJsonQuery(query string) (*jqQuery, error) {
+	p := &jqParser{s: query}
+	if !p.consume("$") {
+		return nil, p.error("query must start with '$'")
+	}
+	q, err := p.segments(false)
+	if err == nil && p.pos < len(p.s) {
+		err = p.error("unexpected character")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+type jqParser struct {
+	s   string
+	pos int
+}
+
+func (p *jqParser) error(msg string) error {
+	return fmt.Errorf("invalid json query %q at position %d: %s", p.s, p.pos, msg)
+}
+
+func (p *jqParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *jqParser) consume(s string) bool {
+	if strings.HasPrefix(p.s[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *jqParser) skipBlanks() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\n\r", p.s[p.pos]) != -1 {
+		p.pos++
+	}
+}
+
+// peekSegment returns whether there is a segment following (allowing for blank space before it)
+func (p *jqParser) peekSegment() bool {
+	save := p.pos
+	p.skipBlanks()
+	result := p.peek() == '.' || p.peek() == '['
+	p.pos = save
+	return result
+}
+
+func (p *jqParser) segments(relative bool) (*jqQuery, error) {
+	q := &jqQuery{relative: relative}
+	for p.peekSegment() {
+		p.skipBlanks()
+		seg := &jqSegment{}
+		if p.consume("..") {
+			seg.descendant = true
+			if p.peek() != '[' {
+				sel, err := p.shorthand()
+				if err != nil {
+					return nil, err
+				}
+				seg.selectors = []jqSelector{sel}
+				q.segments = append(q.segments, seg)
+				continue
+			}
+		} else if p.consume(".") {
+			sel, err := p.shorthand()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = []jqSelector{sel}
+			q.segments = append(q.segments, seg)
+			continue
+		}
+		p.pos++ // '['
+		for {
+			p.skipBlanks()
+			sel, err := p.selector()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = append(seg.selectors, sel)
+			p.skipBlanks()
+			if p.consume("]") {
+				break
+			} else if !p.consume(",") {
+				return nil, p.error("expected ',' or ']'")
+			}
+		}
+		q.segments = append(q.segments, seg)
+	}
+	return q, nil
+}
+
+func (p *jqParser) shorthand() (jqSelector, error) {
+	if p.consume("*") {
+		return jqWildcard{}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.s) {
+		r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r >= 0x80 || (p.pos > start && r >= '0' && r <= '9') {
+			p.pos += size
+		} else {
+			break
+		}
+	}
+	if p.pos == start {
+		return nil, p.error("expected member name")
+	}
+	return jqName(p.s[start:p.pos]), nil
+}
+
+func (p *jqParser) selector() (jqSelector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		s, err := p.stringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return jqName(s), nil
+	case c == '*':
+		p.pos++
+		return jqWildcard{}, nil
+	case c == '?':
+		p.pos++
+		p.skipBlanks()
+		expr, err := p.logicalOr()
+		if err != nil {
+			return nil, err
+		}
+		return jqFilter{expr: expr}, nil
+	case c == ':' || c == '-' || (c >= '0' && c <= '9'):
+		return p.indexOrSlice()
+	}
+	return nil, p.error("invalid selector")
+}
+
+func (p *jqParser) indexOrSlice() (jqSelector, error) {
+	var parts [3]*int
+	n := 0
+	for {
+		p.skipBlanks()
+		if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+			i, err := p.integer()
+			if err != nil {
+				return nil, err
+			}
+			parts[n] = &i
+		}
+		p.skipBlanks()
+		if n < 2 && p.consume(":") {
+			n++
+		} else {
+			break
+		}
+	}
+	if n == 0 {
+		if parts[0] == nil {
+			return nil, p.error("invalid index")
+		}
+		return jqIndex(*parts[0]), nil
+	}
+	result := jqSlice{start: parts[0], end: parts[1], step: 1}
+	if parts[2] != nil {
+		result.step = *parts[2]
+	}
+	return result, nil
+}
+
+func (p *jqParser) integer() (int, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	str := p.s[start:p.pos]
+	if p.pos == digits || (p.s[digits] == '0' && (p.pos-digits > 1 || digits > start)) {
+		return 0, p.error("invalid integer")
+	}
+	return strconv.Atoi(str)
+}
+
+func (p *jqParser) stringLiteral() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\\':
+			p.pos++
+			if p.pos >= len(p.s) {
+				return "", p.error("unterminated string")
+			}
+			esc := p.s[p.pos]
+			p.pos++
+			switch esc {
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '/', '\\', '\'', '"':
+				if (esc == '\'' || esc == '"') && esc != quote {
+					return "", p.error("invalid escape")
+				}
+				b.WriteByte(esc)
+			case 'u':
+				r, err := p.unicodeEscape()
+				if err != nil {
+					return "", err
+				}
+				b.WriteRune(r)
+			default:
+				return "", p.error("invalid escape")
+			}
+		case c < 0x20:
+			return "", p.error("invalid character in string")
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.error("unterminated string")
+}
+
+func (p *jqParser) unicodeEscape() (rune, error) {
+	hex4 := func() (rune, error) {
+		if p.pos+4 > len(p.s) {
+			return 0, p.error("invalid unicode escape")
+		}
+		n, err := strconv.ParseUint(p.s[p.pos:p.pos+4], 16, 32)
+		if err != nil {
+			return 0, p.error("invalid unicode escape")
+		}
+		p.pos += 4
+		return rune(n), nil
+	}
+	r, err := hex4()
+	if err == nil && utf16.IsSurrogate(r) {
+		if !p.consume(`\u`) {
+			return 0, p.error("invalid unicode surrogate")
+		}
+		var r2 rune
+		if r2, err = hex4(); err == nil {
+			if r = utf16.DecodeRune(r, r2); r == utf8.RuneError {
+				err = p.error("invalid unicode surrogate")
+			}
+		}
+	}
+	return r, err
+}
+
+func (p *jqParser) logicalOr() (jqLogical, error) {
+	var result jqOr
+	for {
+		l, err := p.logicalAnd()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, l)
+		p.skipBlanks()
+		if !p.consume("||") {
+			break
+		}
+		p.skipBlanks()
+	}
+	if len(result) == 1 {
+		return result[0], nil
+	}
+	return result, nil
+}
+
+func (p *jqParser) logicalAnd() (jqLogical, error) {
+	var result jqAnd
+	for {
+		l, err := p.basicExpr()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, l)
+		p.skipBlanks()
+		if !p.consume("&&") {
+			break
+		}
+		p.skipBlanks()
+	}
+	if len(result) == 1 {
+		return result[0], nil
+	}
+	return result, nil
+}
+
+func (p *jqParser) basicExpr() (jqLogical, error) {
+	if p.peek() == '!' && !strings.HasPrefix(p.s[p.pos:], "!=") {
+		p.pos++
+		p.skipBlanks()
+		if p.consume("(") {
+			expr, err := p.parenExpr()
+			if err != nil {
+				return nil, err
+			}
+			return jqNot{expr: expr}, nil
+		}
+		operand, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		expr, err := p.testExpr(operand)
+		if err != nil {
+			return nil, err
+		}
+		return jqNot{expr: expr}, nil
+	}
+	if p.consume("(") {
+		return p.parenExpr()
+	}
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlanks()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			p.skipBlanks()
+			var right any
+			if right, err = p.operand(); err != nil {
+				return nil, err
+			}
+			var lc, rc jqComparable
+			if lc, err = p.comparable(left); err == nil {
+				if rc, err = p.comparable(right); err == nil {
+					return jqComparison{left: lc, op: op, right: rc}, nil
+				}
+			}
+			return nil, err
+		}
+	}
+	return p.testExpr(left)
+}
+
+func (p *jqParser) parenExpr() (jqLogical, error) {
+	p.skipBlanks()
+	expr, err := p.logicalOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlanks()
+	if !p.consume(")") {
+		return nil, p.error("expected ')'")
+	}
+	return expr, nil
+}
+
+// testExpr converts a parsed operand to an existence test (for queries) or function test
+func (p *jqParser) testExpr(operand any) (jqLogical, error) {
+	switch ot := operand.(type) {
+	case *jqQuery:
+		return jqExistence{query: ot}, nil
+	case *jqFunction:
+		if ot.name == "length" || ot.name == "count" || ot.name == "value" {
+			return nil, p.error("function " + ot.name + "() result must be compared")
+		}
+		return jqFunctionTest{fn: ot}, nil
+	}
+	return nil, p.error("literal must be compared")
+}
+
+// comparable converts a parsed operand to a comparable - queries must be singular
+func (p *jqParser) comparable(operand any) (jqComparable, error) {
+	switch ot := operand.(type) {
+	case *jqQuery:
+		if !ot.singular() {
+			return nil, p.error("non-singular query in comparison")
+		}
+		return jqSingularQuery{query: ot}, nil
+	case *jqFunction:
+		if ot.name == "match" || ot.name == "search" {
+			return nil, p.error("function " + ot.name + "() result cannot be compared")
+		}
+		return ot, nil
+	}
+	return operand.(jqComparable), nil
+}
+
+// operand parses a query (relative "@" or absolute "$"), function call or literal
+func (p *jqParser) operand() (any, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		return p.segments(c == '@')
+	case c == '\'' || c == '"':
+		s, err := p.stringLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return jqLiteral{v: s}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		for p.pos < len(p.s) && ((p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z') || p.s[p.pos] == '_' || (p.s[p.pos] >= '0' && p.s[p.pos] <= '9')) {
+			p.pos++
+		}
+		name := p.s[start:p.pos]
+		if p.consume("(") {
+			return p.function(name)
+		}
+		switch name {
+		case "true":
+			return jqLiteral{v: true}, nil
+		case "false":
+			return jqLiteral{v: false}, nil
+		case "null":
+			return jqLiteral{v: nil}, nil
+		}
+		p.pos = start
+	}
+	return nil, p.error("invalid filter expression")
+}
+
+var jqNumberRegex = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?`)
+
+func (p *jqParser) number() (any, error) {
+	m := jqNumberRegex.FindString(p.s[p.pos:])
+	if m == "" {
+		return nil, p.error("invalid number")
+	}
+	p.pos += len(m)
+	f, err := strconv.ParseFloat(m, 64)
+	if err != nil {
+		return nil, p.error("invalid number")
+	}
+	return jqLiteral{v: f}, nil
+}
+
+func (p *jqParser) function(name string) (any, error) {
+	argCount, ok := jqFunctionArgs[name]
+	if !ok {
+		return nil, p.error("unknown function " + name + "()")
+	}
+	fn := &jqFunction{name: name}
+	p.skipBlanks()
+	for !p.consume(")") {
+		if len(fn.args) > 0 {
+			if !p.consume(",") {
+				return nil, p.error("expected ',' or ')'")
+			}
+			p.skipBlanks()
+		}
+		arg, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if af, ok := arg.(*jqFunction); ok && (af.name == "match" || af.name == "search") {
+			arg = jqFunctionTest{fn: af}
+		}
+		fn.args = append(fn.args, arg)
+		p.skipBlanks()
+	}
+	if len(fn.args) != argCount {
+		return nil, p.error(fmt.Sprintf("function %s() requires %d argument(s)", name, argCount))
+	}
+	if name == "count" || name == "value" {
+		if _, ok := fn.args[0].(*jqQuery); !ok {
+			return nil, p.error("function " + name + "() argument must be a query")
+		}
+	}
+	return fn, nil
+}
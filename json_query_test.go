@@ -0,0 +1,224 @@
+package marrow
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// example from RFC 9535 section 1.5...
+const jsonQueryStore = `{ "store": {
+    "book": [
+      { "category": "reference",
+        "author": "Nigel Rees",
+        "title": "Sayings of the Century",
+        "price": 8.95
+      },
+      { "category": "fiction",
+        "author": "Evelyn Waugh",
+        "title": "Sword of Honour",
+        "price": 12.99
+      },
+      { "category": "fiction",
+        "author": "Herman Melville",
+        "title": "Moby Dick",
+        "isbn": "0-553-21311-3",
+        "price": 8.99
+      },
+      { "category": "fiction",
+        "author": "J. R. R. Tolkien",
+        "title": "The Lord of the Rings",
+        "isbn": "0-395-19395-8",
+        "price": 22.99
+      }
+    ],
+    "bicycle": {
+      "color": "red",
+      "price": 399
+    }
+  }
+}`
+
+func TestJsonQuery(t *testing.T) {
+	var store any
+	require.NoError(t, json.Unmarshal([]byte(jsonQueryStore), &store))
+	testCases := []struct {
+		query  string
+		expect any
+	}{
+		{`$.store.book[*].author`, []any{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}},
+		{`$..author`, []any{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}},
+		{`$.store..price`, []any{399.0, 8.95, 12.99, 8.99, 22.99}},
+		{`$..book[2].author`, []any{"Herman Melville"}},
+		{`$..book[2].publisher`, []any{}},
+		{`$..book[-1].title`, []any{"The Lord of the Rings"}},
+		{`$..book[0,1].title`, []any{"Sayings of the Century", "Sword of Honour"}},
+		{`$..book[:2].title`, []any{"Sayings of the Century", "Sword of Honour"}},
+		{`$..book[::-2].title`, []any{"The Lord of the Rings", "Sword of Honour"}},
+		{`$..book[1:3:1].title`, []any{"Sword of Honour", "Moby Dick"}},
+		{`$..book[?@.isbn].title`, []any{"Moby Dick", "The Lord of the Rings"}},
+		{`$..book[?!@.isbn].title`, []any{"Sayings of the Century", "Sword of Honour"}},
+		{`$..book[?@.price<10].title`, []any{"Sayings of the Century", "Moby Dick"}},
+		{`$..book[? @.price >= 12.99 && @.category == 'fiction'].title`, []any{"Sword of Honour", "The Lord of the Rings"}},
+		{`$..book[?@.price > 20 || @.author == "Nigel Rees"].title`, []any{"Sayings of the Century", "The Lord of the Rings"}},
+		{`$..book[?!(@.price > 9)].title`, []any{"Sayings of the Century", "Moby Dick"}},
+		{`$..book[?@.price <= $.store.bicycle.price && @.price != 8.95].price`, []any{12.99, 8.99, 22.99}},
+		{`$..book[?match(@.author, "J.*")].title`, []any{"The Lord of the Rings"}},
+		{`$..book[?search(@.title, "o[rn]")].title`, []any{"Sword of Honour", "The Lord of the Rings"}},
+		{`$..book[?length(@.title) == 9].title`, []any{"Moby Dick"}},
+		{`$.store[?count(@.*) == 2].color`, []any{"red"}},
+		{`$.store[?value(@..color) == "red"].price`, []any{399.0}},
+		{`$.store.book[?@.category == 'fiction' && @.isbn][ 'title' ]`, []any{"Moby Dick", "The Lord of the Rings"}},
+		{`$.store.bicycle.*`, []any{"red", 399.0}},
+		{`$.store.book[0]["title", 'author']`, []any{"Sayings of the Century", "Nigel Rees"}},
+		{`$.store.book[0].title`, "Sayings of the Century"},
+		{`$['store']["bicycle"].color`, "red"},
+		{`$.store.book[-4].price`, 8.95},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			ctx := newTestContext(map[Var]any{"store": store})
+			av, err := JsonQuery(Var("store"), tc.query).ResolveValue(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, av)
+		})
+	}
+}
+
+func TestJsonQuery_Filters(t *testing.T) {
+	data := JSONArray{
+		JSON{"name": "Felix", "tags": JSONArray{"cat"}, "age": 3, "owner": nil},
+		JSON{"name": "Rex", "tags": JSONArray{"dog", "good"}, "age": 5},
+		JSON{"name": "Tweety", "tags": JSONArray{}, "age": 1.5, "owner": JSON{"name": "Granny"}},
+	}
+	testCases := []struct {
+		query  string
+		expect any
+	}{
+		{`$[?@.name == "Felix"].age`, []any{3}},
+		{`$[?@.owner == null].name`, []any{"Felix"}},
+		{`$[?@.owner].name`, []any{"Felix", "Tweety"}},
+		{`$[?@.owner.name == 'Granny'].name`, []any{"Tweety"}},
+		{`$[?@.tags[0] == "dog"].name`, []any{"Rex"}},
+		{`$[?length(@.tags) > 0].name`, []any{"Felix", "Rex"}},
+		{`$[?@.age > 2 && (@.name == "Rex" || @.name == "Tweety")].name`, []any{"Rex"}},
+		{`$[?@.age < 2].name`, []any{"Tweety"}},
+		{`$[?@.name > "Q"].name`, []any{"Rex", "Tweety"}},
+		{`$[?@.name < 1].name`, []any{}},
+		{`$[?@.missing == @.other].name`, []any{"Felix", "Rex", "Tweety"}},
+		{`$[?@.name == 1e0].name`, []any{}},
+		{`$[?@.age == 0.5e1].name`, []any{"Rex"}},
+		{`$[?@.name == "Felix"].name`, []any{"Felix"}},
+		{`$[?@.owner == @.owner].name`, []any{"Felix", "Rex", "Tweety"}},
+		{`$[?@.tags == $[1].tags].name`, []any{"Rex"}},
+		{`$[?@ == $[2]].name`, []any{"Tweety"}},
+		{`$[?true == true].name`, []any{"Felix", "Rex", "Tweety"}},
+		{`$[?false != false].name`, []any{}},
+		{`$[?@.age >= 3].name`, []any{"Felix", "Rex"}},
+		{`$[?@.age <= 3].name`, []any{"Felix", "Tweety"}},
+		{`$[?match(@.name, "[Ff]el.*")].name`, []any{"Felix"}},
+		{`$[?match(@.age, "3")].name`, []any{}},
+		{`$[?match(@.name, "(")].name`, []any{}},
+		{`$[?search(@.name, $[0].name)].name`, []any{"Felix"}},
+		{`$[?length("abc") == 3].name`, []any{"Felix", "Rex", "Tweety"}},
+		{`$[?length(@.age) == 1].name`, []any{}},
+		{`$[?length(@.owner) == 1].name`, []any{"Tweety"}},
+		{`$[?count(@.tags[*]) == 2].name`, []any{"Rex"}},
+		{`$[?value(@.tags[*]) == "cat"].name`, []any{"Felix"}},
+		{`$[?value(@.tags[*]) == @.nothing].name`, []any{"Rex", "Tweety"}},
+		{`$[?length(value(@.tags[*])) == 3].name`, []any{"Felix"}},
+		{`$..[?@ == "good"]`, []any{"good"}},
+		{`$[1:].name`, []any{"Rex", "Tweety"}},
+		{`$[-2:-1].name`, []any{"Rex"}},
+		{`$[::0].name`, []any{}},
+		{`$[5:-10:-1].name`, []any{"Tweety", "Rex", "Felix"}},
+		{`$[2:0:-1].name`, []any{"Tweety", "Rex"}},
+		{`$.name`, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			av, err := JsonQuery(data, tc.query).ResolveValue(newTestContext(nil))
+			if tc.expect == nil {
+				require.Error(t, err)
+				assert.Equal(t, `json query "$.name" does not exist`, err.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expect, av)
+			}
+		})
+	}
+}
+
+func TestJsonQuery_Errors(t *testing.T) {
+	testCases := []string{
+		``,
+		`store`,
+		`$.`,
+		`$.1a`,
+		`$[`,
+		`$[]`,
+		`$[1`,
+		`$[1 2]`,
+		`$[01]`,
+		`$[-0]`,
+		`$[1:2:3:4]`,
+		`$['abc`,
+		`$['abc\`,
+		`$['\x']`,
+		`$['\"']`,
+		`$["\u12"]`,
+		`$["\uD800"]`,
+		`$["\uD800A"]`,
+		"$['\x01']",
+		`$[?]`,
+		`$[?@.a == ]`,
+		`$[?(@.a]`,
+		`$[?1]`,
+		`$[?!@.a == 1]`,
+		`$[?@..a == 1]`,
+		`$[?@.* == 1]`,
+		`$[?foo(@)]`,
+		`$[?length(@)]`,
+		`$[?length(@, @)]`,
+		`$[?length(@ @)]`,
+		`$[?count(1) == 1]`,
+		`$[?match(@.a, "a") == true]`,
+		`$[?@.a == -]`,
+		`$[?@.a == nul]`,
+		`$[?@.a == bad]`,
+		`$[?!(@.a]`,
+		`$[?!length(@.a)]`,
+		`$[?!1]`,
+		`$[?(@.a == )]`,
+		`$[?@.a == 1 && ]`,
+		`$[?@.a == 1 || ]`,
+		`$[?length(@.a == 1)]`,
+		`$.a b`,
+		`$[?@.tags == ["dog"]]`,
+	}
+	for _, tc := range testCases {
+		t.Run(tc, func(t *testing.T) {
+			_, err := parseJsonQuery(tc)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestJsonQueryValue(t *testing.T) {
+	v := JsonQuery(Body, "$.foo")
+	assert.Equal(t, `JsonQuery(Body, "$.foo")`, v.String())
+	_, err := JsonQuery(Var("missing"), "$.foo").ResolveValue(newTestContext(nil))
+	require.Error(t, err)
+	_, err = JsonQuery(JSON{}, "foo").ResolveValue(newTestContext(nil))
+	require.Error(t, err)
+	type foo struct {
+		Bar string `json:"bar"`
+	}
+	av, err := JsonQuery(foo{Bar: "baz"}, "$.bar").ResolveValue(newTestContext(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "baz", av)
+	av, err = JsonQuery(JSON{"a": "\U0001F600"}, `$[?@ == "😀"]`).ResolveValue(newTestContext(nil))
+	require.NoError(t, err)
+	assert.Equal(t, []any{"\U0001F600"}, av)
+}
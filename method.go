@@ -2,6 +2,8 @@ package marrow
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"encoding/json"
 	"fmt"
 	"github.com/go-andiamo/marrow/common"
@@ -12,6 +14,7 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"time"
 )
 
 // Method_ is the interface implemented by an instantiated Method (see Method(), Get(), Post(), etc.)
@@ -29,6 +32,8 @@ type Method_ interface {
 	// AuthHeader sets the "Authorization" header for the method call
 	AuthHeader(scheme AuthScheme, value any) Method_
 	// RequestBody sets the http request body for the method call
+	//
+	// besides json values, the body can be Multipart, UrlEncoded, XMLBody, YAMLBody, NDJSONBody, ProtobufBody, FileBody or GraphQL
 	RequestBody(value any) Method_
 	// UseCookie sets a named cookie to use on the method call
 	//
@@ -46,6 +51,24 @@ type Method_ interface {
 	//  * multiple authorize functions can be added.
 	//  * authorize functions are called after pre-captures (i.e. Before's) and after the request has been built
 	Authorize(func(ctx Context) error) Method_
+	// AuthorizeOAuth2 authorizes the http request with an OAuth2 access token (as a Bearer "Authorization" header)
+	//
+	// if the response is 401 Unauthorized, the cached access token is discarded and the request is retried (once) with a newly acquired access token
+	//
+	// see OAuth2Token
+	AuthorizeOAuth2(token OAuth2TokenValue) Method_
+	// AuthMatrix adds authorization cases - each case pairs an "Authorization" header value (or none) with an expected
+	// response status code
+	//
+	// each case is run as a sub-run (reported individually to coverage) making the same request, except for the
+	// "Authorization" header, and asserting only the expected status
+	//
+	// Notes:
+	//  * auth cases are run after pre-captures (i.e. Before's) and before the method's own request
+	//  * authorize functions (see Authorize) are not used for auth cases
+	//
+	// see AuthCase
+	AuthMatrix(cases ...AuthCase) Method_
 
 	MethodExpectations
 	MethodCaptures
@@ -82,13 +105,25 @@ type Method_ interface {
 	//
 	// i.e. treat all `Assert...()` as `Require...()`
 	FailFast() Method_
+	// Timeout sets the timeout for the method call request
+	//
+	// this overrides any default timeout (see with.HttpClientOptions)
+	Timeout(d time.Duration) Method_
 
+	// RequestCompression sets the request body to be compressed using the content encoding ("gzip" or "deflate")
+	//
+	// the request "Content-Encoding" header is set accordingly
+	//
+	// Note: request compression is not applied to gRPC methods (see GrpcMethod)
+	RequestCompression(contentEncoding string) Method_
 	// RequestMarshal provides an override function to build (marshal) the request body
 	//
 	// only one func is used, so if this is called multiple times - last one wins
 	RequestMarshal(fn func(ctx Context, body any) ([]byte, error)) Method_
 	// ResponseUnmarshal provides an override function to unmarshal the response body
 	//
+	// by default, the response body is decoded using the suite codec registered for the response "Content-Type" (see with.Codec)
+	//
 	// only one func is used, so if this is called multiple times - last one wins
 	ResponseUnmarshal(fn func(response *http.Response) (any, error)) Method_
 	Runnable
@@ -201,6 +236,7 @@ type method struct {
 	skips             []Expectation
 	preCaptures       []Runnable
 	authFns           []Runnable
+	authMatrix        []*authCase
 	postOps           []postOp
 	postCaptures      []Runnable
 	expectations      []Expectation
@@ -208,6 +244,10 @@ type method struct {
 	useCookies        map[string]struct{}
 	requestMarshal    func(ctx Context, body any) ([]byte, error)
 	responseUnmarshal func(response *http.Response) (any, error)
+	compression       string
+	grpc              string
+	timeout           time.Duration
+	authCase          bool
 }
 
 func (m *method) addPostCapture(c Runnable) {
@@ -224,9 +264,15 @@ func (m *method) addPostExpectation(exp Expectation) {
 		index:         len(m.expectations),
 	})
 	m.expectations = append(m.expectations, exp)
+	if be, ok := exp.(beforeExpectation); ok {
+		m.preCaptures = append(m.preCaptures, &expectationBefore{exp: be})
+	}
 }
 
 func (m *method) MethodName() string {
+	if m.grpc != "" {
+		return "GRPC " + m.grpc
+	}
 	return string(m.method)
 }
 
@@ -243,6 +289,11 @@ func (m *method) FailFast() Method_ {
 	return m
 }
 
+func (m *method) Timeout(d time.Duration) Method_ {
+	m.timeout = d
+	return m
+}
+
 //go:noinline
 func (m *method) Authorize(fn func(ctx Context) error) Method_ {
 	if fn != nil {
@@ -255,6 +306,29 @@ func (m *method) Authorize(fn func(ctx Context) error) Method_ {
 	return m
 }
 
+//go:noinline
+func (m *method) AuthorizeOAuth2(token OAuth2TokenValue) Method_ {
+	if token != nil {
+		m.authFns = append(m.authFns, &oauth2Authorize{
+			token: token,
+			frame: framing.NewFrame(0),
+		})
+	}
+	return m
+}
+
+//go:noinline
+func (m *method) AuthMatrix(cases ...AuthCase) Method_ {
+	frame := framing.NewFrame(0)
+	for _, ac := range cases {
+		m.authMatrix = append(m.authMatrix, &authCase{
+			AuthCase: ac,
+			frame:    frame,
+		})
+	}
+	return m
+}
+
 func (m *method) QueryParam(name string, values ...any) Method_ {
 	m.queryParams[name] = append(m.queryParams[name], values...)
 	return m
@@ -306,6 +380,11 @@ func (m *method) StoreCookie(name string) Method_ {
 	return m
 }
 
+func (m *method) RequestCompression(contentEncoding string) Method_ {
+	m.compression = contentEncoding
+	return m
+}
+
 func (m *method) RequestMarshal(fn func(ctx Context, body any) ([]byte, error)) Method_ {
 	m.requestMarshal = fn
 	return m
@@ -385,11 +464,12 @@ func (m *method) ForEach(when When, value any, iterVar any, ops ...Runnable) Met
 func (m *method) Run(ctx Context) error {
 	if !m.isSkipped(ctx) {
 		ctx.setCurrentMethod(m)
-		if m.preRun(ctx) {
+		if m.preRun(ctx) && m.runAuthMatrix(ctx) {
 			if request, ok := m.buildRequest(ctx); ok {
+				ctx.setCurrentRequest(request)
+				m.reportGraphQL(ctx)
 				if m.preRequestRun(ctx) {
-					ctx.setCurrentRequest(request)
-					if response, ok := ctx.doRequest(); ok {
+					if response, ok := m.doRequest(ctx); ok {
 						if m.unmarshalResponseBody(ctx, response) {
 							m.postRun(ctx)
 						}
@@ -436,6 +516,47 @@ func (m *method) preRequestRun(ctx Context) bool {
 	return true
 }
 
+// unauthorizedRetrier is implemented by authorize functions that support retrying a request that received a 401 Unauthorized response
+type unauthorizedRetrier interface {
+	retryUnauthorized(ctx Context) error
+}
+
+// doRequest performs the request - if the response is 401 Unauthorized and an authorize function supports retrying,
+// the request is re-built, re-authorized and retried (once)
+func (m *method) doRequest(ctx Context) (response *http.Response, ok bool) {
+	if response, ok = m.send(ctx); ok && response.StatusCode == http.StatusUnauthorized {
+		retry := false
+		for _, c := range m.authFns {
+			if r, is := c.(unauthorizedRetrier); is {
+				if err := r.retryUnauthorized(ctx); err != nil {
+					ctx.reportFailure(err)
+					return nil, false
+				}
+				retry = true
+			}
+		}
+		if retry {
+			_ = response.Body.Close()
+			var request *http.Request
+			if request, ok = m.buildRequest(ctx); ok {
+				ctx.setCurrentRequest(request)
+				if ok = m.preRequestRun(ctx); ok {
+					response, ok = m.send(ctx)
+				}
+			}
+		}
+	}
+	return response, ok
+}
+
+// send sends the current request - as a gRPC call for gRPC methods (see GrpcMethod)
+func (m *method) send(ctx Context) (*http.Response, bool) {
+	if m.grpc != "" {
+		return ctx.doGrpcRequest(m.timeout)
+	}
+	return ctx.doRequest(m.timeout)
+}
+
 func (m *method) postRun(ctx Context) {
 	ok := true
 	lastExp := 0
@@ -479,17 +600,19 @@ func (m *method) postRun(ctx Context) {
 func (m *method) unmarshalResponseBody(ctx Context, res *http.Response) bool {
 	if res.Body != nil {
 		var body any
+		var wire, raw []byte
 		var err error
-		if m.responseUnmarshal != nil {
-			body, err = m.responseUnmarshal(res)
-		} else {
-			decoder := json.NewDecoder(res.Body)
-			decoder.UseNumber()
-			if err = decoder.Decode(&body); err == nil {
-				body, err = normalizeBody(body)
-			} else if err == io.EOF {
-				body = nil
-				err = nil
+		if wire, err = io.ReadAll(res.Body); err == nil {
+			if raw, err = decompressBody(wire, res.Header.Get(hdrContentEncoding)); err == nil {
+				ctx.setCurrentRawBody(raw)
+				if m.responseUnmarshal != nil {
+					res.Body = io.NopCloser(bytes.NewReader(wire))
+					body, err = m.responseUnmarshal(res)
+				} else if len(raw) > 0 {
+					if body, err = ctx.codecFor(res.Header.Get(hdrContentType)).Decode(raw); err == nil {
+						body, err = normalizeBody(body)
+					}
+				}
 			}
 		}
 		if err != nil {
@@ -498,11 +621,34 @@ func (m *method) unmarshalResponseBody(ctx Context, res *http.Response) bool {
 		}
 		ctx.setCurrentBody(body)
 	} else {
+		ctx.setCurrentRawBody(nil)
 		ctx.setCurrentBody(nil)
 	}
 	return true
 }
 
+// decompressBody decompresses the body data according to the content encoding ("gzip" or "deflate") - other
+// content encodings are left as is
+func decompressBody(data []byte, contentEncoding string) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "gzip", "x-gzip":
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case "deflate":
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		return data, nil
+	}
+	if err != nil || len(data) == 0 {
+		return data, nil
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	return io.ReadAll(r)
+}
+
 func normalizeBody(body any) (any, error) {
 	if body == nil {
 		return nil, nil
@@ -591,7 +737,8 @@ func (m *method) buildRequest(ctx Context) (request *http.Request, ok bool) {
 	if url, err = m.buildRequestUrl(ctx); err == nil {
 		var body io.Reader
 		var contentLen int
-		if body, contentLen, err = m.buildRequestBody(ctx); err == nil {
+		var bodyContentType string
+		if body, contentLen, bodyContentType, err = m.buildRequestBody(ctx); err == nil {
 			meth := string(m.method)
 			if meth == "" {
 				meth = http.MethodGet
@@ -610,9 +757,14 @@ func (m *method) buildRequest(ctx Context) (request *http.Request, ok bool) {
 						return
 					}
 				}
-				if !seenContentType {
+				if bodyContentType != "" {
+					request.Header.Set(contentType, bodyContentType)
+				} else if !seenContentType {
 					request.Header.Set("Content-Type", "application/json")
 				}
+				if m.compression != "" && body != nil && m.grpc == "" {
+					request.Header.Set("Content-Encoding", strings.ToLower(m.compression))
+				}
 				for ck := range m.useCookies {
 					if c := ctx.GetCookie(ck); c != nil {
 						request.AddCookie(c)
@@ -624,7 +776,9 @@ func (m *method) buildRequest(ctx Context) (request *http.Request, ok bool) {
 	return
 }
 
-func (m *method) buildRequestBody(ctx Context) (body io.Reader, contentLen int, err error) {
+// buildRequestBody builds the request body - the returned bodyContentType (if any) is the content type derived from the
+// body (which is applied to each built request, rather than stored in the method headers)
+func (m *method) buildRequestBody(ctx Context) (body io.Reader, contentLen int, bodyContentType string, err error) {
 	if m.body != nil {
 		var av any
 		if av, err = ResolveValue(m.body, ctx); err == nil {
@@ -634,13 +788,17 @@ func (m *method) buildRequestBody(ctx Context) (body io.Reader, contentLen int,
 			} else {
 				switch avt := av.(type) {
 				case FormMultipart:
-					var ct string
-					if data, contentLen, ct, err = avt.buildBody(ctx); err == nil {
-						m.headers["Content-Type"] = ct
-					}
+					data, contentLen, bodyContentType, err = avt.buildBody(ctx)
 				case FormUrlEncoded:
 					if data, err = avt.buildBody(ctx); err == nil {
-						m.headers["Content-Type"] = "application/x-www-form-urlencoded"
+						bodyContentType = "application/x-www-form-urlencoded"
+					}
+				case requestBodyEncoder:
+					var ct string
+					if data, ct, err = avt.encodeBody(ctx); err == nil {
+						if _, ok := m.headers["Content-Type"]; !ok {
+							bodyContentType = ct
+						}
 					}
 				case string:
 					data = []byte(avt)
@@ -661,6 +819,11 @@ func (m *method) buildRequestBody(ctx Context) (body io.Reader, contentLen int,
 					}
 				}
 			}
+			if err == nil && len(data) > 0 && m.compression != "" && m.grpc == "" {
+				if data, err = compressBody(data, m.compression); err == nil && contentLen > 0 {
+					contentLen = len(data)
+				}
+			}
 			if err == nil && len(data) > 0 {
 				body = bytes.NewReader(data)
 			}
@@ -670,6 +833,9 @@ func (m *method) buildRequestBody(ctx Context) (body io.Reader, contentLen int,
 }
 
 func (m *method) buildRequestUrl(ctx Context) (url string, err error) {
+	if m.grpc != "" {
+		return ctx.Host() + m.grpcFullMethod(ctx), nil
+	}
 	u := ctx.CurrentUrl()
 	var template urit.Template
 	if template, err = urit.NewTemplate(u); err == nil {
@@ -687,6 +853,9 @@ func (m *method) buildRequestUrl(ctx Context) (url string, err error) {
 }
 
 func (m *method) String() string {
+	if m.grpc != "" {
+		return fmt.Sprintf("GRPC %s %q", m.grpc, m.desc)
+	}
 	return fmt.Sprintf("%s %q", string(m.method), m.desc)
 }
 
@@ -2,6 +2,8 @@ package marrow
 
 import (
 	"github.com/go-andiamo/marrow/framing"
+	"github.com/go-andiamo/marrow/mocks/service"
+	"io/fs"
 	"strings"
 	"time"
 )
@@ -23,6 +25,18 @@ type MethodCaptures interface {
 	//
 	// Note: when only one database is used by tests, the dbName can be ""
 	DbClearTables(when When, dbName string, tableNames ...string) Method_
+	// DbSnapshot takes a named snapshot of a database
+	//
+	// Note: when only one database is used by tests, the dbName can be ""
+	DbSnapshot(when When, dbName string, snapshotName string) Method_
+	// DbRestoreSnapshot restores a database to a previously taken named snapshot
+	//
+	// Note: when only one database is used by tests, the dbName can be ""
+	DbRestoreSnapshot(when When, dbName string, snapshotName string) Method_
+	// DbSeed seeds a database from fixture files (see DbSeed for fixture file details)
+	//
+	// Note: when only one database is used by tests, the dbName can be ""
+	DbSeed(when When, dbName string, fixtures fs.FS) Method_
 	// Wait wait a specified milliseconds
 	//
 	// Note: the wait time is not included in the coverage timings
@@ -52,6 +66,8 @@ type MethodCaptures interface {
 	MockServiceClear(when When, svcName string) Method_
 	// MockServiceCall sets up a mock response on a specific named mock service
 	MockServiceCall(svcName string, path string, method MethodName, responseStatus int, responseBody any, headers ...any) Method_
+	// MockServiceFault sets up a fault (e.g. latency, connection reset, 5xx burst, timeout) on a specific named mock service
+	MockServiceFault(svcName string, path string, method MethodName, fault service.Fault) Method_
 }
 
 //go:noinline
@@ -148,6 +164,68 @@ func (m *method) DbClearTables(when When, dbName string, tableNames ...string) M
 	return m
 }
 
+//go:noinline
+func (m *method) DbSnapshot(when When, dbName string, snapshotName string) Method_ {
+	if when == Before {
+		m.preCaptures = append(m.preCaptures, &dbSnapshot{
+			when:         when,
+			dbName:       dbName,
+			snapshotName: snapshotName,
+			frame:        framing.NewFrame(0),
+		})
+	} else {
+		m.addPostCapture(&dbSnapshot{
+			when:         when,
+			dbName:       dbName,
+			snapshotName: snapshotName,
+			frame:        framing.NewFrame(0),
+		})
+	}
+	return m
+}
+
+//go:noinline
+func (m *method) DbRestoreSnapshot(when When, dbName string, snapshotName string) Method_ {
+	if when == Before {
+		m.preCaptures = append(m.preCaptures, &dbSnapshot{
+			when:         when,
+			dbName:       dbName,
+			snapshotName: snapshotName,
+			restore:      true,
+			frame:        framing.NewFrame(0),
+		})
+	} else {
+		m.addPostCapture(&dbSnapshot{
+			when:         when,
+			dbName:       dbName,
+			snapshotName: snapshotName,
+			restore:      true,
+			frame:        framing.NewFrame(0),
+		})
+	}
+	return m
+}
+
+//go:noinline
+func (m *method) DbSeed(when When, dbName string, fixtures fs.FS) Method_ {
+	if when == Before {
+		m.preCaptures = append(m.preCaptures, &dbSeed{
+			when:     when,
+			dbName:   dbName,
+			fixtures: fixtures,
+			frame:    framing.NewFrame(0),
+		})
+	} else {
+		m.addPostCapture(&dbSeed{
+			when:     when,
+			dbName:   dbName,
+			fixtures: fixtures,
+			frame:    framing.NewFrame(0),
+		})
+	}
+	return m
+}
+
 //go:noinline
 func (m *method) Wait(when When, ms int) Method_ {
 	if when == Before {
@@ -287,3 +365,15 @@ func (m *method) MockServiceCall(svcName string, path string, method MethodName,
 	})
 	return m
 }
+
+//go:noinline
+func (m *method) MockServiceFault(svcName string, path string, method MethodName, fault service.Fault) Method_ {
+	m.preCaptures = append(m.preCaptures, &mockServiceFault{
+		name:   svcName,
+		path:   path,
+		method: strings.ToUpper(string(method)),
+		fault:  fault,
+		frame:  framing.NewFrame(0),
+	})
+	return m
+}
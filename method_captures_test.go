@@ -1,10 +1,12 @@
 package marrow
 
 import (
+	"github.com/go-andiamo/marrow/mocks/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -127,6 +129,45 @@ func TestMethod_DbClearTables(t *testing.T) {
 	assert.Equal(t, 1, raw.postOps[1].index)
 }
 
+func TestMethod_DbSnapshot(t *testing.T) {
+	m := Method(GET, "").
+		DbSnapshot(Before, "", "snap").
+		DbSnapshot(After, "", "snap")
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	assert.Len(t, raw.preCaptures, 1)
+	assert.Len(t, raw.postCaptures, 1)
+	assert.Len(t, raw.postOps, 1)
+	assert.False(t, raw.postOps[0].isExpectation)
+	assert.Equal(t, 0, raw.postOps[0].index)
+}
+
+func TestMethod_DbRestoreSnapshot(t *testing.T) {
+	m := Method(GET, "").
+		DbRestoreSnapshot(Before, "", "snap").
+		DbRestoreSnapshot(After, "", "snap")
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	assert.Len(t, raw.preCaptures, 1)
+	assert.Len(t, raw.postCaptures, 1)
+	assert.Len(t, raw.postOps, 1)
+	assert.False(t, raw.postOps[0].isExpectation)
+	assert.Equal(t, 0, raw.postOps[0].index)
+}
+
+func TestMethod_DbSeed(t *testing.T) {
+	m := Method(GET, "").
+		DbSeed(Before, "", fstest.MapFS{}).
+		DbSeed(After, "", fstest.MapFS{})
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	assert.Len(t, raw.preCaptures, 1)
+	assert.Len(t, raw.postCaptures, 1)
+	assert.Len(t, raw.postOps, 1)
+	assert.False(t, raw.postOps[0].isExpectation)
+	assert.Equal(t, 0, raw.postOps[0].index)
+}
+
 func TestMethod_SetCookie(t *testing.T) {
 	m := Method(GET, "").
 		SetCookie(&http.Cookie{})
@@ -180,6 +221,18 @@ func TestMethod_MockServiceCall(t *testing.T) {
 	assert.Len(t, raw.preCaptures, 1)
 }
 
+func TestMethod_MockServiceFault(t *testing.T) {
+	m := Method(GET, "").
+		MockServiceFault("mock", "/foos", GET, service.Fault{Timeout: true})
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	require.Len(t, raw.preCaptures, 1)
+	mf, ok := raw.preCaptures[0].(*mockServiceFault)
+	require.True(t, ok)
+	assert.Equal(t, "GET", mf.method)
+	assert.True(t, mf.fault.Timeout)
+}
+
 func TestMethod_Wait(t *testing.T) {
 	m := Method(GET, "").
 		Wait(Before, 10).
@@ -297,6 +297,187 @@ type MethodExpectations interface {
 	// RequireMockServiceCalled requires that a specific mock service endpoint+method was called
 	RequireMockServiceCalled(svcName string, path string, method MethodName) Method_
 
+	// AssertTableRows asserts that the rows in a database table (optionally filtered by the where condition) match the expected rows
+	//
+	// see ExpectTableRows for details
+	AssertTableRows(dbName string, table string, where string, expectedRows any, options ...TableRowsOptions) Method_
+	// RequireTableRows requires that the rows in a database table (optionally filtered by the where condition) match the expected rows
+	//
+	// see ExpectTableRows for details
+	RequireTableRows(dbName string, table string, where string, expectedRows any, options ...TableRowsOptions) Method_
+	// AssertDbChanged asserts that the rows in a database table were changed by the request
+	//
+	// see ExpectDbChanged for details
+	AssertDbChanged(dbName string, table string, options ...TableRowsOptions) Method_
+	// RequireDbChanged requires that the rows in a database table were changed by the request
+	//
+	// see ExpectDbChanged for details
+	RequireDbChanged(dbName string, table string, options ...TableRowsOptions) Method_
+	// AssertDbUnchanged asserts that the rows in a database table were not changed by the request
+	//
+	// see ExpectDbUnchanged for details
+	AssertDbUnchanged(dbName string, table string, options ...TableRowsOptions) Method_
+	// RequireDbUnchanged requires that the rows in a database table were not changed by the request
+	//
+	// see ExpectDbUnchanged for details
+	RequireDbUnchanged(dbName string, table string, options ...TableRowsOptions) Method_
+
+	// AssertJsonEqual asserts that the actual value is structurally equal to the expected JSON value
+	//
+	// see ExpectJsonEqual for details
+	AssertJsonEqual(actual any, expected any, options ...JsonCompareOptions) Method_
+	// RequireJsonEqual requires that the actual value is structurally equal to the expected JSON value
+	//
+	// see ExpectJsonEqual for details
+	RequireJsonEqual(actual any, expected any, options ...JsonCompareOptions) Method_
+	// AssertJsonSubset asserts that the expected JSON value is a structural subset of the actual value
+	//
+	// see ExpectJsonSubset for details
+	AssertJsonSubset(actual any, expected any, options ...JsonCompareOptions) Method_
+	// RequireJsonSubset requires that the expected JSON value is a structural subset of the actual value
+	//
+	// see ExpectJsonSubset for details
+	RequireJsonSubset(actual any, expected any, options ...JsonCompareOptions) Method_
+
+	// AssertSnapshot asserts that the resolved value (typically Body) matches a stored golden file snapshot
+	//
+	// see ExpectSnapshot for details
+	AssertSnapshot(name string, value any, options ...SnapshotOptions) Method_
+	// RequireSnapshot requires that the resolved value (typically Body) matches a stored golden file snapshot
+	//
+	// see ExpectSnapshot for details
+	RequireSnapshot(name string, value any, options ...SnapshotOptions) Method_
+
+	// AssertEvery asserts that every element of the value (or resolved value) matches the predicate
+	//
+	// see ExpectEvery for details
+	AssertEvery(value any, predicate any) Method_
+	// RequireEvery requires that every element of the value (or resolved value) matches the predicate
+	//
+	// see ExpectEvery for details
+	RequireEvery(value any, predicate any) Method_
+	// AssertAny asserts that at least one element of the value (or resolved value) matches the predicate
+	//
+	// see ExpectAny for details
+	AssertAny(value any, predicate any) Method_
+	// RequireAny requires that at least one element of the value (or resolved value) matches the predicate
+	//
+	// see ExpectAny for details
+	RequireAny(value any, predicate any) Method_
+	// AssertNone asserts that no element of the value (or resolved value) matches the predicate
+	//
+	// see ExpectNone for details
+	AssertNone(value any, predicate any) Method_
+	// RequireNone requires that no element of the value (or resolved value) matches the predicate
+	//
+	// see ExpectNone for details
+	RequireNone(value any, predicate any) Method_
+	// AssertSortedBy asserts that the elements of the value (or resolved value) are sorted by the value at the path within each element
+	//
+	// see ExpectSortedBy for details
+	AssertSortedBy(value any, path string, order SortOrder) Method_
+	// RequireSortedBy requires that the elements of the value (or resolved value) are sorted by the value at the path within each element
+	//
+	// see ExpectSortedBy for details
+	RequireSortedBy(value any, path string, order SortOrder) Method_
+	// AssertUnique asserts that the elements of the value (or resolved value) are unique by the value at the path within each element
+	//
+	// see ExpectUnique for details
+	AssertUnique(value any, path string) Method_
+	// RequireUnique requires that the elements of the value (or resolved value) are unique by the value at the path within each element
+	//
+	// see ExpectUnique for details
+	RequireUnique(value any, path string) Method_
+	// AssertContainsElement asserts that the value (or resolved value) contains an element that structurally matches the expected element
+	//
+	// see ExpectContainsElement for details
+	AssertContainsElement(value any, element any) Method_
+	// RequireContainsElement requires that the value (or resolved value) contains an element that structurally matches the expected element
+	//
+	// see ExpectContainsElement for details
+	RequireContainsElement(value any, element any) Method_
+	// AssertSetEqual asserts that the value (or resolved value) contains the same elements as the expected value - regardless of order
+	//
+	// see ExpectSetEqual for details
+	AssertSetEqual(value any, expected any) Method_
+	// RequireSetEqual requires that the value (or resolved value) contains the same elements as the expected value - regardless of order
+	//
+	// see ExpectSetEqual for details
+	RequireSetEqual(value any, expected any) Method_
+	// AssertTimeWithin asserts that the time value (or resolved value) is within the tolerance of the reference time (or resolved reference)
+	//
+	// see ExpectTimeWithin for details
+	AssertTimeWithin(value any, reference any, tolerance any) Method_
+	// RequireTimeWithin requires that the time value (or resolved value) is within the tolerance of the reference time (or resolved reference)
+	//
+	// see ExpectTimeWithin for details
+	RequireTimeWithin(value any, reference any, tolerance any) Method_
+	// AssertBefore asserts that the time value (or resolved value) v1 is before v2
+	AssertBefore(v1, v2 any) Method_
+	// RequireBefore requires that the time value (or resolved value) v1 is before v2
+	RequireBefore(v1, v2 any) Method_
+	// AssertAfter asserts that the time value (or resolved value) v1 is after v2
+	AssertAfter(v1, v2 any) Method_
+	// RequireAfter requires that the time value (or resolved value) v1 is after v2
+	RequireAfter(v1, v2 any) Method_
+	// AssertHeader asserts that the named response header is present and that (any of) its value(s) match the matcher
+	//
+	// see ExpectHeader for details
+	AssertHeader(name string, matcher any) Method_
+	// RequireHeader requires that the named response header is present and that (any of) its value(s) match the matcher
+	//
+	// see ExpectHeader for details
+	RequireHeader(name string, matcher any) Method_
+	// AssertContentType asserts that the response "Content-Type" header matches the media type
+	//
+	// see ExpectContentType for details
+	AssertContentType(mediaType string) Method_
+	// RequireContentType requires that the response "Content-Type" header matches the media type
+	//
+	// see ExpectContentType for details
+	RequireContentType(mediaType string) Method_
+	// AssertCacheControl asserts that the response "Cache-Control" header contains all the directives
+	//
+	// see ExpectCacheControl for details
+	AssertCacheControl(directives ...string) Method_
+	// RequireCacheControl requires that the response "Cache-Control" header contains all the directives
+	//
+	// see ExpectCacheControl for details
+	RequireCacheControl(directives ...string) Method_
+	// AssertCORS asserts (using preflight "OPTIONS" requests) that the endpoint allows cross-origin requests from the origin for the methods
+	//
+	// see ExpectCORS for details
+	AssertCORS(origin string, methods ...MethodName) Method_
+	// RequireCORS requires (using preflight "OPTIONS" requests) that the endpoint allows cross-origin requests from the origin for the methods
+	//
+	// see ExpectCORS for details
+	RequireCORS(origin string, methods ...MethodName) Method_
+	// AssertSecurityHeaders asserts that the response has the security headers required by the profile
+	//
+	// see ExpectSecurityHeaders for details
+	AssertSecurityHeaders(profile SecurityHeadersProfile) Method_
+	// RequireSecurityHeaders requires that the response has the security headers required by the profile
+	//
+	// see ExpectSecurityHeaders for details
+	RequireSecurityHeaders(profile SecurityHeadersProfile) Method_
+	// AssertBodyBytes asserts that the raw (undecoded) response body is exactly the expected bytes
+	//
+	// see ExpectBodyBytes for details
+	AssertBodyBytes(expected any) Method_
+	// RequireBodyBytes requires that the raw (undecoded) response body is exactly the expected bytes
+	//
+	// see ExpectBodyBytes for details
+	RequireBodyBytes(expected any) Method_
+	// AssertBodyHash asserts that the SHA-256 hash of the raw (undecoded) response body is the expected hex encoded hash
+	AssertBodyHash(sha256 any) Method_
+	// RequireBodyHash requires that the SHA-256 hash of the raw (undecoded) response body is the expected hex encoded hash
+	RequireBodyHash(sha256 any) Method_
+
+	// AssertNoGraphQLErrors asserts that the (GraphQL) response body has no "errors"
+	AssertNoGraphQLErrors() Method_
+	// RequireNoGraphQLErrors requires that the (GraphQL) response body has no "errors"
+	RequireNoGraphQLErrors() Method_
+
 	// AssertVarSet asserts that a named variable has been set
 	AssertVarSet(v Var) Method_
 	// RequireVarSet requires that a named variable has been set
@@ -960,3 +1141,529 @@ func (m *method) RequireFalse(value any) Method_ {
 	})
 	return m
 }
+
+//go:noinline
+func (m *method) AssertTableRows(dbName string, table string, where string, expectedRows any, options ...TableRowsOptions) Method_ {
+	m.addPostExpectation(&tableRows{
+		dbName:   dbName,
+		table:    table,
+		where:    where,
+		expected: expectedRows,
+		options:  firstTableRowsOptions(options),
+		frame:    framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireTableRows(dbName string, table string, where string, expectedRows any, options ...TableRowsOptions) Method_ {
+	m.addPostExpectation(&tableRows{
+		dbName:            dbName,
+		table:             table,
+		where:             where,
+		expected:          expectedRows,
+		options:           firstTableRowsOptions(options),
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertDbChanged(dbName string, table string, options ...TableRowsOptions) Method_ {
+	m.addPostExpectation(&dbChanged{
+		dbName:  dbName,
+		table:   table,
+		options: firstTableRowsOptions(options),
+		frame:   framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireDbChanged(dbName string, table string, options ...TableRowsOptions) Method_ {
+	m.addPostExpectation(&dbChanged{
+		dbName:            dbName,
+		table:             table,
+		options:           firstTableRowsOptions(options),
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertDbUnchanged(dbName string, table string, options ...TableRowsOptions) Method_ {
+	m.addPostExpectation(&dbChanged{
+		dbName:    dbName,
+		table:     table,
+		options:   firstTableRowsOptions(options),
+		unchanged: true,
+		frame:     framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireDbUnchanged(dbName string, table string, options ...TableRowsOptions) Method_ {
+	m.addPostExpectation(&dbChanged{
+		dbName:            dbName,
+		table:             table,
+		options:           firstTableRowsOptions(options),
+		unchanged:         true,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertJsonEqual(actual any, expected any, options ...JsonCompareOptions) Method_ {
+	m.addPostExpectation(&jsonCompare{
+		actual:   actual,
+		expected: expected,
+		options:  firstJsonCompareOptions(options),
+		frame:    framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireJsonEqual(actual any, expected any, options ...JsonCompareOptions) Method_ {
+	m.addPostExpectation(&jsonCompare{
+		actual:            actual,
+		expected:          expected,
+		options:           firstJsonCompareOptions(options),
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertJsonSubset(actual any, expected any, options ...JsonCompareOptions) Method_ {
+	m.addPostExpectation(&jsonCompare{
+		actual:   actual,
+		expected: expected,
+		subset:   true,
+		options:  firstJsonCompareOptions(options),
+		frame:    framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireJsonSubset(actual any, expected any, options ...JsonCompareOptions) Method_ {
+	m.addPostExpectation(&jsonCompare{
+		actual:            actual,
+		expected:          expected,
+		subset:            true,
+		options:           firstJsonCompareOptions(options),
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertSnapshot(name string, value any, options ...SnapshotOptions) Method_ {
+	m.addPostExpectation(&snapshot{
+		name:    name,
+		value:   value,
+		options: firstSnapshotOptions(options),
+		frame:   framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireSnapshot(name string, value any, options ...SnapshotOptions) Method_ {
+	m.addPostExpectation(&snapshot{
+		name:              name,
+		value:             value,
+		options:           firstSnapshotOptions(options),
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertEvery(value any, predicate any) Method_ {
+	m.addPostExpectation(&collectionPredicate{
+		value:     value,
+		predicate: predicate,
+		match:     matchEvery,
+		frame:     framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireEvery(value any, predicate any) Method_ {
+	m.addPostExpectation(&collectionPredicate{
+		value:             value,
+		predicate:         predicate,
+		match:             matchEvery,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertAny(value any, predicate any) Method_ {
+	m.addPostExpectation(&collectionPredicate{
+		value:     value,
+		predicate: predicate,
+		match:     matchAny,
+		frame:     framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireAny(value any, predicate any) Method_ {
+	m.addPostExpectation(&collectionPredicate{
+		value:             value,
+		predicate:         predicate,
+		match:             matchAny,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertNone(value any, predicate any) Method_ {
+	m.addPostExpectation(&collectionPredicate{
+		value:     value,
+		predicate: predicate,
+		match:     matchNone,
+		frame:     framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireNone(value any, predicate any) Method_ {
+	m.addPostExpectation(&collectionPredicate{
+		value:             value,
+		predicate:         predicate,
+		match:             matchNone,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertSortedBy(value any, path string, order SortOrder) Method_ {
+	m.addPostExpectation(&sortedBy{
+		value: value,
+		path:  path,
+		order: order,
+		frame: framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireSortedBy(value any, path string, order SortOrder) Method_ {
+	m.addPostExpectation(&sortedBy{
+		value:             value,
+		path:              path,
+		order:             order,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertUnique(value any, path string) Method_ {
+	m.addPostExpectation(&unique{
+		value: value,
+		path:  path,
+		frame: framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireUnique(value any, path string) Method_ {
+	m.addPostExpectation(&unique{
+		value:             value,
+		path:              path,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertContainsElement(value any, element any) Method_ {
+	m.addPostExpectation(&containsElement{
+		value:   value,
+		element: element,
+		frame:   framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireContainsElement(value any, element any) Method_ {
+	m.addPostExpectation(&containsElement{
+		value:             value,
+		element:           element,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertSetEqual(value any, expected any) Method_ {
+	m.addPostExpectation(&setEqual{
+		value:    value,
+		expected: expected,
+		frame:    framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireSetEqual(value any, expected any) Method_ {
+	m.addPostExpectation(&setEqual{
+		value:             value,
+		expected:          expected,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertTimeWithin(value any, reference any, tolerance any) Method_ {
+	m.addPostExpectation(&timeCompare{
+		value:     value,
+		reference: reference,
+		tolerance: tolerance,
+		kind:      timeWithin,
+		frame:     framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireTimeWithin(value any, reference any, tolerance any) Method_ {
+	m.addPostExpectation(&timeCompare{
+		value:             value,
+		reference:         reference,
+		tolerance:         tolerance,
+		kind:              timeWithin,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertBefore(v1, v2 any) Method_ {
+	m.addPostExpectation(&timeCompare{
+		value:     v1,
+		reference: v2,
+		kind:      timeBefore,
+		frame:     framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireBefore(v1, v2 any) Method_ {
+	m.addPostExpectation(&timeCompare{
+		value:             v1,
+		reference:         v2,
+		kind:              timeBefore,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertAfter(v1, v2 any) Method_ {
+	m.addPostExpectation(&timeCompare{
+		value:     v1,
+		reference: v2,
+		kind:      timeAfter,
+		frame:     framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireAfter(v1, v2 any) Method_ {
+	m.addPostExpectation(&timeCompare{
+		value:             v1,
+		reference:         v2,
+		kind:              timeAfter,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertHeader(name string, matcher any) Method_ {
+	m.addPostExpectation(&expectHeader{
+		header:  name,
+		matcher: matcher,
+		frame:   framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireHeader(name string, matcher any) Method_ {
+	m.addPostExpectation(&expectHeader{
+		header:            name,
+		matcher:           matcher,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertContentType(mediaType string) Method_ {
+	m.addPostExpectation(&expectContentType{
+		mediaType: mediaType,
+		frame:     framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireContentType(mediaType string) Method_ {
+	m.addPostExpectation(&expectContentType{
+		mediaType:         mediaType,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertCacheControl(directives ...string) Method_ {
+	m.addPostExpectation(&expectCacheControl{
+		directives: directives,
+		frame:      framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireCacheControl(directives ...string) Method_ {
+	m.addPostExpectation(&expectCacheControl{
+		directives:        directives,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertCORS(origin string, methods ...MethodName) Method_ {
+	m.addPostExpectation(&expectCORS{
+		origin:  origin,
+		methods: methods,
+		frame:   framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireCORS(origin string, methods ...MethodName) Method_ {
+	m.addPostExpectation(&expectCORS{
+		origin:            origin,
+		methods:           methods,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertSecurityHeaders(profile SecurityHeadersProfile) Method_ {
+	m.addPostExpectation(&expectSecurityHeaders{
+		profile: profile,
+		frame:   framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireSecurityHeaders(profile SecurityHeadersProfile) Method_ {
+	m.addPostExpectation(&expectSecurityHeaders{
+		profile:           profile,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertBodyBytes(expected any) Method_ {
+	m.addPostExpectation(&expectBodyBytes{
+		expected: expected,
+		frame:    framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireBodyBytes(expected any) Method_ {
+	m.addPostExpectation(&expectBodyBytes{
+		expected:          expected,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertBodyHash(sha256 any) Method_ {
+	m.addPostExpectation(&expectBodyHash{
+		hash:  sha256,
+		frame: framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireBodyHash(sha256 any) Method_ {
+	m.addPostExpectation(&expectBodyHash{
+		hash:              sha256,
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) AssertNoGraphQLErrors() Method_ {
+	m.addPostExpectation(&expectNoGraphQLErrors{
+		frame: framing.NewFrame(0),
+	})
+	return m
+}
+
+//go:noinline
+func (m *method) RequireNoGraphQLErrors() Method_ {
+	m.addPostExpectation(&expectNoGraphQLErrors{
+		frame:             framing.NewFrame(0),
+		commonExpectation: commonExpectation{required: true},
+	})
+	return m
+}
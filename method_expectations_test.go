@@ -4,6 +4,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestMethod_Expect(t *testing.T) {
@@ -748,3 +749,146 @@ func TestRequireFalse(t *testing.T) {
 	assert.True(t, raw.postOps[0].isExpectation)
 	assert.Equal(t, 0, raw.postOps[0].index)
 }
+
+func TestMethod_TableRows(t *testing.T) {
+	m := Method(GET, "").
+		AssertTableRows("", "table", "", nil).
+		RequireTableRows("", "table", "", nil)
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	assert.Len(t, raw.preCaptures, 0)
+	assert.Len(t, raw.expectations, 2)
+	assert.False(t, raw.expectations[0].IsRequired())
+	assert.True(t, raw.expectations[1].IsRequired())
+}
+
+func TestMethod_DbChanged(t *testing.T) {
+	m := Method(GET, "").
+		AssertDbChanged("", "table").
+		RequireDbChanged("", "table").
+		AssertDbUnchanged("", "table").
+		RequireDbUnchanged("", "table")
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	assert.Len(t, raw.preCaptures, 4)
+	assert.Len(t, raw.expectations, 4)
+	assert.False(t, raw.expectations[0].IsRequired())
+	assert.True(t, raw.expectations[1].IsRequired())
+	assert.False(t, raw.expectations[2].IsRequired())
+	assert.True(t, raw.expectations[3].IsRequired())
+}
+
+func TestMethod_JsonEqual(t *testing.T) {
+	m := Method(GET, "").
+		AssertJsonEqual(Body, JSON{}).
+		RequireJsonEqual(Body, JSON{}).
+		AssertJsonSubset(Body, JSON{}).
+		RequireJsonSubset(Body, JSON{})
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	assert.Len(t, raw.expectations, 4)
+	assert.False(t, raw.expectations[0].IsRequired())
+	assert.True(t, raw.expectations[1].IsRequired())
+	assert.False(t, raw.expectations[2].IsRequired())
+	assert.True(t, raw.expectations[3].IsRequired())
+	assert.Equal(t, "Expect Json Equal", raw.expectations[1].Name())
+	assert.Equal(t, "Expect Json Subset", raw.expectations[3].Name())
+}
+
+func TestMethod_Snapshot(t *testing.T) {
+	m := Method(GET, "").
+		AssertSnapshot("foo", Body).
+		RequireSnapshot("foo", Body)
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	assert.Len(t, raw.expectations, 2)
+	assert.False(t, raw.expectations[0].IsRequired())
+	assert.True(t, raw.expectations[1].IsRequired())
+	assert.Equal(t, "Expect Snapshot foo", raw.expectations[1].Name())
+}
+
+func TestMethod_CollectionExpectations(t *testing.T) {
+	m := Method(GET, "").
+		AssertEvery(Body, AnyString()).
+		RequireEvery(Body, AnyString()).
+		AssertAny(Body, AnyString()).
+		RequireAny(Body, AnyString()).
+		AssertNone(Body, AnyString()).
+		RequireNone(Body, AnyString()).
+		AssertSortedBy(Body, "id", Ascending).
+		RequireSortedBy(Body, "id", Descending).
+		AssertUnique(Body, "id").
+		RequireUnique(Body, "id").
+		AssertContainsElement(Body, "a").
+		RequireContainsElement(Body, "a").
+		AssertSetEqual(Body, JSONArray{}).
+		RequireSetEqual(Body, JSONArray{})
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	require.Len(t, raw.expectations, 14)
+	for i, exp := range raw.expectations {
+		assert.Equal(t, i%2 == 1, exp.IsRequired())
+	}
+}
+
+func TestMethod_TimeExpectations(t *testing.T) {
+	m := Method(GET, "").
+		AssertTimeWithin(Body, Now(), time.Second).
+		RequireTimeWithin(Body, Now(), time.Second).
+		AssertBefore(Body, Now()).
+		RequireBefore(Body, Now()).
+		AssertAfter(Body, Now()).
+		RequireAfter(Body, Now())
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	require.Len(t, raw.expectations, 6)
+	for i, exp := range raw.expectations {
+		assert.Equal(t, i%2 == 1, exp.IsRequired())
+	}
+}
+
+func TestMethod_HeaderExpectations(t *testing.T) {
+	m := Method(GET, "").
+		AssertHeader("Vary", "Origin").
+		RequireHeader("Vary", "Origin").
+		AssertContentType("application/json").
+		RequireContentType("application/json").
+		AssertCacheControl("no-store").
+		RequireCacheControl("no-store").
+		AssertCORS("https://example.com", GET).
+		RequireCORS("https://example.com", GET).
+		AssertSecurityHeaders(SecurityHeadersBaseline).
+		RequireSecurityHeaders(SecurityHeadersBaseline)
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	require.Len(t, raw.expectations, 10)
+	for i, exp := range raw.expectations {
+		assert.Equal(t, i%2 == 1, exp.IsRequired())
+	}
+}
+
+func TestMethod_BodyExpectations(t *testing.T) {
+	m := Method(GET, "").
+		AssertBodyBytes("foo").
+		RequireBodyBytes("foo").
+		AssertBodyHash("abc").
+		RequireBodyHash("abc")
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	require.Len(t, raw.expectations, 4)
+	for i, exp := range raw.expectations {
+		assert.Equal(t, i%2 == 1, exp.IsRequired())
+	}
+}
+
+func TestMethod_GraphQLExpectations(t *testing.T) {
+	m := Method(POST, "").
+		AssertNoGraphQLErrors().
+		RequireNoGraphQLErrors()
+	raw, ok := m.(*method)
+	require.True(t, ok)
+	require.Len(t, raw.expectations, 2)
+	for i, exp := range raw.expectations {
+		assert.Equal(t, i%2 == 1, exp.IsRequired())
+	}
+}
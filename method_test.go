@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestMethod(t *testing.T) {
@@ -29,6 +30,9 @@ func TestMethod(t *testing.T) {
 	assert.False(t, raw.failFast)
 	m.FailFast()
 	assert.True(t, raw.failFast)
+	assert.Equal(t, time.Duration(0), raw.timeout)
+	m.Timeout(time.Second)
+	assert.Equal(t, time.Second, raw.timeout)
 }
 
 func TestMethods(t *testing.T) {
@@ -214,7 +218,7 @@ func TestMethod_BuildRequestBody(t *testing.T) {
 			"foo": 42,
 		})
 		raw := m.(*method)
-		body, _, err := raw.buildRequestBody(ctx)
+		body, _, _, err := raw.buildRequestBody(ctx)
 		require.NoError(t, err)
 		data, err := io.ReadAll(body)
 		require.NoError(t, err)
@@ -228,7 +232,7 @@ func TestMethod_BuildRequestBody(t *testing.T) {
 		})
 		ctx := newTestContext(nil)
 		raw := m.(*method)
-		body, _, err := raw.buildRequestBody(ctx)
+		body, _, _, err := raw.buildRequestBody(ctx)
 		require.NoError(t, err)
 		data, err := io.ReadAll(body)
 		require.NoError(t, err)
@@ -239,7 +243,7 @@ func TestMethod_BuildRequestBody(t *testing.T) {
 		m.RequestBody([]byte("foo"))
 		ctx := newTestContext(nil)
 		raw := m.(*method)
-		body, _, err := raw.buildRequestBody(ctx)
+		body, _, _, err := raw.buildRequestBody(ctx)
 		require.NoError(t, err)
 		data, err := io.ReadAll(body)
 		require.NoError(t, err)
@@ -250,7 +254,7 @@ func TestMethod_BuildRequestBody(t *testing.T) {
 		m.RequestBody([]any{"foo"})
 		ctx := newTestContext(nil)
 		raw := m.(*method)
-		body, _, err := raw.buildRequestBody(ctx)
+		body, _, _, err := raw.buildRequestBody(ctx)
 		require.NoError(t, err)
 		data, err := io.ReadAll(body)
 		require.NoError(t, err)
@@ -261,7 +265,7 @@ func TestMethod_BuildRequestBody(t *testing.T) {
 		m.RequestBody(map[string]string{"foo": "bar"})
 		ctx := newTestContext(nil)
 		raw := m.(*method)
-		body, _, err := raw.buildRequestBody(ctx)
+		body, _, _, err := raw.buildRequestBody(ctx)
 		require.NoError(t, err)
 		data, err := io.ReadAll(body)
 		require.NoError(t, err)
@@ -272,7 +276,7 @@ func TestMethod_BuildRequestBody(t *testing.T) {
 		m.RequestBody(42)
 		ctx := newTestContext(nil)
 		raw := m.(*method)
-		body, _, err := raw.buildRequestBody(ctx)
+		body, _, _, err := raw.buildRequestBody(ctx)
 		require.NoError(t, err)
 		data, err := io.ReadAll(body)
 		require.NoError(t, err)
@@ -289,9 +293,9 @@ func TestMethod_BuildRequestBody(t *testing.T) {
 		))
 		ctx := newTestContext(map[Var]any{"foo": "bar"})
 		raw := m.(*method)
-		body, _, err := raw.buildRequestBody(ctx)
+		body, _, ct, err := raw.buildRequestBody(ctx)
 		require.NoError(t, err)
-		assert.Contains(t, raw.headers["Content-Type"], "multipart/form-data; boundary=")
+		assert.Contains(t, ct, "multipart/form-data; boundary=")
 		data, err := io.ReadAll(body)
 		require.NoError(t, err)
 		assert.Contains(t, string(data), `Content-Disposition: form-data; name="foo"`)
@@ -310,7 +314,7 @@ func TestMethod_BuildRequestBody(t *testing.T) {
 		))
 		ctx := newTestContext(nil)
 		raw := m.(*method)
-		_, _, err := raw.buildRequestBody(ctx)
+		_, _, _, err := raw.buildRequestBody(ctx)
 		require.Error(t, err)
 	})
 	t.Run("url encoded", func(t *testing.T) {
@@ -324,9 +328,10 @@ func TestMethod_BuildRequestBody(t *testing.T) {
 		))
 		ctx := newTestContext(map[Var]any{"foo1": "bar", "foo3": "bar3", "foo4": "bar4a"})
 		raw := m.(*method)
-		body, _, err := raw.buildRequestBody(ctx)
+		body, _, ct, err := raw.buildRequestBody(ctx)
 		require.NoError(t, err)
-		assert.Equal(t, "application/x-www-form-urlencoded", raw.headers["Content-Type"])
+		assert.Equal(t, "application/x-www-form-urlencoded", ct)
+		assert.NotContains(t, raw.headers, "Content-Type")
 		data, err := io.ReadAll(body)
 		require.NoError(t, err)
 		assert.Equal(t, "foo1=bar&foo2=foo2&foo3=bar3&foo4=bar4&foo4=bar4a&foo5=bar5&foo5=bar5a", string(data))
@@ -336,7 +341,7 @@ func TestMethod_BuildRequestBody(t *testing.T) {
 		m.RequestBody(UrlEncoded("foo", Var("foo")))
 		ctx := newTestContext(nil)
 		raw := m.(*method)
-		_, _, err := raw.buildRequestBody(ctx)
+		_, _, _, err := raw.buildRequestBody(ctx)
 		require.Error(t, err)
 	})
 }
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Option is an option for NewMockedService
+type Option func(m *mockedService)
+
+// Proxy sets the mocked service to forward calls (that are not mocked or replayed) to the target url
+//
+// the target url is the base url of the real service - e.g. "http://localhost:8081" (the request path and query are appended)
+func Proxy(target string) Option {
+	return func(m *mockedService) {
+		m.proxy = &proxying{target: target}
+	}
+}
+
+// Record sets the mocked service to record proxied calls into a cassette file in the dir
+//
+// the cassette file is named "<service name>.json" and is written as each call is recorded - the recorded cassette
+// can then be used with ReplayFrom
+//
+// Record is only used when the mocked service also has a Proxy target
+func Record(dir string) Option {
+	return func(m *mockedService) {
+		m.record = dir
+	}
+}
+
+// ReplayFrom sets the mocked service to replay calls from a cassette file (named "<service name>.json") in the fsys
+//
+// calls are matched on method, path, query and body (json bodies are compared by value) - when there are multiple
+// matching recorded calls, they are replayed in the order recorded (and the last is then replayed for any further calls)
+//
+// calls that are mocked (see MockedService.MockCall) take precedence over replayed calls - and calls that are not
+// matched are proxied (if there is a Proxy target) or responded to with 404 Not Found
+func ReplayFrom(fsys fs.FS) Option {
+	return func(m *mockedService) {
+		m.replay = &replaying{fsys: fsys}
+	}
+}
+
+func (m *mockedService) cassetteName() string {
+	return m.name + ".json"
+}
+
+func (m *mockedService) initOptions() (err error) {
+	if m.pact != nil {
+		m.pact.init(m.pactExclude)
+	}
+	if m.replay != nil {
+		if err = m.replay.load(m.cassetteName(), m.proxy != nil); err != nil {
+			return err
+		}
+	}
+	if m.proxy != nil {
+		if err = m.proxy.init(); err != nil {
+			return err
+		}
+		if m.record != "" {
+			m.proxy.recording = &cassette{Name: m.name, Interactions: make([]interaction, 0)}
+			m.proxy.recordPath = filepath.Join(m.record, m.cassetteName())
+			if m.replay != nil {
+				m.proxy.recording.Interactions = append(m.proxy.recording.Interactions, m.replay.cassette.Interactions...)
+			}
+		}
+	} else if m.record != "" {
+		err = errors.New("record requires a proxy target")
+	}
+	return err
+}
+
+func (m *mockedService) serveRecorded(w http.ResponseWriter, r *http.Request, body []byte) {
+	if m.replay == nil && m.proxy == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if m.replay != nil {
+		if i, ok := m.replay.match(r, body); ok {
+			m.markServed(r)
+			i.Response.write(w)
+			return
+		}
+	}
+	if m.proxy != nil {
+		i, err := m.proxy.forward(r, body)
+		if err != nil {
+			http.Error(w, "mocked service: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		m.markServed(r)
+		if err = m.proxy.record(i); err != nil {
+			http.Error(w, "mocked service: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		i.Response.write(w)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (m *mockedService) markServed(r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.served[r.Method+" "+r.URL.Path]++
+}
+
+// cassette is the file format for recorded calls
+type cassette struct {
+	Name         string        `json:"name"`
+	Interactions []interaction `json:"interactions"`
+}
+
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	recordedBody
+}
+
+type recordedResponse struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	recordedBody
+}
+
+type recordedBody struct {
+	Body         string `json:"body,omitempty"`
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+}
+
+func newRecordedBody(data []byte) recordedBody {
+	if utf8.Valid(data) {
+		return recordedBody{Body: string(data)}
+	}
+	return recordedBody{Body: base64.StdEncoding.EncodeToString(data), BodyEncoding: "base64"}
+}
+
+func (b recordedBody) bytes() []byte {
+	if b.BodyEncoding == "base64" {
+		data, _ := base64.StdEncoding.DecodeString(b.Body)
+		return data
+	}
+	return []byte(b.Body)
+}
+
+func (r recordedResponse) write(w http.ResponseWriter) {
+	for k, vs := range r.Headers {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(r.Status)
+	_, _ = w.Write(r.bytes())
+}
+
+func (r recordedRequest) matches(req *http.Request, body []byte) bool {
+	return r.Method == req.Method && r.Path == req.URL.Path && queriesMatch(r.Query, req.URL.RawQuery) && bodiesMatch(r.bytes(), body)
+}
+
+func queriesMatch(q1, q2 string) bool {
+	if q1 == q2 {
+		return true
+	}
+	v1, err1 := url.ParseQuery(q1)
+	v2, err2 := url.ParseQuery(q2)
+	return err1 == nil && err2 == nil && reflect.DeepEqual(v1, v2)
+}
+
+func bodiesMatch(b1, b2 []byte) bool {
+	if bytes.Equal(b1, b2) {
+		return true
+	}
+	var j1, j2 any
+	return json.Unmarshal(b1, &j1) == nil && json.Unmarshal(b2, &j2) == nil && reflect.DeepEqual(j1, j2)
+}
+
+type replaying struct {
+	fsys     fs.FS
+	cassette *cassette
+	used     []bool
+	mu       sync.Mutex
+}
+
+func (r *replaying) load(name string, optional bool) error {
+	r.cassette = &cassette{Interactions: make([]interaction, 0)}
+	data, err := fs.ReadFile(r.fsys, name)
+	if err == nil {
+		err = json.Unmarshal(data, r.cassette)
+	} else if optional && errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read cassette %q: %w", name, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return nil
+}
+
+func (r *replaying) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.used = make([]bool, len(r.cassette.Interactions))
+}
+
+func (r *replaying) match(req *http.Request, body []byte) (*interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := -1
+	for i := range r.cassette.Interactions {
+		if r.cassette.Interactions[i].Request.matches(req, body) {
+			if !r.used[i] {
+				r.used[i] = true
+				return &r.cassette.Interactions[i], true
+			}
+			last = i
+		}
+	}
+	if last != -1 {
+		return &r.cassette.Interactions[last], true
+	}
+	return nil, false
+}
+
+type proxying struct {
+	target     string
+	client     *http.Client
+	recording  *cassette
+	recordPath string
+	mu         sync.Mutex
+}
+
+func (p *proxying) init() error {
+	if u, err := url.Parse(p.target); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid proxy target %q", p.target)
+	}
+	p.target = strings.TrimSuffix(p.target, "/")
+	p.client = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return nil
+}
+
+// hopHeaders are headers that are not forwarded (or recorded)
+var hopHeaders = map[string]struct{}{
+	"Connection":          {},
+	"Keep-Alive":          {},
+	"Proxy-Authenticate":  {},
+	"Proxy-Authorization": {},
+	"Te":                  {},
+	"Trailer":             {},
+	"Transfer-Encoding":   {},
+	"Upgrade":             {},
+	"Content-Length":      {},
+	"Date":                {},
+}
+
+func (p *proxying) forward(r *http.Request, body []byte) (*interaction, error) {
+	u := p.target + r.URL.Path
+	if r.URL.RawQuery != "" {
+		u += "?" + r.URL.RawQuery
+	}
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range r.Header {
+		if _, hop := hopHeaders[http.CanonicalHeaderKey(k)]; !hop {
+			req.Header[k] = vs
+		}
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	hdrs := make(map[string][]string, len(res.Header))
+	for k, vs := range res.Header {
+		if _, hop := hopHeaders[http.CanonicalHeaderKey(k)]; !hop {
+			hdrs[k] = vs
+		}
+	}
+	return &interaction{
+		Request: recordedRequest{
+			Method:       r.Method,
+			Path:         r.URL.Path,
+			Query:        r.URL.RawQuery,
+			recordedBody: newRecordedBody(body),
+		},
+		Response: recordedResponse{
+			Status:       res.StatusCode,
+			Headers:      hdrs,
+			recordedBody: newRecordedBody(resBody),
+		},
+	}, nil
+}
+
+// record adds the interaction to the recording (if recording) and writes the cassette file
+func (p *proxying) record(i *interaction) error {
+	if p.recording == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recording.Interactions = append(p.recording.Interactions, *i)
+	data, err := json.MarshalIndent(p.recording, "", "  ")
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(p.recordPath), 0o755); err == nil {
+			err = os.WriteFile(p.recordPath, data, 0o644)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write cassette: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestUpstream(t *testing.T) (*httptest.Server, *int) {
+	calls := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte{0xff, 0x00, 0xfe})
+		case "/redirect":
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Upstream", "yes")
+			w.WriteHeader(http.StatusOK)
+			_, _ = fmt.Fprintf(w, `{"method":%q,"path":%q,"query":%q,"body":%q,"hdr":%q}`, r.Method, r.URL.Path, r.URL.RawQuery, string(body), r.Header.Get("X-Test"))
+		}
+	}))
+	t.Cleanup(svr.Close)
+	return svr, &calls
+}
+
+func doTestCall(t *testing.T, svc MockedService, method string, path string, body string) (int, http.Header, []byte) {
+	req, err := http.NewRequest(method, svc.Url()+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("X-Test", "test-hdr")
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	data, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, res.Header, data
+}
+
+func TestMockedService_ProxyRecordReplay(t *testing.T) {
+	upstream, upstreamCalls := newTestUpstream(t)
+	dir := t.TempDir()
+
+	// record...
+	svc := NewMockedService("downstream", Proxy(upstream.URL+"/"), Record(dir))
+	require.NoError(t, svc.Start())
+	status, hdrs, body := doTestCall(t, svc, http.MethodGet, "/foos?a=1&b=2", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "yes", hdrs.Get("X-Upstream"))
+	assert.Equal(t, `{"method":"GET","path":"/foos","query":"a=1&b=2","body":"","hdr":"test-hdr"}`, string(body))
+	status, _, body = doTestCall(t, svc, http.MethodPost, "/foos", `{"foo":"bar","baz":1}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"method":"POST","path":"/foos","query":"","body":"{\"foo\":\"bar\",\"baz\":1}","hdr":"test-hdr"}`, string(body))
+	status, _, body = doTestCall(t, svc, http.MethodGet, "/binary", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []byte{0xff, 0x00, 0xfe}, body)
+	status, hdrs, _ = doTestCall(t, svc, http.MethodGet, "/redirect", "")
+	assert.Equal(t, http.StatusFound, status)
+	assert.Equal(t, "/elsewhere", hdrs.Get("Location"))
+	assert.True(t, svc.AssertCalled("/foos", http.MethodGet))
+	assert.True(t, svc.AssertCalled("/foos", http.MethodPost))
+	assert.False(t, svc.AssertCalled("/foos", http.MethodPut))
+	svc.Shutdown()
+	assert.Equal(t, 4, *upstreamCalls)
+
+	data, err := os.ReadFile(filepath.Join(dir, "downstream.json"))
+	require.NoError(t, err)
+	c := &cassette{}
+	require.NoError(t, json.Unmarshal(data, c))
+	assert.Equal(t, "downstream", c.Name)
+	require.Len(t, c.Interactions, 4)
+	assert.Equal(t, "a=1&b=2", c.Interactions[0].Request.Query)
+	assert.NotContains(t, c.Interactions[0].Response.Headers, "Date")
+	assert.NotContains(t, c.Interactions[0].Response.Headers, "Content-Length")
+	assert.Equal(t, "base64", c.Interactions[2].Response.BodyEncoding)
+
+	// replay...
+	svc = NewMockedService("downstream", ReplayFrom(os.DirFS(dir)))
+	require.NoError(t, svc.Start())
+	defer svc.Shutdown()
+	status, hdrs, body = doTestCall(t, svc, http.MethodGet, "/foos?b=2&a=1", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "yes", hdrs.Get("X-Upstream"))
+	assert.Equal(t, `{"method":"GET","path":"/foos","query":"a=1&b=2","body":"","hdr":"test-hdr"}`, string(body))
+	status, _, body = doTestCall(t, svc, http.MethodPost, "/foos", `{"baz":1,  "foo":"bar"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"method":"POST","path":"/foos","query":"","body":"{\"foo\":\"bar\",\"baz\":1}","hdr":"test-hdr"}`, string(body))
+	status, _, body = doTestCall(t, svc, http.MethodGet, "/binary", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []byte{0xff, 0x00, 0xfe}, body)
+	// the last matching is replayed again...
+	status, _, _ = doTestCall(t, svc, http.MethodGet, "/binary", "")
+	assert.Equal(t, http.StatusOK, status)
+	// not matched...
+	status, _, _ = doTestCall(t, svc, http.MethodPost, "/foos", `{"foo":"other"}`)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _, _ = doTestCall(t, svc, http.MethodGet, "/foos?a=2", "")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.True(t, svc.AssertCalled("/binary", http.MethodGet))
+	// mocked calls take precedence...
+	svc.MockCall("/binary", http.MethodGet, http.StatusTeapot, nil)
+	status, _, _ = doTestCall(t, svc, http.MethodGet, "/binary", "")
+	assert.Equal(t, http.StatusTeapot, status)
+	svc.Clear()
+	assert.False(t, svc.AssertCalled("/binary", http.MethodGet))
+	status, _, _ = doTestCall(t, svc, http.MethodGet, "/binary", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 4, *upstreamCalls)
+}
+
+func TestMockedService_ReplayInOrder(t *testing.T) {
+	fsys := fstest.MapFS{
+		"svc.json": {Data: []byte(`{"name":"svc","interactions":[
+{"request":{"method":"GET","path":"/status"},"response":{"status":202,"body":"pending"}},
+{"request":{"method":"GET","path":"/status"},"response":{"status":200,"body":"done"}}
+]}`)},
+	}
+	svc := NewMockedService("svc", ReplayFrom(fsys))
+	require.NoError(t, svc.Start())
+	defer svc.Shutdown()
+	status, _, body := doTestCall(t, svc, http.MethodGet, "/status", "")
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.Equal(t, "pending", string(body))
+	for i := 0; i < 2; i++ {
+		status, _, body = doTestCall(t, svc, http.MethodGet, "/status", "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "done", string(body))
+	}
+	svc.Clear()
+	status, _, _ = doTestCall(t, svc, http.MethodGet, "/status", "")
+	assert.Equal(t, http.StatusAccepted, status)
+}
+
+func TestMockedService_ReplayWithProxyFallback(t *testing.T) {
+	upstream, upstreamCalls := newTestUpstream(t)
+	dir := t.TempDir()
+	fsys := fstest.MapFS{
+		"svc.json": {Data: []byte(`{"name":"svc","interactions":[{"request":{"method":"GET","path":"/replayed"},"response":{"status":200,"body":"replayed"}}]}`)},
+	}
+	svc := NewMockedService("svc", ReplayFrom(fsys), Proxy(upstream.URL), Record(dir))
+	require.NoError(t, svc.Start())
+	defer svc.Shutdown()
+	status, _, body := doTestCall(t, svc, http.MethodGet, "/replayed", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "replayed", string(body))
+	assert.Equal(t, 0, *upstreamCalls)
+	status, _, _ = doTestCall(t, svc, http.MethodGet, "/proxied", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 1, *upstreamCalls)
+
+	data, err := os.ReadFile(filepath.Join(dir, "svc.json"))
+	require.NoError(t, err)
+	c := &cassette{}
+	require.NoError(t, json.Unmarshal(data, c))
+	require.Len(t, c.Interactions, 2)
+	assert.Equal(t, "/replayed", c.Interactions[0].Request.Path)
+	assert.Equal(t, "/proxied", c.Interactions[1].Request.Path)
+
+	// missing cassette is ok when proxying...
+	svc2 := NewMockedService("other", ReplayFrom(fsys), Proxy(upstream.URL))
+	require.NoError(t, svc2.Start())
+	svc2.Shutdown()
+}
+
+func TestMockedService_OptionErrors(t *testing.T) {
+	testCases := []struct {
+		options   []Option
+		expectErr string
+	}{
+		{
+			options:   []Option{ReplayFrom(fstest.MapFS{})},
+			expectErr: `mocked service: unable to read cassette "svc.json": open svc.json: file does not exist`,
+		},
+		{
+			options:   []Option{ReplayFrom(fstest.MapFS{"svc.json": {Data: []byte(`not json`)}})},
+			expectErr: `mocked service: unable to read cassette "svc.json": invalid character 'o' in literal null (expecting 'u')`,
+		},
+		{
+			options:   []Option{Record(t.TempDir())},
+			expectErr: `mocked service: record requires a proxy target`,
+		},
+		{
+			options:   []Option{Proxy("not a url")},
+			expectErr: `mocked service: invalid proxy target "not a url"`,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]", i+1), func(t *testing.T) {
+			svc := NewMockedService("svc", tc.options...)
+			err := svc.Start()
+			require.Error(t, err)
+			assert.Equal(t, tc.expectErr, err.Error())
+		})
+	}
+}
+
+func TestMockedService_ProxyErrors(t *testing.T) {
+	upstream, _ := newTestUpstream(t)
+	t.Run("upstream down", func(t *testing.T) {
+		down := httptest.NewServer(http.NotFoundHandler())
+		down.Close()
+		svc := NewMockedService("svc", Proxy(down.URL))
+		require.NoError(t, svc.Start())
+		defer svc.Shutdown()
+		status, _, body := doTestCall(t, svc, http.MethodGet, "/foos", "")
+		assert.Equal(t, http.StatusBadGateway, status)
+		assert.True(t, strings.HasPrefix(string(body), "mocked service: "))
+		assert.False(t, svc.AssertCalled("/foos", http.MethodGet))
+	})
+	t.Run("record fails", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(file, []byte{}, 0o644))
+		svc := NewMockedService("svc", Proxy(upstream.URL), Record(file))
+		require.NoError(t, svc.Start())
+		defer svc.Shutdown()
+		status, _, body := doTestCall(t, svc, http.MethodGet, "/foos", "")
+		assert.Equal(t, http.StatusInternalServerError, status)
+		assert.True(t, strings.HasPrefix(string(body), "mocked service: unable to write cassette: "))
+	})
+}
+
+func Test_bodiesMatch(t *testing.T) {
+	assert.True(t, bodiesMatch(nil, []byte{}))
+	assert.True(t, bodiesMatch([]byte(`abc`), []byte(`abc`)))
+	assert.False(t, bodiesMatch([]byte(`abc`), []byte(`abd`)))
+	assert.True(t, bodiesMatch([]byte(`{"a":1,"b":[1,2]}`), []byte(`{ "b":[1,2], "a":1.0 }`)))
+	assert.False(t, bodiesMatch([]byte(`{"a":1,"b":[1,2]}`), []byte(`{"a":1,"b":[2,1]}`)))
+}
+
+func Test_queriesMatch(t *testing.T) {
+	assert.True(t, queriesMatch("", ""))
+	assert.True(t, queriesMatch("a=1&b=2", "b=2&a=1"))
+	assert.False(t, queriesMatch("a=1&a=2", "a=2&a=1"))
+	assert.False(t, queriesMatch("a=1", "a=1&b=2"))
+	assert.False(t, queriesMatch("a=%zz", "a=1"))
+}
+
+func Test_recordedBody(t *testing.T) {
+	b := newRecordedBody([]byte("text"))
+	assert.Equal(t, "", b.BodyEncoding)
+	assert.Equal(t, []byte("text"), b.bytes())
+	b = newRecordedBody([]byte{0xff, 0x00})
+	assert.Equal(t, "base64", b.BodyEncoding)
+	assert.True(t, bytes.Equal([]byte{0xff, 0x00}, b.bytes()))
+}
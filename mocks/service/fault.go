@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Fault is a fault injected into the responses of a mocked service (see MockedService.MockFault)
+//
+// faults are used to test the resilience of the API under test (e.g. retries, circuit breakers and fallbacks) - the
+// fault fields can be combined (e.g. Latency with Status) - but ConnectionReset, Timeout and Status take precedence
+// (in that order) over any response that would otherwise have been served
+type Fault struct {
+	// Latency is the fixed delay before responding
+	Latency time.Duration
+	// Jitter is the maximum random delay added to the Latency
+	Jitter time.Duration
+	// ConnectionReset, when true, resets the connection (without any response)
+	ConnectionReset bool
+	// Timeout, when true, never responds - the connection is closed when the caller gives up (or the mocked service is shut down)
+	Timeout bool
+	// Status, when non-zero, responds with this status (and the Body) instead of the mocked response (e.g. a 503 Service Unavailable)
+	Status int
+	// Body is the response body used with Status
+	Body any
+	// TruncateBody, when greater than zero, truncates the response body after this many bytes - whilst declaring the full "Content-Length"
+	TruncateBody int
+	// ChunkSize, when greater than zero, streams the response body in chunks of this many bytes (with ChunkDelay between each chunk)
+	ChunkSize int
+	// ChunkDelay is the delay between each streamed chunk (see ChunkSize)
+	ChunkDelay time.Duration
+	// Probability is the probability (0.0 to 1.0) that the fault is injected into any call - zero (or 1.0) means always injected
+	Probability float64
+	// Count is the number of calls the fault is injected into (e.g. a burst of 5xx responses) - zero means unlimited
+	Count int
+}
+
+type mockedFault struct {
+	Fault
+	injected int
+}
+
+func faultKey(path string, method string) string {
+	return method + " " + path
+}
+
+func (m *mockedService) MockFault(path string, method string, fault Fault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults[faultKey(path, method)] = &mockedFault{Fault: fault}
+}
+
+// injectFault determines the fault (if any) to inject into the call - matching on method & path, method only,
+// path only and then any method & path
+func (m *mockedService) injectFault(r *http.Request) *Fault {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range []string{faultKey(r.URL.Path, r.Method), faultKey("", r.Method), faultKey(r.URL.Path, ""), faultKey("", "")} {
+		if f, ok := m.faults[k]; ok {
+			if f.Count > 0 && f.injected >= f.Count {
+				continue
+			}
+			if f.Probability > 0 && f.Probability < 1 && rand.Float64() >= f.Probability {
+				return nil
+			}
+			f.injected++
+			return &f.Fault
+		}
+	}
+	return nil
+}
+
+func (m *mockedService) serveFault(w http.ResponseWriter, r *http.Request, body []byte, f *Fault) {
+	if !f.delay(r.Context()) {
+		return
+	}
+	switch {
+	case f.ConnectionReset:
+		m.markServed(r)
+		resetConnection(w)
+	case f.Timeout:
+		m.markServed(r)
+		select {
+		case <-r.Context().Done():
+		case <-m.done:
+		}
+		resetConnection(w)
+	case f.Status != 0:
+		m.markServed(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.Status)
+		_, _ = w.Write(bodyToBytes(f.Body))
+	case f.TruncateBody > 0 || f.ChunkSize > 0:
+		rb := &responseBuffer{header: http.Header{}}
+		m.serve(rb, r, body)
+		rb.writeTo(r.Context(), w, f)
+	default:
+		m.serve(w, r, body)
+	}
+}
+
+// delay waits for the fault latency (plus jitter) - returning false if the caller gave up waiting
+func (f *Fault) delay(ctx context.Context) bool {
+	d := f.Latency
+	if f.Jitter > 0 {
+		d += rand.N(f.Jitter)
+	}
+	return sleep(ctx, d)
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// resetConnection closes the underlying connection without a response (with a TCP RST where possible)
+func resetConnection(w http.ResponseWriter) {
+	if hj, ok := w.(http.Hijacker); ok {
+		if conn, _, err := hj.Hijack(); err == nil {
+			if tc, ok := conn.(*net.TCPConn); ok {
+				_ = tc.SetLinger(0)
+			}
+			_ = conn.Close()
+			return
+		}
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// responseBuffer buffers a response - so that the body can be truncated or streamed
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+var _ http.ResponseWriter = (*responseBuffer)(nil)
+
+func (rb *responseBuffer) Header() http.Header {
+	return rb.header
+}
+
+func (rb *responseBuffer) Write(data []byte) (int, error) {
+	if rb.status == 0 {
+		rb.status = http.StatusOK
+	}
+	return rb.body.Write(data)
+}
+
+func (rb *responseBuffer) WriteHeader(status int) {
+	if rb.status == 0 {
+		rb.status = status
+	}
+}
+
+func (rb *responseBuffer) writeTo(ctx context.Context, w http.ResponseWriter, f *Fault) {
+	for k, vs := range rb.header {
+		w.Header()[k] = vs
+	}
+	data := rb.body.Bytes()
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if rb.status == 0 {
+		rb.status = http.StatusOK
+	}
+	w.WriteHeader(rb.status)
+	if f.TruncateBody > 0 && f.TruncateBody < len(data) {
+		data = data[:f.TruncateBody]
+	}
+	if f.ChunkSize <= 0 {
+		_, _ = w.Write(data)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	for len(data) > 0 {
+		n := min(f.ChunkSize, len(data))
+		if _, err := w.Write(data[:n]); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if data = data[n:]; len(data) > 0 && !sleep(ctx, f.ChunkDelay) {
+			return
+		}
+	}
+}
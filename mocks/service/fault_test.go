@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startFaultService(t *testing.T) MockedService {
+	svc := NewMockedService("foo-service")
+	require.NoError(t, svc.Start())
+	t.Cleanup(svc.Shutdown)
+	return svc
+}
+
+func doFaultCall(svc MockedService, method string, path string, timeout time.Duration) (*http.Response, []byte, error) {
+	client := &http.Client{Timeout: timeout, Transport: &http.Transport{DisableKeepAlives: true}}
+	req, err := http.NewRequest(method, svc.Url()+path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	body, err := io.ReadAll(res.Body)
+	return res, body, err
+}
+
+func TestMockedService_MockFault_Latency(t *testing.T) {
+	svc := startFaultService(t)
+	svc.MockCall("/foos", http.MethodGet, http.StatusOK, `{"foo":"bar"}`)
+	svc.MockFault("/foos", http.MethodGet, Fault{Latency: 50 * time.Millisecond, Jitter: 10 * time.Millisecond})
+	start := time.Now()
+	res, body, err := doFaultCall(svc, http.MethodGet, "/foos", time.Second)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `{"foo":"bar"}`, string(body))
+
+	_, _, err = doFaultCall(svc, http.MethodGet, "/foos", 20*time.Millisecond)
+	require.Error(t, err)
+}
+
+func TestMockedService_MockFault_ConnectionReset(t *testing.T) {
+	svc := startFaultService(t)
+	svc.MockCall("/foos", http.MethodGet, http.StatusOK, nil)
+	svc.MockFault("/foos", "", Fault{ConnectionReset: true})
+	_, _, err := doFaultCall(svc, http.MethodGet, "/foos", time.Second)
+	require.Error(t, err)
+	assert.True(t, svc.AssertCalled("/foos", http.MethodGet))
+}
+
+func TestMockedService_MockFault_Timeout(t *testing.T) {
+	svc := startFaultService(t)
+	svc.MockFault("", "", Fault{Timeout: true})
+	start := time.Now()
+	_, _, err := doFaultCall(svc, http.MethodPost, "/anything", 50*time.Millisecond)
+	require.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// shutdown releases hung calls...
+	errs := make(chan error, 1)
+	go func() {
+		_, _, err := doFaultCall(svc, http.MethodGet, "/foos", 5*time.Second)
+		errs <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	start = time.Now()
+	svc.Shutdown()
+	svc.Shutdown()
+	require.Error(t, <-errs)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestMockedService_MockFault_StatusBurst(t *testing.T) {
+	svc := startFaultService(t)
+	svc.MockCall("/foos", http.MethodGet, http.StatusOK, `{"foo":"bar"}`)
+	svc.MockFault("/foos", http.MethodGet, Fault{Status: http.StatusServiceUnavailable, Body: map[string]any{"error": "unavailable"}, Count: 2})
+	for i := 0; i < 2; i++ {
+		res, body, err := doFaultCall(svc, http.MethodGet, "/foos", time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, `{"error":"unavailable"}`, string(body))
+	}
+	res, body, err := doFaultCall(svc, http.MethodGet, "/foos", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `{"foo":"bar"}`, string(body))
+}
+
+func TestMockedService_MockFault_TruncateBody(t *testing.T) {
+	svc := startFaultService(t)
+	svc.MockCall("/foos", http.MethodGet, http.StatusOK, `{"foo":"bar"}`)
+	svc.MockFault("/foos", http.MethodGet, Fault{TruncateBody: 5})
+	res, body, err := doFaultCall(svc, http.MethodGet, "/foos", time.Second)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, int64(13), res.ContentLength)
+	assert.Equal(t, `{"foo`, string(body))
+}
+
+func TestMockedService_MockFault_SlowStreaming(t *testing.T) {
+	svc := startFaultService(t)
+	svc.MockCall("/foos", http.MethodGet, http.StatusCreated, `{"foo":"bar"}`)
+	svc.MockFault("/foos", http.MethodGet, Fault{ChunkSize: 5, ChunkDelay: 20 * time.Millisecond})
+	start := time.Now()
+	res, body, err := doFaultCall(svc, http.MethodGet, "/foos", time.Second)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.Equal(t, `{"foo":"bar"}`, string(body))
+}
+
+func TestMockedService_MockFault_Probability(t *testing.T) {
+	svc := startFaultService(t)
+	svc.MockFault("/foos", http.MethodGet, Fault{Status: http.StatusInternalServerError, Probability: 0.5})
+	statuses := map[int]int{}
+	for i := 0; i < 100; i++ {
+		res, _, err := doFaultCall(svc, http.MethodGet, "/foos", time.Second)
+		require.NoError(t, err)
+		statuses[res.StatusCode]++
+	}
+	assert.Greater(t, statuses[http.StatusInternalServerError], 0)
+	assert.Greater(t, statuses[http.StatusNotFound], 0)
+}
+
+func TestMockedService_MockFault_MatchingAndClear(t *testing.T) {
+	svc := startFaultService(t)
+	svc.MockFault("", http.MethodPost, Fault{Status: http.StatusBadGateway})
+	svc.MockFault("/foos", "", Fault{Status: http.StatusGatewayTimeout})
+	svc.MockFault("/foos", http.MethodPost, Fault{Status: http.StatusTooManyRequests})
+	for _, tc := range []struct {
+		method string
+		path   string
+		expect int
+	}{
+		{http.MethodPost, "/foos", http.StatusTooManyRequests},
+		{http.MethodPost, "/bars", http.StatusBadGateway},
+		{http.MethodGet, "/foos", http.StatusGatewayTimeout},
+		{http.MethodGet, "/bars", http.StatusNotFound},
+	} {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			res, _, err := doFaultCall(svc, tc.method, tc.path, time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, res.StatusCode)
+		})
+	}
+	svc.Clear()
+	res, _, err := doFaultCall(svc, http.MethodPost, "/foos", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestResponseBuffer(t *testing.T) {
+	rb := &responseBuffer{header: http.Header{}}
+	_, _ = rb.Write([]byte("abc"))
+	rb.WriteHeader(http.StatusTeapot)
+	assert.Equal(t, http.StatusOK, rb.status)
+	assert.Equal(t, "abc", rb.body.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, sleep(ctx, time.Second))
+	assert.True(t, sleep(ctx, 0))
+	f := &Fault{}
+	assert.True(t, f.delay(ctx))
+	assert.True(t, strings.HasPrefix(faultKey("/foos", "GET"), "GET "))
+}
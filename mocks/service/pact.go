@@ -0,0 +1,155 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-andiamo/marrow/common"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Pact sets the mocked service to generate a Pact (v3) contract file from the mocked calls that were actually hit
+//
+// the mocked service is the provider and the consumer is the name of the API under test - the pact file is
+// named "<consumer>-<service name>.json" in the dir and is written as each mocked call is hit
+//
+// each interaction is the request the API under test sent (method, path, query, headers & body) and the
+// mocked response (status, headers & body) - identical interactions are only included once
+//
+// credential and tracing request headers (e.g. "Authorization", "Cookie", "X-Api-Key", "Traceparent" and "X-Request-Id")
+// are never written to the pact file - further headers can be excluded using PactExcludeHeaders
+//
+// the generated pact file can then be verified against the real service (see marrow.PactEndpoints)
+func Pact(consumer string, dir string) Option {
+	return func(m *mockedService) {
+		m.pact = &pactWriter{
+			path: filepath.Join(dir, consumer+"-"+m.name+".json"),
+			pact: common.Pact{
+				Consumer:     common.PactParticipant{Name: consumer},
+				Provider:     common.PactParticipant{Name: m.name},
+				Interactions: make([]common.PactInteraction, 0),
+				Metadata: map[string]any{
+					"pactSpecification": map[string]any{"version": common.PactSpecificationVersion},
+				},
+			},
+		}
+	}
+}
+
+// PactExcludeHeaders sets additional headers (request and response) that are not written to the pact file (see Pact)
+func PactExcludeHeaders(headers ...string) Option {
+	return func(m *mockedService) {
+		m.pactExclude = append(m.pactExclude, headers...)
+	}
+}
+
+type pactWriter struct {
+	path    string
+	pact    common.Pact
+	exclude map[string]struct{}
+	mu      sync.Mutex
+}
+
+// pactIgnoreHeaders are request headers that are not included in pact interactions (in addition to hopHeaders) - i.e.
+// transport noise, credentials (which must not be written into contracts) and per-request tracing/correlation ids
+// (which would defeat identical interaction de-duplication and be replayed as stale values)
+var pactIgnoreHeaders = map[string]struct{}{
+	"User-Agent":        {},
+	"Accept-Encoding":   {},
+	"Authorization":     {},
+	"Cookie":            {},
+	"X-Api-Key":         {},
+	"Traceparent":       {},
+	"Tracestate":        {},
+	"Baggage":           {},
+	"X-Request-Id":      {},
+	"X-Correlation-Id":  {},
+	"X-Amzn-Trace-Id":   {},
+	"X-B3-Traceid":      {},
+	"X-B3-Spanid":       {},
+	"X-B3-Parentspanid": {},
+	"X-B3-Sampled":      {},
+	"B3":                {},
+}
+
+func (p *pactWriter) init(exclude []string) {
+	p.exclude = make(map[string]struct{}, len(exclude))
+	for _, h := range exclude {
+		p.exclude[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+}
+
+// add adds the interaction (if generating a pact) and writes the pact file
+func (p *pactWriter) add(r *http.Request, reqBody []byte, status int, resHeaders http.Header, resBody []byte) error {
+	if p == nil {
+		return nil
+	}
+	i := common.PactInteraction{
+		Description: r.Method + " " + r.URL.Path,
+		Request: common.PactRequest{
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Headers: p.headers(r.Header, true),
+			Body:    common.PactBody(reqBody),
+		},
+		Response: common.PactResponse{
+			Status:  status,
+			Headers: p.headers(resHeaders, false),
+			Body:    common.PactBody(resBody),
+		},
+	}
+	if q := r.URL.Query(); len(q) > 0 {
+		i.Request.Query = common.PactQuery(q)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 1
+	for _, existing := range p.pact.Interactions {
+		if reflect.DeepEqual(existing.Request, i.Request) && reflect.DeepEqual(existing.Response, i.Response) {
+			return nil
+		}
+		if existing.Request.Method == i.Request.Method && existing.Request.Path == i.Request.Path {
+			n++
+		}
+	}
+	if n > 1 {
+		i.Description += " #" + strconv.Itoa(n)
+	}
+	p.pact.Interactions = append(p.pact.Interactions, i)
+	data, err := json.MarshalIndent(p.pact, "", "  ")
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(p.path), 0o755); err == nil {
+			err = os.WriteFile(p.path, data, 0o644)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write pact: %w", err)
+	}
+	return nil
+}
+
+func (p *pactWriter) headers(hdrs http.Header, request bool) map[string]string {
+	result := make(map[string]string, len(hdrs))
+	for k, vs := range hdrs {
+		ck := http.CanonicalHeaderKey(k)
+		if _, hop := hopHeaders[ck]; hop {
+			continue
+		}
+		if _, ignore := pactIgnoreHeaders[ck]; ignore && request {
+			continue
+		}
+		if _, exclude := p.exclude[ck]; exclude {
+			continue
+		}
+		result[ck] = strings.Join(vs, ", ")
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
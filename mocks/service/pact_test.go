@@ -0,0 +1,134 @@
+package service
+
+import (
+	"encoding/json"
+	"github.com/go-andiamo/marrow/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMockedService_Pact(t *testing.T) {
+	dir := t.TempDir()
+	svc := NewMockedService("foo-service", Pact("my-api", dir))
+	err := svc.Start()
+	require.NoError(t, err)
+	defer svc.Shutdown()
+	svc.MockCall("/foos", http.MethodGet, http.StatusOK, []map[string]any{{"foo": "bar"}})
+	svc.MockCall("/foos", http.MethodGet, http.StatusOK, []map[string]any{{"foo": "bar"}})
+	svc.MockCall("/foos", http.MethodGet, http.StatusOK, []map[string]any{})
+	svc.MockCall("/foos", http.MethodPost, http.StatusCreated, "created", "Content-Type", "text/plain")
+	svc.MockCall("/unused", http.MethodGet, http.StatusOK, nil)
+
+	path := filepath.Join(dir, "my-api-foo-service.json")
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+
+	status, _, _ := doTestCall(t, svc, http.MethodGet, "/foos?a=1&a=2", "")
+	assert.Equal(t, http.StatusOK, status)
+	status, _, _ = doTestCall(t, svc, http.MethodGet, "/foos?a=1&a=2", "")
+	assert.Equal(t, http.StatusOK, status)
+	status, _, _ = doTestCall(t, svc, http.MethodGet, "/foos", "")
+	assert.Equal(t, http.StatusOK, status)
+	status, _, body := doTestCall(t, svc, http.MethodPost, "/foos", "not json")
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "created", string(body))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	pact := common.Pact{}
+	require.NoError(t, json.Unmarshal(data, &pact))
+	assert.Equal(t, "my-api", pact.Consumer.Name)
+	assert.Equal(t, "foo-service", pact.Provider.Name)
+	assert.Equal(t, map[string]any{"version": "3.0.0"}, pact.Metadata["pactSpecification"])
+	require.Len(t, pact.Interactions, 3)
+
+	i := pact.Interactions[0]
+	assert.Equal(t, "GET /foos", i.Description)
+	assert.Equal(t, http.MethodGet, i.Request.Method)
+	assert.Equal(t, "/foos", i.Request.Path)
+	assert.Equal(t, common.PactQuery{"a": {"1", "2"}}, i.Request.Query)
+	assert.Equal(t, map[string]string{"X-Test": "test-hdr"}, i.Request.Headers)
+	assert.Empty(t, i.Request.Body)
+	assert.Equal(t, http.StatusOK, i.Response.Status)
+	assert.Equal(t, map[string]string{"Content-Type": "application/json"}, i.Response.Headers)
+	assert.JSONEq(t, `[{"foo":"bar"}]`, string(i.Response.Body))
+
+	i = pact.Interactions[1]
+	assert.Equal(t, "GET /foos #2", i.Description)
+	assert.Nil(t, i.Request.Query)
+	assert.JSONEq(t, `[]`, string(i.Response.Body))
+
+	i = pact.Interactions[2]
+	assert.Equal(t, "POST /foos", i.Description)
+	assert.Equal(t, `"not json"`, string(i.Request.Body))
+	assert.Equal(t, http.StatusCreated, i.Response.Status)
+	assert.Equal(t, map[string]string{"Content-Type": "text/plain"}, i.Response.Headers)
+	assert.Equal(t, `"created"`, string(i.Response.Body))
+}
+
+func TestMockedService_Pact_WriteError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(dir, []byte{}, 0o644))
+	svc := NewMockedService("foo-service", Pact("my-api", dir))
+	err := svc.Start()
+	require.NoError(t, err)
+	defer svc.Shutdown()
+	svc.MockCall("/foos", http.MethodGet, http.StatusOK, nil)
+
+	status, _, body := doTestCall(t, svc, http.MethodGet, "/foos", "")
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Contains(t, string(body), "mocked service: unable to write pact")
+	assert.True(t, svc.AssertCalled("/foos", http.MethodGet))
+}
+
+func TestPactWriter_Headers(t *testing.T) {
+	hdrs := http.Header{
+		"User-Agent":     {"go"},
+		"Content-Length": {"10"},
+		"Accept":         {"a", "b"},
+		"Authorization":  {"Bearer secret"},
+		"Cookie":         {"session=secret"},
+		"X-Api-Key":      {"secret"},
+		"Traceparent":    {"00-abc-def-01"},
+		"X-Request-Id":   {"123"},
+		"X-Tenant":       {"t1"},
+	}
+	p := &pactWriter{}
+	p.init(nil)
+	assert.Equal(t, map[string]string{"Accept": "a, b", "X-Tenant": "t1"}, p.headers(hdrs, true))
+	assert.Nil(t, p.headers(http.Header{}, true))
+	p.init([]string{"x-tenant"})
+	assert.Equal(t, map[string]string{"Accept": "a, b"}, p.headers(hdrs, true))
+	assert.Equal(t, map[string]string{"Content-Type": "application/json"}, p.headers(http.Header{"Content-Type": {"application/json"}, "X-Tenant": {"t1"}}, false))
+}
+
+func TestMockedService_Pact_ExcludesCredentials(t *testing.T) {
+	dir := t.TempDir()
+	svc := NewMockedService("foo-service", Pact("my-api", dir), PactExcludeHeaders("X-Tenant"))
+	require.NoError(t, svc.Start())
+	defer svc.Shutdown()
+	svc.MockCall("/foos", http.MethodGet, http.StatusOK, nil)
+	svc.MockCall("/foos", http.MethodGet, http.StatusOK, nil)
+	for _, reqId := range []string{"1", "2"} {
+		req, err := http.NewRequest(http.MethodGet, svc.Url()+"/foos", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Request-Id", reqId)
+		req.Header.Set("X-Tenant", "t1")
+		req.Header.Set("Accept", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "my-api-foo-service.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	pact := common.Pact{}
+	require.NoError(t, json.Unmarshal(data, &pact))
+	require.Len(t, pact.Interactions, 1)
+	assert.Equal(t, map[string]string{"Accept": "application/json"}, pact.Interactions[0].Request.Headers)
+}
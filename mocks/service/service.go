@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/go-andiamo/marrow/internal/netutil"
+	"io"
 	"net"
 	"net/http"
 	"strconv"
@@ -22,26 +24,51 @@ type MockedService interface {
 	Clear()
 	MockCall(path string, method string, responseStatus int, responseBody any, headers ...string)
 	AssertCalled(path string, method string) bool
-}
-
-func NewMockedService(name string) MockedService {
-	return &mockedService{
+	// MockFault sets a fault to be injected into calls to the path & method (an empty path or method matches any)
+	//
+	// only one fault is set for each path & method - setting another replaces it (Clear removes all faults)
+	MockFault(path string, method string, fault Fault)
+}
+
+// NewMockedService creates a new mocked service
+//
+// by default, the mocked service only responds to mocked calls (see MockedService.MockCall) - options
+// can be used to proxy, record and replay calls (see Proxy, Record and ReplayFrom) - and to generate
+// a Pact contract from the mocked calls that were hit (see Pact)
+func NewMockedService(name string, options ...Option) MockedService {
+	result := &mockedService{
 		name:       name,
 		host:       "localhost",
-		actualHost: localIP(),
+		actualHost: netutil.LocalIP(),
 		endpoints:  make(map[string]*mockedEndpoint),
+		served:     make(map[string]int),
+		faults:     make(map[string]*mockedFault),
+	}
+	for _, o := range options {
+		if o != nil {
+			o(result)
+		}
 	}
+	return result
 }
 
 type mockedService struct {
-	name       string
-	host       string
-	actualHost string
-	port       int
-	server     *http.Server
-	listener   net.Listener
-	mu         sync.RWMutex
-	endpoints  map[string]*mockedEndpoint
+	name        string
+	host        string
+	actualHost  string
+	port        int
+	server      *http.Server
+	listener    net.Listener
+	mu          sync.RWMutex
+	endpoints   map[string]*mockedEndpoint
+	served      map[string]int
+	proxy       *proxying
+	record      string
+	replay      *replaying
+	pact        *pactWriter
+	pactExclude []string
+	faults      map[string]*mockedFault
+	done        chan struct{}
 }
 
 var _ MockedService = &mockedService{}
@@ -82,13 +109,18 @@ func (m *mockedService) Start() (err error) {
 			err = fmt.Errorf("mocked service: %w", err)
 		}
 	}()
+	if err = m.initOptions(); err != nil {
+		return
+	}
+	m.done = make(chan struct{})
 	// listen on "127.0.0.1:0" (i.e. port 0) tells the OS to pick an unused port
 	if m.listener, err = net.Listen("tcp", "127.0.0.1:0"); err == nil {
 		addr := m.listener.Addr().(*net.TCPAddr)
 		m.port = addr.Port
-		m.server = &http.Server{Handler: m}
+		svr, l := &http.Server{Handler: m}, m.listener
+		m.server = svr
 		go func() {
-			_ = m.server.Serve(m.listener)
+			_ = svr.Serve(l)
 		}()
 	}
 	return
@@ -96,9 +128,11 @@ func (m *mockedService) Start() (err error) {
 
 func (m *mockedService) Shutdown() {
 	if m.server != nil {
+		close(m.done)
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		_ = m.server.Shutdown(ctx)
+		m.server = nil
 	}
 }
 
@@ -106,6 +140,11 @@ func (m *mockedService) Clear() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.endpoints = make(map[string]*mockedEndpoint)
+	m.served = make(map[string]int)
+	m.faults = make(map[string]*mockedFault)
+	if m.replay != nil {
+		m.replay.reset()
+	}
 }
 
 func (m *mockedService) MockCall(path string, method string, responseStatus int, responseBody any, headers ...string) {
@@ -154,10 +193,32 @@ func (m *mockedService) AssertCalled(path string, method string) bool {
 	if ep, ok := m.endpoints[method+" "+path]; ok && ep.calls > 0 {
 		return true
 	}
-	return false
+	return m.served[method+" "+path] > 0
 }
 
+// ServeHTTP serves mocked calls - falling back to replayed and then proxied calls (where configured)
+//
+// any fault (see MockFault) is injected into the call
 func (m *mockedService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f := m.injectFault(r); f != nil {
+		m.serveFault(w, r, body, f)
+	} else {
+		m.serve(w, r, body)
+	}
+}
+
+func (m *mockedService) serve(w http.ResponseWriter, r *http.Request, body []byte) {
+	if !m.serveMocked(w, r, body) {
+		m.serveRecorded(w, r, body)
+	}
+}
+
+func (m *mockedService) serveMocked(w http.ResponseWriter, r *http.Request, body []byte) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if ep, ok := m.endpoints[r.Method+" "+r.URL.Path]; ok && ep.calls < len(ep.statuses) {
@@ -172,25 +233,13 @@ func (m *mockedService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		if !seenContentType {
 			w.Header().Add("Content-Type", "application/json")
 		}
+		if err := m.pact.add(r, body, ep.statuses[n], w.Header(), ep.bodies[n]); err != nil {
+			http.Error(w, "mocked service: "+err.Error(), http.StatusInternalServerError)
+			return true
+		}
 		w.WriteHeader(ep.statuses[n])
 		_, _ = w.Write(ep.bodies[n])
-	} else {
-		w.WriteHeader(http.StatusNotFound)
-	}
-}
-
-func localIP() (result string) {
-	result = "127.0.0.1"
-	if addrs, err := net.InterfaceAddrs(); err == nil {
-		for _, addr := range addrs {
-			// check if the address is an IP address and not a loopback...
-			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
-				if ipNet.IP.To4() != nil { // ensure it's an IPv4 address
-					result = ipNet.IP.String()
-					break
-				}
-			}
-		}
+		return true
 	}
-	return result
+	return false
 }
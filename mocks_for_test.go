@@ -2,7 +2,9 @@ package marrow
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/tls"
+	"database/sql"
 	"github.com/go-andiamo/marrow/framing"
 	"github.com/go-andiamo/marrow/mocks/service"
 	"github.com/go-andiamo/marrow/with"
@@ -117,6 +119,48 @@ func (m *mockImage) StartupInit(ctx Context) error {
 	return m.initErr
 }
 
+type mockSnapshotsImage struct {
+	mockImage
+	db        *sql.DB
+	err       error
+	snapshots []string
+	restores  []string
+}
+
+var _ with.ImageDatabaseSnapshots = (*mockSnapshotsImage)(nil)
+
+func (m *mockSnapshotsImage) Database() *sql.DB {
+	return m.db
+}
+
+func (m *mockSnapshotsImage) TakeSnapshot(snapshotName string) error {
+	m.snapshots = append(m.snapshots, snapshotName)
+	return m.err
+}
+
+func (m *mockSnapshotsImage) RestoreSnapshot(snapshotName string) error {
+	m.restores = append(m.restores, snapshotName)
+	return m.err
+}
+
+type mockJwtIssuerImage struct {
+	mockImage
+	keys map[string]crypto.Signer
+}
+
+var _ with.ImageJwtIssuer = (*mockJwtIssuerImage)(nil)
+
+func (m *mockJwtIssuerImage) Issuer() string {
+	return "http://localhost:8080"
+}
+
+func (m *mockJwtIssuerImage) SigningKey(alg string) (kid string, key crypto.Signer, ok bool) {
+	if key, ok = m.keys[alg]; ok {
+		kid = "kid-" + alg
+	}
+	return
+}
+
 type mockApiImage struct {
 	mockImage
 }
@@ -189,6 +233,10 @@ func (m *mockService) AssertCalled(path string, method string) bool {
 	return false
 }
 
+func (m *mockService) MockFault(path string, method string, fault service.Fault) {
+	// mock does nothing
+}
+
 type nullWriter struct{}
 
 var _ io.Writer = (*nullWriter)(nil)
@@ -252,6 +300,7 @@ type mockMockedService struct {
 	called  bool
 	cleared bool
 	mocked  bool
+	faulted bool
 }
 
 var _ service.MockedService = (*mockMockedService)(nil)
@@ -297,6 +346,10 @@ func (m *mockMockedService) AssertCalled(path string, method string) bool {
 	return m.called
 }
 
+func (m *mockMockedService) MockFault(path string, method string, fault service.Fault) {
+	m.faulted = true
+}
+
 type mockListener struct {
 	calls []string
 }
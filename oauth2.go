@@ -0,0 +1,284 @@
+package marrow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-andiamo/marrow/framing"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth2Grant is the grant used by OAuth2Token to acquire an access token
+//
+// see ClientCredentialsGrant and PasswordGrant
+type OAuth2Grant interface {
+	grantValues(ctx Context) (url.Values, string, error)
+}
+
+// ClientCredentialsGrant is the OAuth2 client credentials grant (for use with OAuth2Token)
+func ClientCredentialsGrant() OAuth2Grant {
+	return clientCredentialsGrant{}
+}
+
+// PasswordGrant is the OAuth2 resource owner password credentials grant (for use with OAuth2Token)
+//
+// the username and password can be any value or resolvable (e.g. Var)
+func PasswordGrant(username any, password any) OAuth2Grant {
+	return passwordGrant{
+		username: username,
+		password: password,
+	}
+}
+
+type clientCredentialsGrant struct{}
+
+func (g clientCredentialsGrant) grantValues(ctx Context) (url.Values, string, error) {
+	return url.Values{"grant_type": {"client_credentials"}}, "", nil
+}
+
+type passwordGrant struct {
+	username any
+	password any
+}
+
+func (g passwordGrant) grantValues(ctx Context) (values url.Values, key string, err error) {
+	var uv, pv any
+	if uv, pv, err = ResolveValues(g.username, g.password, ctx); err == nil {
+		username := fmt.Sprintf("%v", uv)
+		values = url.Values{
+			"grant_type": {"password"},
+			"username":   {username},
+			"password":   {fmt.Sprintf("%v", pv)},
+		}
+		key = username
+	}
+	return values, key, err
+}
+
+// OAuth2TokenValue is the resolvable returned by OAuth2Token
+type OAuth2TokenValue interface {
+	Resolvable
+	// Invalidate discards the cached access token - so that the next resolve acquires a new access token
+	Invalidate(ctx Context) error
+}
+
+// OAuth2Token creates a resolvable value that acquires an OAuth2 access token from a token endpoint
+//
+// the tokenUrl, clientId & clientSecret can be any value or resolvable (e.g. Var or TemplateString - such as
+// TemplateString("{$svc:oidc:token}") when using with.OIDCIssuer)
+//
+// if the grant is nil, ClientCredentialsGrant is used
+//
+// the acquired access token is cached for the suite run and is refreshed (using the refresh token, if provided) when it expires
+//
+// this returns a resolvable value that can be used with Auth - which can then be used by Method_.AuthHeader
+// example:
+//
+//	Method(GET, "do get").
+//	    AuthHeader(BearerAuth, OAuth2Token(
+//	        TemplateString("{$svc:oidc:token}"),
+//	        "my-client", "my-secret",
+//	        []string{"read"}, ClientCredentialsGrant()))
+//
+// or used with Method_.AuthorizeOAuth2 (which also retries the request, with a newly acquired token, on a 401 Unauthorized response)
+func OAuth2Token(tokenUrl any, clientId any, clientSecret any, scopes []string, grant OAuth2Grant) OAuth2TokenValue {
+	if grant == nil {
+		grant = ClientCredentialsGrant()
+	}
+	return &oauth2Token{
+		tokenUrl:     tokenUrl,
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+		grant:        grant,
+	}
+}
+
+type oauth2Token struct {
+	tokenUrl     any
+	clientId     any
+	clientSecret any
+	scopes       []string
+	grant        OAuth2Grant
+}
+
+var _ OAuth2TokenValue = (*oauth2Token)(nil)
+
+type oauth2TokenRequest struct {
+	tokenUrl     string
+	clientId     string
+	clientSecret string
+	values       url.Values
+	key          string
+}
+
+func (t *oauth2Token) ResolveValue(ctx Context) (av any, err error) {
+	var req *oauth2TokenRequest
+	if req, err = t.request(ctx); err == nil {
+		cache := ctx.oauth2Tokens()
+		cached := cache.get(req.key)
+		if cached != nil && !cached.expired() {
+			return cached.AccessToken, nil
+		}
+		values := req.values
+		if cached != nil && cached.RefreshToken != "" {
+			values = url.Values{
+				"grant_type":    {"refresh_token"},
+				"refresh_token": {cached.RefreshToken},
+			}
+		}
+		var token *oauth2CachedToken
+		if token, err = fetchOAuth2Token(ctx, req, values); err != nil && cached != nil && cached.RefreshToken != "" {
+			// refresh failed - fallback to acquiring a new token...
+			token, err = fetchOAuth2Token(ctx, req, req.values)
+		}
+		if err == nil {
+			cache.put(req.key, token)
+			av = token.AccessToken
+		}
+	}
+	return av, err
+}
+
+func (t *oauth2Token) Invalidate(ctx Context) (err error) {
+	var req *oauth2TokenRequest
+	if req, err = t.request(ctx); err == nil {
+		ctx.oauth2Tokens().put(req.key, nil)
+	}
+	return err
+}
+
+func (t *oauth2Token) String() string {
+	return fmt.Sprintf("OAuth2Token(%v, %v)", t.tokenUrl, t.clientId)
+}
+
+func (t *oauth2Token) request(ctx Context) (req *oauth2TokenRequest, err error) {
+	var uv, cv, sv any
+	if uv, cv, err = ResolveValues(t.tokenUrl, t.clientId, ctx); err == nil {
+		if sv, err = ResolveValue(t.clientSecret, ctx); err == nil {
+			var values url.Values
+			var grantKey string
+			if values, grantKey, err = t.grant.grantValues(ctx); err == nil {
+				if len(t.scopes) > 0 {
+					values.Set("scope", strings.Join(t.scopes, " "))
+				}
+				req = &oauth2TokenRequest{
+					tokenUrl:     fmt.Sprintf("%v", uv),
+					clientId:     fmt.Sprintf("%v", cv),
+					clientSecret: fmt.Sprintf("%v", sv),
+					values:       values,
+				}
+				req.key = strings.Join([]string{req.tokenUrl, req.clientId, values.Get("grant_type"), grantKey, values.Get("scope")}, "\x00")
+			}
+		}
+	}
+	return req, err
+}
+
+func fetchOAuth2Token(ctx Context, req *oauth2TokenRequest, values url.Values) (token *oauth2CachedToken, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("oauth2 token: %w", err)
+		}
+	}()
+	var hr *http.Request
+	if hr, err = http.NewRequestWithContext(ctx.Ctx(), http.MethodPost, req.tokenUrl, strings.NewReader(values.Encode())); err == nil {
+		hr.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		hr.Header.Set("Accept", "application/json")
+		hr.SetBasicAuth(url.QueryEscape(req.clientId), url.QueryEscape(req.clientSecret))
+		var res *http.Response
+		if res, err = ctx.DoRequest(hr); err == nil {
+			defer func() {
+				_ = res.Body.Close()
+			}()
+			var data []byte
+			if data, err = io.ReadAll(res.Body); err == nil {
+				if res.StatusCode != http.StatusOK {
+					return nil, fmt.Errorf("unexpected status %d: %s", res.StatusCode, string(data))
+				}
+				token = &oauth2CachedToken{}
+				if err = json.Unmarshal(data, token); err == nil {
+					if token.AccessToken == "" {
+						return nil, errors.New("response did not contain access_token")
+					}
+					if token.ExpiresIn > 0 {
+						token.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+					}
+				}
+			}
+		}
+	}
+	return token, err
+}
+
+// oauth2ExpirySkew is the time before actual expiry that a cached token is treated as expired
+const oauth2ExpirySkew = 10 * time.Second
+
+type oauth2CachedToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	expiry       time.Time
+}
+
+func (t *oauth2CachedToken) expired() bool {
+	return !t.expiry.IsZero() && time.Now().Add(oauth2ExpirySkew).After(t.expiry)
+}
+
+type oauth2TokenCache struct {
+	mutex  sync.Mutex
+	tokens map[string]*oauth2CachedToken
+}
+
+func (c *oauth2TokenCache) get(key string) *oauth2CachedToken {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.tokens[key]
+}
+
+func (c *oauth2TokenCache) put(key string, token *oauth2CachedToken) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if token == nil {
+		delete(c.tokens, key)
+	} else {
+		c.tokens[key] = token
+	}
+}
+
+// oauth2Authorize is the authorize function used by Method_.AuthorizeOAuth2
+type oauth2Authorize struct {
+	token OAuth2TokenValue
+	frame *framing.Frame
+}
+
+var _ Runnable = (*oauth2Authorize)(nil)
+
+func (a *oauth2Authorize) Run(ctx Context) (err error) {
+	var av any
+	if av, err = ResolveValue(a.token, ctx); err == nil {
+		if req := ctx.CurrentRequest(); req != nil {
+			req.Header.Set("Authorization", string(BearerAuth)+" "+fmt.Sprintf("%v", av))
+		}
+	}
+	return wrapCaptureError(err, "", a)
+}
+
+func (a *oauth2Authorize) Name() string {
+	return "Authorize OAuth2"
+}
+
+func (a *oauth2Authorize) Frame() *framing.Frame {
+	return a.frame
+}
+
+// retryUnauthorized discards the cached token - so that the request can be retried with a new token
+func (a *oauth2Authorize) retryUnauthorized(ctx Context) error {
+	return a.token.Invalidate(ctx)
+}
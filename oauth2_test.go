@@ -0,0 +1,187 @@
+package marrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-andiamo/marrow/coverage"
+	"github.com/go-andiamo/marrow/with"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestTokenServer(t *testing.T, expiresIn int64, refresh bool) (*httptest.Server, *atomic.Int32) {
+	calls := &atomic.Int32{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		require.NoError(t, r.ParseForm())
+		clientId, clientSecret, ok := r.BasicAuth()
+		if !ok || clientId != "my-client" || clientSecret != "my-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body := map[string]any{
+			"access_token": r.PostForm.Get("grant_type") + "-" + strconv.Itoa(int(n)),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		}
+		if refresh {
+			body["refresh_token"] = "refresh"
+		}
+		data, _ := json.Marshal(body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	return svr, calls
+}
+
+func TestOAuth2Token(t *testing.T) {
+	t.Run("client credentials cached", func(t *testing.T) {
+		svr, calls := newTestTokenServer(t, 3600, false)
+		defer svr.Close()
+		ctx := newTestContext(map[Var]any{"url": svr.URL})
+		ctx.httpDo = http.DefaultClient
+		tok := OAuth2Token(Var("url"), "my-client", "my-secret", []string{"read", "write"}, nil)
+		v, err := tok.ResolveValue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "client_credentials-1", v)
+		v, err = tok.ResolveValue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "client_credentials-1", v)
+		// another token value with same params uses the same cached token...
+		v, err = OAuth2Token(svr.URL, "my-client", "my-secret", []string{"read", "write"}, ClientCredentialsGrant()).ResolveValue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "client_credentials-1", v)
+		assert.Equal(t, int32(1), calls.Load())
+
+		err = tok.Invalidate(ctx)
+		require.NoError(t, err)
+		v, err = tok.ResolveValue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "client_credentials-2", v)
+		assert.Equal(t, `OAuth2Token(Var(url), my-client)`, fmt.Sprintf("%s", tok))
+	})
+	t.Run("expired refreshes", func(t *testing.T) {
+		svr, calls := newTestTokenServer(t, 1, true)
+		defer svr.Close()
+		ctx := newTestContext(map[Var]any{"user": "bilbo"})
+		ctx.httpDo = http.DefaultClient
+		tok := OAuth2Token(svr.URL, "my-client", "my-secret", nil, PasswordGrant(Var("user"), "password"))
+		v, err := tok.ResolveValue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "password-1", v)
+		v, err = tok.ResolveValue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "refresh_token-2", v)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+	t.Run("bad client", func(t *testing.T) {
+		svr, _ := newTestTokenServer(t, 3600, false)
+		defer svr.Close()
+		ctx := newTestContext(nil)
+		ctx.httpDo = http.DefaultClient
+		_, err := OAuth2Token(svr.URL, "my-client", "wrong", nil, nil).ResolveValue(ctx)
+		require.Error(t, err)
+		assert.Equal(t, "oauth2 token: unexpected status 401: ", err.Error())
+	})
+	t.Run("no access token", func(t *testing.T) {
+		ctx := newTestContext(nil)
+		ctx.httpDo = &dummyDo{status: http.StatusOK, body: []byte(`{}`)}
+		_, err := OAuth2Token("http://localhost", "my-client", "my-secret", nil, nil).ResolveValue(ctx)
+		require.Error(t, err)
+		assert.Equal(t, "oauth2 token: response did not contain access_token", err.Error())
+	})
+	t.Run("unresolved", func(t *testing.T) {
+		ctx := newTestContext(nil)
+		tok := OAuth2Token(Var("url"), "my-client", "my-secret", nil, nil)
+		_, err := tok.ResolveValue(ctx)
+		require.Error(t, err)
+		err = tok.Invalidate(ctx)
+		require.Error(t, err)
+		_, err = OAuth2Token("", "my-client", "my-secret", nil, PasswordGrant(Var("user"), "password")).ResolveValue(ctx)
+		require.Error(t, err)
+	})
+	t.Run("oidc issuer", func(t *testing.T) {
+		w := with.OIDCIssuer("oidc")
+		s := Suite().(*suite)
+		require.NoError(t, w.Init(s))
+		defer w.Shutdown()()
+		ctx := newTestContext(nil)
+		ctx.images = s.images
+		ctx.httpDo = http.DefaultClient
+		tok := OAuth2Token(TemplateString("{$svc:oidc:token}"), "my-client", "my-secret", []string{"read"}, PasswordGrant("bilbo", "password"))
+		v, err := tok.ResolveValue(ctx)
+		require.NoError(t, err)
+		token, err := jwt.Parse(v.(string), func(token *jwt.Token) (interface{}, error) {
+			_, key, _ := s.images["oidc"].(with.ImageJwtIssuer).SigningKey(token.Method.Alg())
+			return key.Public(), nil
+		})
+		require.NoError(t, err)
+		claims := token.Claims.(jwt.MapClaims)
+		assert.Equal(t, "bilbo", claims["sub"])
+		assert.Equal(t, "my-client", claims["client_id"])
+		assert.Equal(t, "read", claims["scope"])
+		iss, _ := ctx.ResolveServiceValue("oidc:issuer")
+		assert.Equal(t, iss, claims["iss"])
+	})
+}
+
+func TestMethod_AuthorizeOAuth2(t *testing.T) {
+	svr, calls := newTestTokenServer(t, 3600, false)
+	defer svr.Close()
+	ctx := newTestContext(nil)
+	ctx.currEndpoint = Endpoint("/foos", "")
+	ctx.coverage = coverage.NewCoverage()
+	apiCalls := 0
+	auths := make([]string, 0)
+	ctx.httpDo = doFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.String() == svr.URL {
+			return http.DefaultClient.Do(req)
+		}
+		apiCalls++
+		auths = append(auths, req.Header.Get("Authorization"))
+		status := http.StatusOK
+		if apiCalls == 1 {
+			status = http.StatusUnauthorized
+		}
+		return (&dummyDo{status: status, body: []byte(`{}`)}).Do(req)
+	})
+	m := Method(GET, "").
+		AuthorizeOAuth2(OAuth2Token(svr.URL, "my-client", "my-secret", nil, nil)).
+		AssertOK()
+	err := m.Run(ctx)
+	require.NoError(t, err)
+	require.False(t, ctx.failed)
+	assert.Equal(t, 2, apiCalls)
+	assert.Equal(t, []string{"Bearer client_credentials-1", "Bearer client_credentials-2"}, auths)
+	assert.Equal(t, int32(2), calls.Load())
+
+	raw := Method(GET, "").AuthorizeOAuth2(nil).(*method)
+	assert.Empty(t, raw.authFns)
+	raw = Method(GET, "").AuthorizeOAuth2(OAuth2Token("", "", "", nil, nil)).(*method)
+	require.Len(t, raw.authFns, 1)
+	c := raw.authFns[0].(*oauth2Authorize)
+	assert.Equal(t, "Authorize OAuth2", c.Name())
+	assert.NotNil(t, c.Frame())
+}
+
+func TestOAuth2CachedToken_Expired(t *testing.T) {
+	tok := &oauth2CachedToken{}
+	assert.False(t, tok.expired())
+	tok.expiry = time.Now().Add(time.Minute)
+	assert.False(t, tok.expired())
+	tok.expiry = time.Now().Add(time.Second)
+	assert.True(t, tok.expired())
+}
+
+type doFunc func(req *http.Request) (*http.Response, error)
+
+func (f doFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
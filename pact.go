@@ -0,0 +1,110 @@
+package marrow
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-andiamo/marrow/common"
+	"io"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// PactEndpoints reads a Pact (v2 or v3) contract file and creates endpoint tests that verify each interaction
+// against the API under test (i.e. the API under test is the provider)
+//
+// each interaction becomes a method test (described by the interaction description) that sends the
+// interaction request (method, path, query, headers & body) and asserts the interaction response:
+//   - the response status is the interaction response status
+//   - each interaction response header is present (the "Content-Type" header is matched by media type only) - a
+//     multi-valued header (values joined with ", ") is met by the provider sending either multiple headers or a single joined header
+//   - where the interaction response body is json - the actual response body contains the expected body (see ExpectJsonSubset)
+//   - where the interaction response body is text - the actual response body equals the expected text
+//
+// the states arg maps provider state names to before/after operations that set up the state - these are
+// added to each method test for an interaction with that provider state (provider states that are not
+// in the map are ignored)
+//
+// as the interactions are run as normal method tests, each is reported to coverage
+//
+// Note: Pact matching rules and generators are not supported (and are ignored)
+func PactEndpoints(r io.Reader, states map[string][]BeforeAfter) ([]Endpoint_, error) {
+	pact := common.Pact{}
+	if err := json.NewDecoder(r).Decode(&pact); err != nil {
+		return nil, fmt.Errorf("unable to read pact: %w", err)
+	}
+	result := make([]Endpoint_, 0, len(pact.Interactions))
+	for i, interaction := range pact.Interactions {
+		if interaction.Request.Method == "" || interaction.Request.Path == "" {
+			return nil, fmt.Errorf("pact interaction [%d] %q: request method and path are required", i, interaction.Description)
+		}
+		result = append(result, Endpoint(interaction.Request.Path, pact.Consumer.Name, pactMethod(interaction, states)))
+	}
+	return result, nil
+}
+
+func pactMethod(interaction common.PactInteraction, states map[string][]BeforeAfter) Method_ {
+	ops := make([]BeforeAfter, 0)
+	for _, state := range interaction.States() {
+		ops = append(ops, states[state]...)
+	}
+	req := interaction.Request
+	result := Method(MethodName(req.Method), interaction.Description, ops...)
+	for _, k := range slices.Sorted(maps.Keys(req.Query)) {
+		values := make([]any, 0, len(req.Query[k]))
+		for _, v := range req.Query[k] {
+			values = append(values, v)
+		}
+		result.QueryParam(k, values...)
+	}
+	for _, k := range slices.Sorted(maps.Keys(req.Headers)) {
+		result.RequestHeader(k, req.Headers[k])
+	}
+	if v, isJson, ok := common.PactBodyValue(req.Body, pactHeader(req.Headers, hdrContentType)); ok {
+		if isJson {
+			result.RequestBody(req.Body)
+		} else {
+			result.RequestBody(v)
+		}
+	}
+	res := interaction.Response
+	result.AssertStatus(res.Status)
+	for _, k := range slices.Sorted(maps.Keys(res.Headers)) {
+		if http.CanonicalHeaderKey(k) == hdrContentType {
+			result.AssertContentType(res.Headers[k])
+		} else {
+			for _, v := range strings.Split(res.Headers[k], ", ") {
+				result.AssertHeader(k, pactHeaderMatcher(v, res.Headers[k]))
+			}
+		}
+	}
+	if v, isJson, ok := common.PactBodyValue(res.Body, pactHeader(res.Headers, hdrContentType)); ok {
+		if isJson {
+			result.AssertJsonSubset(Body, v)
+		} else {
+			result.AssertEqual(BodyText, v)
+		}
+	}
+	return result
+}
+
+// pactHeaderMatcher returns the matcher for an expected header value - where the pact header value is multi-valued
+// (i.e. joined with ", "), each value is matched as an element of the (comma separated) actual header value(s)
+// so that the provider may send either multiple headers or a single joined header
+func pactHeaderMatcher(v string, joined string) any {
+	if v == joined {
+		return v
+	}
+	return regexp.MustCompile(`(^|,)\s*` + regexp.QuoteMeta(v) + `\s*(,|$)`)
+}
+
+func pactHeader(hdrs map[string]string, name string) string {
+	for k, v := range hdrs {
+		if http.CanonicalHeaderKey(k) == name {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,194 @@
+package marrow
+
+import (
+	"encoding/json"
+	"github.com/go-andiamo/marrow/coverage"
+	"github.com/go-andiamo/marrow/mocks/service"
+	"github.com/go-andiamo/marrow/with"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPactEndpoints(t *testing.T) {
+	const pact = `{
+		"consumer": {"name": "my-consumer"},
+		"provider": {"name": "my-api"},
+		"interactions": [
+			{
+				"description": "get foos",
+				"providerStates": [{"name": "foos exist"}],
+				"request": {"method": "GET", "path": "/foos", "query": {"b": ["2"], "a": ["1", "3"]}, "headers": {"Accept": "application/json"}},
+				"response": {"status": 200, "headers": {"Content-Type": "application/json", "X-Foo": "foo"}, "body": [{"foo": "bar"}]}
+			},
+			{
+				"description": "create foo",
+				"providerState": "no foos",
+				"request": {"method": "POST", "path": "/foos", "headers": {"Content-Type": "application/json"}, "body": {"foo": "bar"}},
+				"response": {"status": 201}
+			},
+			{
+				"description": "post text",
+				"request": {"method": "POST", "path": "/texts", "headers": {"Content-Type": "text/plain"}, "body": "some text"},
+				"response": {"status": 200, "headers": {"Content-Type": "text/plain"}, "body": "ok"}
+			}
+		]
+	}`
+	states := map[string][]BeforeAfter{
+		"foos exist": {DoBefore(SetVar("state", "foos exist"))},
+	}
+	endpoints, err := PactEndpoints(strings.NewReader(pact), states)
+	require.NoError(t, err)
+	require.Len(t, endpoints, 3)
+	assert.Equal(t, "/foos", endpoints[0].Url())
+	assert.Equal(t, "my-consumer", endpoints[0].Description())
+	require.Len(t, endpoints[0].(*endpoint).methods, 1)
+	m := endpoints[0].(*endpoint).methods[0].(*method)
+	assert.Equal(t, GET, m.method)
+	assert.Equal(t, "get foos", m.desc)
+	assert.Len(t, m.preCaptures, 1)
+	assert.Equal(t, queryParams{"a": {"1", "3"}, "b": {"2"}}, m.queryParams)
+	assert.Equal(t, map[string]any{"Accept": "application/json"}, m.headers)
+	assert.Nil(t, m.body)
+	assert.Len(t, m.postOps, 4)
+	m = endpoints[1].(*endpoint).methods[0].(*method)
+	assert.Equal(t, POST, m.method)
+	assert.Len(t, m.preCaptures, 0)
+	assert.Equal(t, json.RawMessage(`{"foo": "bar"}`), m.body)
+	assert.Len(t, m.postOps, 1)
+	m = endpoints[2].(*endpoint).methods[0].(*method)
+	assert.Equal(t, "some text", m.body)
+	assert.Len(t, m.postOps, 3)
+
+	_, err = PactEndpoints(strings.NewReader(`{`), nil)
+	require.Error(t, err)
+	_, err = PactEndpoints(strings.NewReader(`{"interactions":[{"description":"bad","request":{"path":"/foos"}}]}`), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `pact interaction [0] "bad"`)
+}
+
+func TestPactEndpoints_RoundTrip(t *testing.T) {
+	// generate a pact from a mocked service...
+	dir := t.TempDir()
+	svc := service.NewMockedService("foo-service", service.Pact("my-api", dir))
+	require.NoError(t, svc.Start())
+	defer svc.Shutdown()
+	svc.MockCall("/foos", http.MethodGet, http.StatusOK, []map[string]any{{"foo": "bar"}}, "X-Foo", "foo")
+	svc.MockCall("/foos", http.MethodPost, http.StatusCreated, map[string]any{"id": 1})
+	for _, call := range []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/foos?a=1", ""},
+		{http.MethodPost, "/foos", `{"foo":"bar"}`},
+	} {
+		req, err := http.NewRequest(call.method, svc.Url()+call.path, strings.NewReader(call.body))
+		require.NoError(t, err)
+		if call.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+	}
+	f, err := os.Open(filepath.Join(dir, "my-api-foo-service.json"))
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	endpoints, err := PactEndpoints(f, nil)
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+
+	// verify the pact against the (real) provider...
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Get("a") == "1":
+			w.Header().Set("X-Foo", "foo")
+			_, _ = w.Write([]byte(`[{"foo":"bar","extra":true}]`))
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":1,"received":` + string(body) + `}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer provider.Close()
+	u, err := url.Parse(provider.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	cov := coverage.NewCoverage()
+	s := Suite(endpoints...).Init(
+		with.ApiHost(u.Hostname(), port),
+		with.HttpDo(http.DefaultClient),
+		with.CoverageCollector(cov),
+		with.Logging(&nullWriter{}, &nullWriter{}),
+	)
+	err = s.Run()
+	require.NoError(t, err)
+	assert.Len(t, cov.Timings, 2)
+	assert.Empty(t, cov.Failures)
+	assert.Len(t, cov.Met, 7)
+}
+
+func TestPactEndpoints_MultiValuedHeaders(t *testing.T) {
+	const pact = `{
+		"consumer": {"name": "my-consumer"},
+		"interactions": [
+			{
+				"description": "get foos",
+				"request": {"method": "GET", "path": "/foos"},
+				"response": {"status": 200, "headers": {"Vary": "Origin, Accept-Encoding"}}
+			}
+		]
+	}`
+	for name, hdrs := range map[string][]string{
+		"multiple headers": {"Origin", "Accept-Encoding"},
+		"joined header":    {"Accept-Encoding,Origin"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for _, v := range hdrs {
+					w.Header().Add("Vary", v)
+				}
+			}))
+			defer provider.Close()
+			u, err := url.Parse(provider.URL)
+			require.NoError(t, err)
+			port, err := strconv.Atoi(u.Port())
+			require.NoError(t, err)
+			endpoints, err := PactEndpoints(strings.NewReader(pact), nil)
+			require.NoError(t, err)
+
+			cov := coverage.NewCoverage()
+			s := Suite(endpoints...).Init(
+				with.ApiHost(u.Hostname(), port),
+				with.HttpDo(http.DefaultClient),
+				with.CoverageCollector(cov),
+				with.Logging(&nullWriter{}, &nullWriter{}),
+			)
+			err = s.Run()
+			require.NoError(t, err)
+			assert.Empty(t, cov.Failures)
+			assert.Len(t, cov.Met, 3)
+		})
+	}
+	m, ok := pactHeaderMatcher("Origin", "Origin, Accept").(*regexp.Regexp)
+	require.True(t, ok)
+	assert.False(t, m.MatchString("X-Origin"))
+	assert.Equal(t, "Origin", pactHeaderMatcher("Origin", "Origin"))
+}
@@ -0,0 +1,207 @@
+package marrow
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"github.com/go-andiamo/marrow/common"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// requestBodyEncoder is implemented by request body values (e.g. XMLBodyValue) that encode themselves
+// and determine the request content type
+type requestBodyEncoder interface {
+	encodeBody(ctx Context) (data []byte, contentType string, err error)
+}
+
+type XMLBodyValue struct {
+	Value any
+}
+
+// XMLBody creates an XMLBodyValue for use as Method_.RequestBody
+//
+// the value (or resolved value - including nested Var, TemplateString etc.) is encoded as xml (see common.XmlCodec)
+// and the request content type is "application/xml"
+func XMLBody(value any) XMLBodyValue {
+	return XMLBodyValue{Value: value}
+}
+
+func (v XMLBodyValue) encodeBody(ctx Context) (data []byte, contentType string, err error) {
+	var av any
+	if av, err = ResolveValue(v.Value, ctx); err == nil {
+		data, err = common.XmlCodec.Encode(plainValue(av))
+	}
+	return data, "application/xml", err
+}
+
+type YAMLBodyValue struct {
+	Value any
+}
+
+// YAMLBody creates a YAMLBodyValue for use as Method_.RequestBody
+//
+// the value (or resolved value - including nested Var, TemplateString etc.) is encoded as yaml
+// and the request content type is "application/yaml"
+func YAMLBody(value any) YAMLBodyValue {
+	return YAMLBodyValue{Value: value}
+}
+
+func (v YAMLBodyValue) encodeBody(ctx Context) (data []byte, contentType string, err error) {
+	var av any
+	if av, err = ResolveValue(v.Value, ctx); err == nil {
+		data, err = common.YamlCodec.Encode(plainValue(av))
+	}
+	return data, "application/yaml", err
+}
+
+type NDJSONBodyValue struct {
+	Items []any
+}
+
+// NDJSONBody creates a NDJSONBodyValue for use as Method_.RequestBody
+//
+// each item (or resolved item - including nested Var, TemplateString etc.) is encoded as a line of json
+// and the request content type is "application/x-ndjson"
+func NDJSONBody(items ...any) NDJSONBodyValue {
+	return NDJSONBodyValue{Items: items}
+}
+
+func (v NDJSONBodyValue) encodeBody(ctx Context) (data []byte, contentType string, err error) {
+	var buf bytes.Buffer
+	for _, item := range v.Items {
+		var av any
+		if av, err = ResolveValue(item, ctx); err != nil {
+			return nil, "", err
+		}
+		var line []byte
+		if line, err = json.Marshal(av); err != nil {
+			return nil, "", err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), "application/x-ndjson", nil
+}
+
+type ProtobufBodyValue struct {
+	Message any
+}
+
+// ProtobufBody creates a ProtobufBodyValue for use as Method_.RequestBody
+//
+// the message (or resolved message) is encoded as protobuf binary and the request content type is "application/x-protobuf"
+//
+// the message can be:
+//   - a message with a Marshal() ([]byte, error) method (e.g. gogo/protobuf generated messages)
+//   - an encoding.BinaryMarshaler
+//   - func() ([]byte, error) - e.g. for google.golang.org/protobuf messages:
+//     ProtobufBody(func() ([]byte, error) { return proto.Marshal(msg) })
+//   - []byte - already encoded message
+func ProtobufBody(msg any) ProtobufBodyValue {
+	return ProtobufBodyValue{Message: msg}
+}
+
+type protobufMarshaler interface {
+	Marshal() ([]byte, error)
+}
+
+func (v ProtobufBodyValue) encodeBody(ctx Context) (data []byte, contentType string, err error) {
+	const ct = "application/x-protobuf"
+	var av any
+	if av, err = ResolveValue(v.Message, ctx); err == nil {
+		switch avt := av.(type) {
+		case protobufMarshaler:
+			data, err = avt.Marshal()
+		case encoding.BinaryMarshaler:
+			data, err = avt.MarshalBinary()
+		case func() ([]byte, error):
+			data, err = avt()
+		case []byte:
+			data = avt
+		default:
+			err = fmt.Errorf("cannot encode %T as protobuf", av)
+		}
+	}
+	return data, ct, err
+}
+
+type FileBodyValue struct {
+	FS   fs.FS
+	Path any
+}
+
+// FileBody creates a FileBodyValue for use as Method_.RequestBody
+//
+// the request body is the content of the file read from the fs (the path can be a string or resolvable, e.g. TemplateString)
+//
+// the request content type is determined by the file extension (or, if the extension is unknown, by sniffing the content)
+func FileBody(fs fs.FS, path any) FileBodyValue {
+	return FileBodyValue{FS: fs, Path: path}
+}
+
+func (v FileBodyValue) encodeBody(ctx Context) (data []byte, contentType string, err error) {
+	var av any
+	if av, err = ResolveValue(v.Path, ctx); err == nil {
+		p := fmt.Sprintf("%v", av)
+		if data, err = fs.ReadFile(v.FS, p); err == nil {
+			if contentType = mime.TypeByExtension(path.Ext(p)); contentType == "" {
+				contentType = http.DetectContentType(data)
+			}
+		}
+	}
+	return data, contentType, err
+}
+
+// plainValue converts JSON and JSONArray (recursively) to map[string]any and []any (so that encoders see plain values)
+func plainValue(v any) any {
+	switch vt := v.(type) {
+	case JSON:
+		return plainValue(map[string]any(vt))
+	case JSONArray:
+		return plainValue([]any(vt))
+	case map[string]any:
+		m := make(map[string]any, len(vt))
+		for k, mv := range vt {
+			m[k] = plainValue(mv)
+		}
+		return m
+	case []any:
+		sl := make([]any, len(vt))
+		for i, sv := range vt {
+			sl[i] = plainValue(sv)
+		}
+		return sl
+	}
+	return v
+}
+
+// compressBody compresses request body data according to the content encoding ("gzip" or "deflate")
+func compressBody(data []byte, contentEncoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	var w interface {
+		Write(p []byte) (int, error)
+		Close() error
+	}
+	switch strings.ToLower(contentEncoding) {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("unsupported request compression %q", contentEncoding)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,264 @@
+package marrow
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"testing/fstest"
+)
+
+type testProtoMessage struct {
+	data []byte
+	err  error
+}
+
+func (m testProtoMessage) Marshal() ([]byte, error) {
+	return m.data, m.err
+}
+
+type testBinaryMessage struct{}
+
+func (m testBinaryMessage) MarshalBinary() ([]byte, error) {
+	return []byte{0x0a, 0x01}, nil
+}
+
+func TestRequestBodyEncoders(t *testing.T) {
+	files := fstest.MapFS{
+		"data/foo.json": {Data: []byte(`{"foo":"bar"}`)},
+		"data/foo.bin":  {Data: []byte{0x00, 0x01}},
+	}
+	ctx := newTestContext(map[Var]any{"name": "Bilbo", "age": 111, "file": "foo"})
+	testCases := []struct {
+		name        string
+		body        requestBodyEncoder
+		expect      string
+		contentType string
+		expectErr   bool
+	}{
+		{
+			name:        "xml",
+			body:        XMLBody(JSON{"person": JSON{"@id": Var("age"), "name": TemplateString("{$name} Baggins")}}),
+			expect:      `<person id="111"><name>Bilbo Baggins</name></person>`,
+			contentType: "application/xml",
+		},
+		{
+			name:      "xml unresolved",
+			body:      XMLBody(Var("missing")),
+			expectErr: true,
+		},
+		{
+			name:        "yaml",
+			body:        YAMLBody(JSON{"name": Var("name"), "tags": JSONArray{"hobbit", Var("age")}}),
+			expect:      "name: Bilbo\ntags:\n    - hobbit\n    - 111\n",
+			contentType: "application/yaml",
+		},
+		{
+			name:      "yaml unresolved",
+			body:      YAMLBody(Var("missing")),
+			expectErr: true,
+		},
+		{
+			name:        "ndjson",
+			body:        NDJSONBody(JSON{"name": Var("name")}, JSON{"age": Var("age")}, "foo"),
+			expect:      "{\"name\":\"Bilbo\"}\n{\"age\":111}\n\"foo\"\n",
+			contentType: "application/x-ndjson",
+		},
+		{
+			name:      "ndjson unresolved",
+			body:      NDJSONBody(Var("missing")),
+			expectErr: true,
+		},
+		{
+			name:      "ndjson marshal fails",
+			body:      NDJSONBody(func() {}),
+			expectErr: true,
+		},
+		{
+			name:        "protobuf marshaler",
+			body:        ProtobufBody(testProtoMessage{data: []byte{0x08, 0x01}}),
+			expect:      "\x08\x01",
+			contentType: "application/x-protobuf",
+		},
+		{
+			name:      "protobuf marshaler fails",
+			body:      ProtobufBody(testProtoMessage{err: errors.New("fooey")}),
+			expectErr: true,
+		},
+		{
+			name:        "protobuf binary marshaler",
+			body:        ProtobufBody(testBinaryMessage{}),
+			expect:      "\x0a\x01",
+			contentType: "application/x-protobuf",
+		},
+		{
+			name: "protobuf func",
+			body: ProtobufBody(func() ([]byte, error) {
+				return []byte{0x10}, nil
+			}),
+			expect:      "\x10",
+			contentType: "application/x-protobuf",
+		},
+		{
+			name:        "protobuf bytes",
+			body:        ProtobufBody([]byte{0x18}),
+			expect:      "\x18",
+			contentType: "application/x-protobuf",
+		},
+		{
+			name:      "protobuf unsupported",
+			body:      ProtobufBody("foo"),
+			expectErr: true,
+		},
+		{
+			name:        "file",
+			body:        FileBody(files, TemplateString("data/{$file}.json")),
+			expect:      `{"foo":"bar"}`,
+			contentType: "application/json",
+		},
+		{
+			name:        "file sniffed",
+			body:        FileBody(files, "data/foo.bin"),
+			expect:      "\x00\x01",
+			contentType: "application/octet-stream",
+		},
+		{
+			name:      "file missing",
+			body:      FileBody(files, "data/missing.json"),
+			expectErr: true,
+		},
+		{
+			name:      "file path unresolved",
+			body:      FileBody(files, Var("missing")),
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, ct, err := tc.body.encodeBody(ctx)
+			if tc.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expect, string(data))
+				assert.Equal(t, tc.contentType, ct)
+			}
+		})
+	}
+}
+
+func TestMethod_BuildRequest_BodyEncoders(t *testing.T) {
+	t.Run("content type", func(t *testing.T) {
+		m := Method(POST, "").RequestBody(XMLBody(JSON{"foo": "bar"}))
+		ctx := newTestContext(nil)
+		ctx.currEndpoint = Endpoint("/foos", "").(*endpoint)
+		req, ok := m.(*method).buildRequest(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "application/xml", req.Header.Get("Content-Type"))
+		assert.Equal(t, "", req.Header.Get("Content-Encoding"))
+		data, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "<foo>bar</foo>", string(data))
+	})
+	t.Run("content type not overridden", func(t *testing.T) {
+		m := Method(POST, "").
+			RequestHeader("Content-Type", "application/vnd.foo+xml").
+			RequestBody(XMLBody(JSON{"foo": "bar"}))
+		ctx := newTestContext(nil)
+		ctx.currEndpoint = Endpoint("/foos", "").(*endpoint)
+		req, ok := m.(*method).buildRequest(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "application/vnd.foo+xml", req.Header.Get("Content-Type"))
+	})
+	t.Run("content type per build", func(t *testing.T) {
+		m := Method(POST, "").RequestBody(Var("body"))
+		raw := m.(*method)
+		ctx := newTestContext(map[Var]any{"body": XMLBody(JSON{"foo": "bar"})})
+		ctx.currEndpoint = Endpoint("/foos", "").(*endpoint)
+		req, ok := raw.buildRequest(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "application/xml", req.Header.Get("Content-Type"))
+		assert.NotContains(t, raw.headers, "Content-Type")
+		ctx.SetVar("body", YAMLBody(JSON{"foo": "bar"}))
+		req, ok = raw.buildRequest(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "application/yaml", req.Header.Get("Content-Type"))
+		ctx.SetVar("body", JSON{"foo": "bar"})
+		req, ok = raw.buildRequest(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.NotContains(t, raw.headers, "Content-Type")
+	})
+	t.Run("gzip", func(t *testing.T) {
+		m := Method(POST, "").
+			RequestBody(JSON{"foo": Var("foo")}).
+			RequestCompression("gzip")
+		ctx := newTestContext(map[Var]any{"foo": "bar"})
+		ctx.currEndpoint = Endpoint("/foos", "").(*endpoint)
+		req, ok := m.(*method).buildRequest(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "gzip", req.Header.Get("Content-Encoding"))
+		gr, err := gzip.NewReader(req.Body)
+		require.NoError(t, err)
+		data, err := io.ReadAll(gr)
+		require.NoError(t, err)
+		assert.Equal(t, `{"foo":"bar"}`, string(data))
+	})
+	t.Run("deflate multipart", func(t *testing.T) {
+		m := Method(POST, "").
+			RequestBody(Multipart(Field("foo", "bar"))).
+			RequestCompression("DEFLATE")
+		ctx := newTestContext(nil)
+		ctx.currEndpoint = Endpoint("/foos", "").(*endpoint)
+		req, ok := m.(*method).buildRequest(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "deflate", req.Header.Get("Content-Encoding"))
+		data, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), req.ContentLength)
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		data, err = io.ReadAll(zr)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `name="foo"`)
+	})
+	t.Run("no body not compressed", func(t *testing.T) {
+		m := Method(GET, "").RequestCompression("gzip")
+		ctx := newTestContext(nil)
+		ctx.currEndpoint = Endpoint("/foos", "").(*endpoint)
+		req, ok := m.(*method).buildRequest(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "", req.Header.Get("Content-Encoding"))
+	})
+	t.Run("grpc not compressed", func(t *testing.T) {
+		m := GrpcMethod("marrow.test.Things/GetThing", "").
+			RequestBody(JSON{"id": "abc"}).
+			RequestCompression("gzip")
+		ctx := newTestContext(nil)
+		ctx.currEndpoint = Endpoint("/", "").(*endpoint)
+		req, ok := m.(*method).buildRequest(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "", req.Header.Get("Content-Encoding"))
+		data, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"id":"abc"}`, string(data))
+	})
+	t.Run("unsupported compression", func(t *testing.T) {
+		m := Method(POST, "").RequestBody("foo").RequestCompression("br")
+		ctx := newTestContext(nil)
+		ctx.currEndpoint = Endpoint("/foos", "").(*endpoint)
+		_, ok := m.(*method).buildRequest(ctx)
+		require.False(t, ok)
+	})
+	t.Run("encode fails", func(t *testing.T) {
+		m := Method(POST, "").RequestBody(ProtobufBody("foo"))
+		ctx := newTestContext(nil)
+		ctx.currEndpoint = Endpoint("/foos", "").(*endpoint)
+		_, ok := m.(*method).buildRequest(ctx)
+		require.False(t, ok)
+	})
+}
@@ -713,6 +713,80 @@ func (BodyValue) String() string {
 	return "Body"
 }
 
+type RawBodyValue string
+
+const (
+	// RawBody resolves to the raw (undecoded) response body bytes ([]byte)
+	//
+	// gzip and deflate compressed response bodies are decompressed
+	RawBody RawBodyValue = "RawBody"
+)
+
+func (RawBodyValue) ResolveValue(ctx Context) (any, error) {
+	if data := ctx.CurrentRawBody(); data != nil {
+		return data, nil
+	}
+	return nil, nil
+}
+
+func (RawBodyValue) String() string {
+	return "RawBody"
+}
+
+type BodyTextValue string
+
+const (
+	// BodyText resolves to the raw (undecoded) response body as a string
+	BodyText BodyTextValue = "BodyText"
+)
+
+func (BodyTextValue) ResolveValue(ctx Context) (any, error) {
+	return string(ctx.CurrentRawBody()), nil
+}
+
+func (BodyTextValue) String() string {
+	return "BodyText"
+}
+
+type BodySizeValue string
+
+const (
+	// BodySize resolves to the size (int) of the raw (undecoded) response body
+	BodySize BodySizeValue = "BodySize"
+)
+
+func (BodySizeValue) ResolveValue(ctx Context) (any, error) {
+	return len(ctx.CurrentRawBody()), nil
+}
+
+func (BodySizeValue) String() string {
+	return "BodySize"
+}
+
+type ResponseEncodingValue string
+
+const (
+	// ResponseEncoding resolves to the content encoding of the current Context response (e.g. "gzip", "br" or "identity")
+	//
+	// where the http client transparently decompressed the response, the content encoding is "gzip"
+	ResponseEncoding ResponseEncodingValue = "ResponseEncoding"
+)
+
+func (ResponseEncodingValue) ResolveValue(ctx Context) (any, error) {
+	if response := ctx.CurrentResponse(); response == nil {
+		return nil, errors.New("response is nil")
+	} else if ce := response.Header.Get(hdrContentEncoding); ce != "" {
+		return strings.ToLower(ce), nil
+	} else if response.Uncompressed {
+		return "gzip", nil
+	}
+	return "identity", nil
+}
+
+func (ResponseEncodingValue) String() string {
+	return "ResponseEncoding"
+}
+
 type JsonifyValue struct {
 	Value any
 }
@@ -1431,3 +1505,121 @@ func (v ImageValue) ResolveValue(ctx Context) (any, error) {
 		return nil, fmt.Errorf("unable to resolve image value: %q", b.String())
 	}
 }
+
+type NowValue struct{}
+
+// Now resolves to the current time (time.Time)
+func Now() NowValue {
+	return NowValue{}
+}
+
+func (NowValue) ResolveValue(ctx Context) (any, error) {
+	return time.Now(), nil
+}
+
+func (NowValue) String() string {
+	return "Now()"
+}
+
+type TimeAddValue struct {
+	Value    any
+	Duration any
+}
+
+// TimeAdd resolves to the time value (or resolved value) plus the duration
+//
+// the value (or resolved value) can be a time.Time or a string (RFC 3339 or HTTP date format)
+//
+// the duration (or resolved duration) can be a time.Duration or a string (e.g. "5s", "-1h30m")
+func TimeAdd(value any, duration any) TimeAddValue {
+	return TimeAddValue{
+		Value:    value,
+		Duration: duration,
+	}
+}
+
+func (v TimeAddValue) ResolveValue(ctx Context) (av any, err error) {
+	var rv, dv any
+	if rv, dv, err = ResolveValues(v.Value, v.Duration, ctx); err == nil {
+		var t time.Time
+		if t, err = toTime(rv); err == nil {
+			var d time.Duration
+			if d, err = toDuration(dv); err == nil {
+				av = t.Add(d)
+			}
+		}
+	}
+	return av, err
+}
+
+func (v TimeAddValue) String() string {
+	return fmt.Sprintf("TimeAdd(%s, %s)", stringifyValue(v.Value), stringifyValue(v.Duration))
+}
+
+type TimeFormatValue struct {
+	Value  any
+	Layout string
+}
+
+// TimeFormat resolves to the time value (or resolved value) formatted using the layout (e.g. time.RFC3339)
+//
+// the value (or resolved value) can be a time.Time or a string (RFC 3339 or HTTP date format)
+func TimeFormat(value any, layout string) TimeFormatValue {
+	return TimeFormatValue{
+		Value:  value,
+		Layout: layout,
+	}
+}
+
+func (v TimeFormatValue) ResolveValue(ctx Context) (av any, err error) {
+	var rv any
+	if rv, err = ResolveValue(v.Value, ctx); err == nil {
+		var t time.Time
+		if t, err = toTime(rv); err == nil {
+			av = t.Format(v.Layout)
+		}
+	}
+	return av, err
+}
+
+func (v TimeFormatValue) String() string {
+	return fmt.Sprintf("TimeFormat(%s, %q)", stringifyValue(v.Value), v.Layout)
+}
+
+// toTime converts a value to a time - the value can be a time.Time or a string (RFC 3339 or HTTP date format)
+func toTime(v any) (time.Time, error) {
+	switch vt := v.(type) {
+	case time.Time:
+		return vt, nil
+	case *time.Time:
+		if vt != nil {
+			return *vt, nil
+		}
+	case string:
+		if t, ok := parseTime(vt); ok {
+			return t, nil
+		}
+		return time.Time{}, fmt.Errorf("cannot parse time %q", vt)
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %T to time", v)
+}
+
+func parseTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func toDuration(v any) (time.Duration, error) {
+	switch vt := v.(type) {
+	case time.Duration:
+		return vt, nil
+	case string:
+		return time.ParseDuration(vt)
+	}
+	return 0, fmt.Errorf("cannot convert %T to duration", v)
+}
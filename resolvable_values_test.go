@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestResolveValue(t *testing.T) {
@@ -1028,3 +1029,96 @@ func TestApiCall(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestNow(t *testing.T) {
+	v := Now()
+	assert.Equal(t, "Now()", v.String())
+	av, err := v.ResolveValue(nil)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), av.(time.Time), time.Second)
+}
+
+func TestTimeAdd(t *testing.T) {
+	ctx := newTestContext(map[Var]any{"d": "1h"})
+	v := TimeAdd("2025-01-02T03:04:05Z", Var("d"))
+	assert.Equal(t, `TimeAdd("2025-01-02T03:04:05Z", Var(d))`, v.String())
+	av, err := v.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 2, 4, 4, 5, 0, time.UTC), av.(time.Time).UTC())
+
+	av, err = TimeAdd(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), -time.Minute).ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 3, 5, 0, time.UTC), av)
+
+	_, err = TimeAdd("not a time", time.Second).ResolveValue(ctx)
+	require.Error(t, err)
+	_, err = TimeAdd(Now(), 1).ResolveValue(ctx)
+	require.Error(t, err)
+	_, err = TimeAdd(Var("missing"), time.Second).ResolveValue(ctx)
+	require.Error(t, err)
+}
+
+func TestTimeFormat(t *testing.T) {
+	tm := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	v := TimeFormat(&tm, "2006-01-02")
+	assert.Contains(t, v.String(), `"2006-01-02")`)
+	av, err := v.ResolveValue(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01-02", av)
+
+	av, err = TimeFormat("Thu, 02 Jan 2025 03:04:05 GMT", time.RFC3339).ResolveValue(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01-02T03:04:05Z", av)
+
+	_, err = TimeFormat((*time.Time)(nil), time.RFC3339).ResolveValue(nil)
+	require.Error(t, err)
+	_, err = TimeFormat(Var("missing"), time.RFC3339).ResolveValue(newTestContext(nil))
+	require.Error(t, err)
+}
+
+func TestRawBodyResolvables(t *testing.T) {
+	ctx := newTestContext(nil)
+	av, err := RawBody.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, av)
+	av, err = BodyText.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", av)
+	av, err = BodySize.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, av)
+
+	ctx.currRawBody = []byte("hello")
+	av, err = RawBody.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), av)
+	av, err = BodyText.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", av)
+	av, err = BodySize.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, av)
+
+	assert.Equal(t, "RawBody", RawBody.String())
+	assert.Equal(t, "BodyText", BodyText.String())
+	assert.Equal(t, "BodySize", BodySize.String())
+}
+
+func TestResponseEncoding(t *testing.T) {
+	assert.Equal(t, "ResponseEncoding", ResponseEncoding.String())
+	ctx := newTestContext(nil)
+	_, err := ResponseEncoding.ResolveValue(ctx)
+	require.Error(t, err)
+	ctx.currResponse = &http.Response{Header: http.Header{}}
+	av, err := ResponseEncoding.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "identity", av)
+	ctx.currResponse.Uncompressed = true
+	av, err = ResponseEncoding.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", av)
+	ctx.currResponse.Header.Set("Content-Encoding", "BR")
+	av, err = ResponseEncoding.ResolveValue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "br", av)
+}
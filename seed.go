@@ -0,0 +1,286 @@
+package marrow
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-andiamo/marrow/framing"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+type dbSeed struct {
+	when     When
+	dbName   string
+	fixtures fs.FS
+	frame    *framing.Frame
+}
+
+var _ Capture = (*dbSeed)(nil)
+var _ BeforeAfter = (*dbSeed)(nil)
+
+// DbSeed is used to seed a database from fixture files
+//
+// each fixture file (in the root of the fixtures fs.FS) provides the rows for one table - the table name being the
+// file name without extension.  Fixture files can be:
+//   - ".yaml" or ".yml" - a list of rows, where each row is a map of column name to value
+//   - ".json" - an array of rows, where each row is an object of column name to value
+//   - ".csv" - a header line of column names followed by rows of values
+//
+// null values (JSON null or YAML ~) are inserted as SQL NULL - CSV has no null representation (every CSV value is
+// a string, an empty cell being an empty string), so use a YAML or JSON fixture where nulls are needed
+//
+// string values can contain templates (e.g. "{$var}") - see TemplateString
+//
+// rows can also contain the special columns:
+//   - "$var" - the name of a variable to capture the generated id of the inserted row into
+//   - "$id" - the name of the id column (defaults to "id")
+//
+// tables are inserted in order of their foreign key dependencies (discovered from the database schema) - if the
+// foreign keys cannot be discovered, tables are inserted in fixture file name order
+//
+// Note: when only one database is used by tests, the dbName can be ""
+//
+//go:noinline
+func DbSeed(when When, dbName string, fixtures fs.FS) BeforeAfter {
+	return &dbSeed{
+		when:     when,
+		dbName:   dbName,
+		fixtures: fixtures,
+		frame:    framing.NewFrame(0),
+	}
+}
+
+func (c *dbSeed) Name() string {
+	return "SEED " + c.dbName
+}
+
+func (c *dbSeed) When() When {
+	return c.when
+}
+
+func (c *dbSeed) Run(ctx Context) error {
+	return wrapCaptureError(c.seed(ctx), "", c)
+}
+
+func (c *dbSeed) Frame() *framing.Frame {
+	return c.frame
+}
+
+const (
+	seedVarColumn       = "$var"
+	seedIdColumn        = "$id"
+	defaultSeedIdColumn = "id"
+)
+
+type seedTable struct {
+	name string
+	rows []Columns
+}
+
+func (c *dbSeed) seed(ctx Context) (err error) {
+	db := ctx.Db(c.dbName)
+	if db == nil {
+		return fmt.Errorf("db name %q not found", c.dbName)
+	}
+	var tables []*seedTable
+	if tables, err = loadSeedFixtures(c.fixtures); err == nil {
+		if tables, err = orderSeedTables(tables, discoverForeignKeys(db)); err == nil {
+			for t := 0; t < len(tables) && err == nil; t++ {
+				for r := 0; r < len(tables[t].rows) && err == nil; r++ {
+					err = c.insertRow(ctx, tables[t].name, tables[t].rows[r])
+				}
+			}
+		}
+	}
+	return err
+}
+
+func (c *dbSeed) insertRow(ctx Context, tableName string, row Columns) (err error) {
+	varName, _ := row[seedVarColumn].(string)
+	idCol, _ := row[seedIdColumn].(string)
+	if idCol == "" {
+		idCol = defaultSeedIdColumn
+	}
+	insertRow := make(Columns, len(row))
+	for k, v := range row {
+		if k != seedVarColumn && k != seedIdColumn {
+			if s, ok := v.(string); ok && strings.Contains(s, "{$") {
+				insertRow[k] = TemplateString(s)
+			} else {
+				insertRow[k] = v
+			}
+		}
+	}
+	if varName == "" {
+		_, err = ctx.dbInsert(c.dbName, tableName, insertRow, "")
+	} else if v, ok := insertRow[idCol]; ok {
+		var av any
+		if av, err = ResolveValue(v, ctx); err == nil {
+			if _, err = ctx.dbInsert(c.dbName, tableName, insertRow, ""); err == nil {
+				ctx.SetVar(Var(varName), av)
+			}
+		}
+	} else {
+		var id any
+		if id, err = ctx.dbInsert(c.dbName, tableName, insertRow, idCol); err == nil {
+			ctx.SetVar(Var(varName), id)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("seed table %q: %w", tableName, err)
+	}
+	return err
+}
+
+func loadSeedFixtures(fixtures fs.FS) (tables []*seedTable, err error) {
+	if fixtures == nil {
+		return nil, errors.New("fixtures not provided")
+	}
+	var entries []fs.DirEntry
+	if entries, err = fs.ReadDir(fixtures, "."); err == nil {
+		for e := 0; e < len(entries) && err == nil; e++ {
+			if entry := entries[e]; !entry.IsDir() {
+				name := entry.Name()
+				ext := strings.ToLower(path.Ext(name))
+				var rows []Columns
+				var data []byte
+				switch ext {
+				case ".yaml", ".yml":
+					if data, err = fs.ReadFile(fixtures, name); err == nil {
+						err = yaml.Unmarshal(data, &rows)
+					}
+				case ".json":
+					if data, err = fs.ReadFile(fixtures, name); err == nil {
+						err = json.Unmarshal(data, &rows)
+					}
+				case ".csv":
+					if data, err = fs.ReadFile(fixtures, name); err == nil {
+						rows, err = readCsvRows(data)
+					}
+				default:
+					continue
+				}
+				if err == nil {
+					tables = append(tables, &seedTable{
+						name: strings.TrimSuffix(name, path.Ext(name)),
+						rows: rows,
+					})
+				} else {
+					err = fmt.Errorf("fixture file %q: %w", name, err)
+				}
+			}
+		}
+	}
+	return tables, err
+}
+
+func readCsvRows(data []byte) (rows []Columns, err error) {
+	var records [][]string
+	if records, err = csv.NewReader(strings.NewReader(string(data))).ReadAll(); err == nil && len(records) > 0 {
+		hdrs := records[0]
+		rows = make([]Columns, 0, len(records)-1)
+		for _, record := range records[1:] {
+			row := make(Columns, len(hdrs))
+			for i, hdr := range hdrs {
+				if i < len(record) {
+					row[hdr] = record[i]
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, err
+}
+
+// foreignKeyQueries are the queries used to discover foreign key table dependencies (child table, parent table)
+//
+// each is tried in turn until one succeeds
+var foreignKeyQueries = []string{
+	// MySQL...
+	`SELECT table_name, referenced_table_name FROM information_schema.key_column_usage WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL`,
+	// Postgres...
+	`SELECT tc.table_name, ccu.table_name FROM information_schema.table_constraints tc JOIN information_schema.constraint_column_usage ccu ON ccu.constraint_name = tc.constraint_name AND ccu.constraint_schema = tc.constraint_schema WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema()`,
+}
+
+// discoverForeignKeys returns a map of lower-cased table names to the lower-cased table names they depend on
+func discoverForeignKeys(db *sql.DB) map[string][]string {
+	for _, query := range foreignKeyQueries {
+		if deps, err := queryForeignKeys(db, query); err == nil {
+			return deps
+		}
+	}
+	return nil
+}
+
+func queryForeignKeys(db *sql.DB, query string) (deps map[string][]string, err error) {
+	var rows *sql.Rows
+	if rows, err = db.Query(query); err == nil {
+		defer func() {
+			_ = rows.Close()
+		}()
+		deps = make(map[string][]string)
+		for rows.Next() && err == nil {
+			var child, parent string
+			if err = rows.Scan(&child, &parent); err == nil {
+				child = strings.ToLower(child)
+				deps[child] = append(deps[child], strings.ToLower(parent))
+			}
+		}
+		if err == nil {
+			err = rows.Err()
+		}
+	}
+	return deps, err
+}
+
+// orderSeedTables orders the tables so that parent tables come before tables that depend on them (retaining the
+// original order where there are no dependencies)
+func orderSeedTables(tables []*seedTable, deps map[string][]string) ([]*seedTable, error) {
+	if len(deps) == 0 {
+		return tables, nil
+	}
+	present := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		present[strings.ToLower(t.name)] = true
+	}
+	result := make([]*seedTable, 0, len(tables))
+	done := make(map[string]bool, len(tables))
+	for len(result) < len(tables) {
+		added := false
+		for _, t := range tables {
+			name := strings.ToLower(t.name)
+			if done[name] {
+				continue
+			}
+			ready := true
+			for _, parent := range deps[name] {
+				if parent != name && present[parent] && !done[parent] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[name] = true
+				result = append(result, t)
+				added = true
+			}
+		}
+		if !added {
+			remaining := make([]string, 0, len(tables)-len(result))
+			for _, t := range tables {
+				if !done[strings.ToLower(t.name)] {
+					remaining = append(remaining, t.name)
+				}
+			}
+			sort.Strings(remaining)
+			return nil, fmt.Errorf("circular foreign key dependencies between tables: %s", strings.Join(remaining, ", "))
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,135 @@
+package marrow
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-andiamo/marrow/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDbSeed(t *testing.T) {
+	fixtures := fstest.MapFS{
+		"addresses.yaml": &fstest.MapFile{Data: []byte(`
+- person_id: "{$person-id}"
+  address: "Bag End"
+`)},
+		"people.json": &fstest.MapFile{Data: []byte(`[{"name":"Bilbo","$var":"person-id"}]`)},
+		"readme.txt":  &fstest.MapFile{Data: []byte(`ignored`)},
+	}
+	c := DbSeed(Before, "", fixtures)
+	assert.Equal(t, "SEED ", c.(Capture).Name())
+	assert.Equal(t, Before, c.When())
+	assert.NotNil(t, c.Frame())
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"table_name", "referenced_table_name"}).AddRow("ADDRESSES", "People"))
+	mock.ExpectExec("INSERT INTO people").WithArgs("Bilbo").WillReturnResult(sqlmock.NewResult(42, 1))
+	mock.ExpectExec("INSERT INTO addresses").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	ctx := newTestContext(nil)
+	ctx.dbs.register("", db, common.DatabaseArgs{})
+	err = c.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), ctx.vars["person-id"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDbSeed_Returning(t *testing.T) {
+	fixtures := fstest.MapFS{
+		"people.csv": &fstest.MapFile{Data: []byte("name,$var,$id\nBilbo,person-id,person_id\n")},
+	}
+	c := DbSeed(Before, "", fixtures)
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectQuery("SELECT").WillReturnError(errors.New("not mysql"))
+	mock.ExpectQuery("SELECT").WillReturnError(errors.New("not postgres"))
+	mock.ExpectQuery(`INSERT INTO people \(name\) VALUES \(\$1\) RETURNING person_id`).WithArgs("Bilbo").WillReturnRows(sqlmock.NewRows([]string{"person_id"}).AddRow("abc"))
+	ctx := newTestContext(nil)
+	ctx.dbs.register("", db, common.DatabaseArgs{Style: common.NumberedDbArgs, Base: 1})
+	err = c.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", ctx.vars["person-id"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDbSeed_Nulls(t *testing.T) {
+	fixtures := fstest.MapFS{
+		"addresses.yaml": &fstest.MapFile{Data: []byte("- address: ~\n")},
+		"people.json":    &fstest.MapFile{Data: []byte(`[{"nickname":null}]`)},
+	}
+	c := DbSeed(Before, "", fixtures)
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectQuery("SELECT").WillReturnError(errors.New("not mysql"))
+	mock.ExpectQuery("SELECT").WillReturnError(errors.New("not postgres"))
+	mock.ExpectExec("INSERT INTO addresses").WithArgs(nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO people").WithArgs(nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	ctx := newTestContext(nil)
+	ctx.dbs.register("", db, common.DatabaseArgs{})
+	err = c.Run(ctx)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDbSeed_Errors(t *testing.T) {
+	t.Run("unknown db", func(t *testing.T) {
+		c := DbSeed(Before, "", fstest.MapFS{})
+		err := c.Run(newTestContext(nil))
+		require.Error(t, err)
+	})
+	t.Run("bad fixture", func(t *testing.T) {
+		c := DbSeed(Before, "", fstest.MapFS{
+			"people.json": &fstest.MapFile{Data: []byte(`{`)},
+		})
+		db, _, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		ctx := newTestContext(nil)
+		ctx.dbs.register("", db, common.DatabaseArgs{})
+		err = c.Run(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `fixture file "people.json"`)
+	})
+	t.Run("insert fails", func(t *testing.T) {
+		c := DbSeed(Before, "", fstest.MapFS{
+			"people.json": &fstest.MapFile{Data: []byte(`[{"name":"Bilbo"}]`)},
+		})
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"table_name", "referenced_table_name"}))
+		mock.ExpectExec("INSERT INTO people").WillReturnError(errors.New("fooey"))
+		ctx := newTestContext(nil)
+		ctx.dbs.register("", db, common.DatabaseArgs{})
+		err = c.Run(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `seed table "people": fooey`)
+	})
+}
+
+func Test_orderSeedTables(t *testing.T) {
+	tables := []*seedTable{{name: "c"}, {name: "b"}, {name: "a"}, {name: "d"}}
+	ordered, err := orderSeedTables(tables, map[string][]string{
+		"c": {"b", "other"},
+		"b": {"a", "b"},
+	})
+	require.NoError(t, err)
+	names := make([]string, len(ordered))
+	for i, tb := range ordered {
+		names[i] = tb.name
+	}
+	assert.Equal(t, []string{"a", "d", "b", "c"}, names)
+
+	_, err = orderSeedTables(tables, map[string][]string{
+		"a": {"b"},
+		"b": {"a"},
+	})
+	require.Error(t, err)
+	assert.Equal(t, "circular foreign key dependencies between tables: a, b", err.Error())
+}
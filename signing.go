@@ -0,0 +1,333 @@
+package marrow
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AwsCredentials are the AWS credentials used by SignAwsV4
+//
+// each can be any value or resolvable (e.g. Var or TemplateString - such as TemplateString("{$svc:aws:accesskey}"))
+type AwsCredentials struct {
+	AccessKey    any
+	SecretKey    any
+	SessionToken any // optional
+}
+
+// SignAwsV4 returns an authorize function (for use with Method_.Authorize) that signs the built request using
+// AWS Signature Version 4 - e.g. for testing APIs behind API Gateway IAM auth
+//
+// the service and region can be any value or resolvable (e.g. TemplateString("{$svc:aws:region}"))
+//
+// example:
+//
+//	Method(GET, "do get").
+//	    Authorize(SignAwsV4("execute-api", "us-east-1", AwsCredentials{
+//	        AccessKey: Var("access-key"),
+//	        SecretKey: Var("secret-key"),
+//	    }))
+func SignAwsV4(service any, region any, creds AwsCredentials) func(ctx Context) error {
+	return func(ctx Context) (err error) {
+		req := ctx.CurrentRequest()
+		if req == nil {
+			return fmt.Errorf("sign aws v4: no current request")
+		}
+		var sv, rv, akv, skv, tv any
+		if sv, rv, err = ResolveValues(service, region, ctx); err == nil {
+			if akv, skv, err = ResolveValues(creds.AccessKey, creds.SecretKey, ctx); err == nil {
+				if tv, err = ResolveValue(creds.SessionToken, ctx); err == nil {
+					var body []byte
+					if body, err = requestBody(req); err == nil {
+						signer := &awsV4Signer{
+							service:      fmt.Sprintf("%v", sv),
+							region:       fmt.Sprintf("%v", rv),
+							accessKey:    fmt.Sprintf("%v", akv),
+							secretKey:    fmt.Sprintf("%v", skv),
+							sessionToken: optionalString(tv),
+						}
+						signer.sign(req, body, time.Now())
+					}
+				}
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("sign aws v4: %w", err)
+		}
+		return err
+	}
+}
+
+type awsV4Signer struct {
+	service      string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+const (
+	awsV4Algorithm  = "AWS4-HMAC-SHA256"
+	awsV4TimeFormat = "20060102T150405Z"
+	awsV4DateFormat = "20060102"
+)
+
+func (s *awsV4Signer) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(awsV4TimeFormat)
+	payloadHash := hexSha256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+	if s.service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	canonicalHeaders, signedHeaders := s.canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalUri(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{now.Format(awsV4DateFormat), s.region, s.service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		awsV4Algorithm,
+		amzDate,
+		scope,
+		hexSha256([]byte(canonicalRequest)),
+	}, "\n")
+	key := hmacSha256([]byte("AWS4"+s.secretKey), []byte(now.Format(awsV4DateFormat)))
+	key = hmacSha256(key, []byte(s.region))
+	key = hmacSha256(key, []byte(s.service))
+	key = hmacSha256(key, []byte("aws4_request"))
+	signature := hex.EncodeToString(hmacSha256(key, []byte(stringToSign)))
+	req.Header.Set("Authorization", awsV4Algorithm+" Credential="+s.accessKey+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalUri returns the uri encoded path - each path segment is encoded twice (except for s3)
+func (s *awsV4Signer) canonicalUri(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		seg = awsUriEncode(seg)
+		if s.service != "s3" {
+			seg = awsUriEncode(seg)
+		}
+		segments[i] = seg
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalHeaders returns the canonical headers and signed headers - signed headers are "host", "content-type",
+// "content-md5" and any "x-amz-*" headers
+func (s *awsV4Signer) canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lname := strings.ToLower(name)
+		if lname == "content-type" || lname == "content-md5" || strings.HasPrefix(lname, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			headers[lname] = strings.Join(trimmed, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	pairs := make([][2]string, 0, len(query))
+	for k, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, [2]string{awsUriEncode(k), awsUriEncode(v)})
+		}
+	}
+	// sorted by encoded key, then by encoded value (sorting the joined "k=v" would misplace keys that share a prefix)
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	params := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		params = append(params, p[0]+"="+p[1])
+	}
+	return strings.Join(params, "&")
+}
+
+// awsUriEncode uri encodes every byte except the unreserved characters ('A'-'Z', 'a'-'z', '0'-'9', '-', '.', '_' and '~')
+func awsUriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(fmt.Sprintf("%%%02X", c))
+		}
+	}
+	return b.String()
+}
+
+// HmacPart is a part of the canonical string signed by SignHmac
+type HmacPart struct {
+	name   string
+	header string
+}
+
+var (
+	HmacMethod   = HmacPart{name: "method"}   // HmacMethod is the request method (e.g. "POST")
+	HmacPath     = HmacPart{name: "path"}     // HmacPath is the request url path
+	HmacQuery    = HmacPart{name: "query"}    // HmacQuery is the request url raw query
+	HmacBody     = HmacPart{name: "body"}     // HmacBody is the request body
+	HmacBodyHash = HmacPart{name: "bodyhash"} // HmacBodyHash is the hex encoded SHA-256 hash of the request body
+)
+
+// HmacHeader is a request header value part of the canonical string signed by SignHmac
+//
+// Note: the header must be set on the request before signing (e.g. using Method_.RequestHeader)
+func HmacHeader(name string) HmacPart {
+	return HmacPart{name: "header", header: name}
+}
+
+// HmacTimestamp is a timestamp (unix seconds) part of the canonical string signed by SignHmac - the timestamp is
+// also set as the named request header
+func HmacTimestamp(headerName string) HmacPart {
+	return HmacPart{name: "timestamp", header: headerName}
+}
+
+// SignHmac returns an authorize function (for use with Method_.Authorize) that signs the built request using
+// HMAC SHA-256 - e.g. for testing APIs behind webhook-signature middleware
+//
+// the canonical string signed is the canonical parts joined with "\n" - if no canonical parts are specified, only the
+// request body is signed
+//
+// the hex encoded signature is set as the named request header
+//
+// the secret can be any value or resolvable (e.g. Var)
+//
+// example:
+//
+//	Method(POST, "webhook").
+//	    RequestBody(JSON{"event": "created"}).
+//	    Authorize(SignHmac("X-Signature", Var("webhook-secret"), HmacTimestamp("X-Timestamp"), HmacBody))
+func SignHmac(headerName string, secret any, canonicalParts ...HmacPart) func(ctx Context) error {
+	if len(canonicalParts) == 0 {
+		canonicalParts = []HmacPart{HmacBody}
+	}
+	return func(ctx Context) (err error) {
+		req := ctx.CurrentRequest()
+		if req == nil {
+			return fmt.Errorf("sign hmac: no current request")
+		}
+		var sv any
+		if sv, err = ResolveValue(secret, ctx); err == nil {
+			var body []byte
+			if body, err = requestBody(req); err == nil {
+				var key []byte
+				if kb, ok := sv.([]byte); ok {
+					key = kb
+				} else {
+					key = []byte(fmt.Sprintf("%v", sv))
+				}
+				canonical := hmacCanonicalString(req, body, canonicalParts, time.Now())
+				req.Header.Set(headerName, hex.EncodeToString(hmacSha256(key, []byte(canonical))))
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("sign hmac: %w", err)
+		}
+		return err
+	}
+}
+
+func hmacCanonicalString(req *http.Request, body []byte, parts []HmacPart, now time.Time) string {
+	values := make([]string, len(parts))
+	for i, part := range parts {
+		switch part.name {
+		case "method":
+			values[i] = req.Method
+		case "path":
+			values[i] = req.URL.EscapedPath()
+		case "query":
+			values[i] = req.URL.RawQuery
+		case "body":
+			values[i] = string(body)
+		case "bodyhash":
+			values[i] = hexSha256(body)
+		case "header":
+			values[i] = req.Header.Get(part.header)
+		case "timestamp":
+			values[i] = strconv.FormatInt(now.Unix(), 10)
+			req.Header.Set(part.header, values[i])
+		}
+	}
+	return strings.Join(values, "\n")
+}
+
+// requestBody reads the request body - and resets the request body so that it can still be sent
+func requestBody(req *http.Request) (body []byte, err error) {
+	if req.GetBody != nil {
+		var rc io.ReadCloser
+		if rc, err = req.GetBody(); err == nil {
+			defer func() {
+				_ = rc.Close()
+			}()
+			body, err = io.ReadAll(rc)
+		}
+	} else if req.Body != nil && req.Body != http.NoBody {
+		if body, err = io.ReadAll(req.Body); err == nil {
+			_ = req.Body.Close()
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+	}
+	return body, err
+}
+
+func optionalString(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func hexSha256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,170 @@
+package marrow
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAwsV4Signer(t *testing.T) {
+	// test vectors from the AWS Signature Version 4 test suite...
+	signer := &awsV4Signer{
+		service:   "service",
+		region:    "us-east-1",
+		accessKey: "AKIDEXAMPLE",
+		secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	testCases := []struct {
+		method    string
+		url       string
+		expectSig string
+	}{
+		{
+			method:    http.MethodGet,
+			url:       "https://example.amazonaws.com/",
+			expectSig: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			method:    http.MethodGet,
+			url:       "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			expectSig: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			method:    http.MethodPost,
+			url:       "https://example.amazonaws.com/",
+			expectSig: "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.url, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, tc.url, nil)
+			require.NoError(t, err)
+			signer.sign(req, nil, now)
+			assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+			assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature="+tc.expectSig, req.Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestSignAwsV4(t *testing.T) {
+	t.Run("signs", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost:8080/foos/a b", strings.NewReader(`{"foo":"bar"}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		ctx := newTestContext(map[Var]any{"secret": "my-secret"})
+		ctx.currRequest = req
+		fn := SignAwsV4("s3", "eu-west-1", AwsCredentials{AccessKey: "my-key", SecretKey: Var("secret"), SessionToken: "my-token"})
+		err = fn(ctx)
+		require.NoError(t, err)
+		auth := req.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=my-key/"))
+		assert.Contains(t, auth, "/eu-west-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token, Signature=")
+		assert.Equal(t, "my-token", req.Header.Get("X-Amz-Security-Token"))
+		assert.Equal(t, hexSha256([]byte(`{"foo":"bar"}`)), req.Header.Get("X-Amz-Content-Sha256"))
+		// body still readable...
+		data, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"foo":"bar"}`, string(data))
+	})
+	t.Run("no request", func(t *testing.T) {
+		err := SignAwsV4("s3", "eu-west-1", AwsCredentials{})(newTestContext(nil))
+		require.Error(t, err)
+	})
+	t.Run("unresolved", func(t *testing.T) {
+		ctx := newTestContext(nil)
+		ctx.currRequest, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/foos", nil)
+		err := SignAwsV4("s3", "eu-west-1", AwsCredentials{AccessKey: Var("missing")})(ctx)
+		require.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "sign aws v4: "))
+	})
+}
+
+func TestAwsV4Signer_CanonicalUri(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/foo bar/a=b", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/foo%2520bar/a%253Db", (&awsV4Signer{service: "execute-api"}).canonicalUri(req.URL))
+	assert.Equal(t, "/foo%20bar/a%3Db", (&awsV4Signer{service: "s3"}).canonicalUri(req.URL))
+	req.URL.Path = ""
+	assert.Equal(t, "/", (&awsV4Signer{}).canonicalUri(req.URL))
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	u, err := url.Parse("http://localhost/foos?a-b=2&a=1&b=z&b=a&c%20d=x%2Fy&a_b=3")
+	require.NoError(t, err)
+	assert.Equal(t, "a=1&a-b=2&a_b=3&b=a&b=z&c%20d=x%2Fy", canonicalQuery(u))
+	u.RawQuery = ""
+	assert.Equal(t, "", canonicalQuery(u))
+}
+
+func TestSignHmac(t *testing.T) {
+	sign := func(secret string, s string) string {
+		h := hmac.New(sha256.New, []byte(secret))
+		h.Write([]byte(s))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	newRequest := func() *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost:8080/webhooks?a=1", bytes.NewReader([]byte(`{"event":"created"}`)))
+		require.NoError(t, err)
+		req.Header.Set("X-Id", "123")
+		return req
+	}
+	t.Run("body only", func(t *testing.T) {
+		ctx := newTestContext(nil)
+		ctx.currRequest = newRequest()
+		err := SignHmac("X-Signature", "my-secret")(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, sign("my-secret", `{"event":"created"}`), ctx.currRequest.Header.Get("X-Signature"))
+	})
+	t.Run("parts", func(t *testing.T) {
+		ctx := newTestContext(map[Var]any{"secret": []byte("my-secret")})
+		ctx.currRequest = newRequest()
+		err := SignHmac("X-Signature", Var("secret"),
+			HmacMethod, HmacPath, HmacQuery, HmacHeader("X-Id"), HmacTimestamp("X-Timestamp"), HmacBodyHash)(ctx)
+		require.NoError(t, err)
+		ts := ctx.currRequest.Header.Get("X-Timestamp")
+		_, err = strconv.ParseInt(ts, 10, 64)
+		require.NoError(t, err)
+		expect := sign("my-secret", "POST\n/webhooks\na=1\n123\n"+ts+"\n"+hexSha256([]byte(`{"event":"created"}`)))
+		assert.Equal(t, expect, ctx.currRequest.Header.Get("X-Signature"))
+	})
+	t.Run("no request", func(t *testing.T) {
+		err := SignHmac("X-Signature", "my-secret")(newTestContext(nil))
+		require.Error(t, err)
+	})
+	t.Run("unresolved", func(t *testing.T) {
+		ctx := newTestContext(nil)
+		ctx.currRequest = newRequest()
+		err := SignHmac("X-Signature", Var("missing"))(ctx)
+		require.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "sign hmac: "))
+	})
+}
+
+func TestRequestBody(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://localhost", io.NopCloser(strings.NewReader("foo")))
+	require.NoError(t, err)
+	require.Nil(t, req.GetBody)
+	body, err := requestBody(req)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(body))
+	data, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(data))
+
+	req, err = http.NewRequest(http.MethodGet, "http://localhost", nil)
+	require.NoError(t, err)
+	body, err = requestBody(req)
+	require.NoError(t, err)
+	assert.Empty(t, body)
+}
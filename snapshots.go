@@ -0,0 +1,289 @@
+package marrow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/go-andiamo/marrow/framing"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SnapshotsDir is the directory (relative to the test file declaring the snapshot expectation) where snapshots are stored
+	SnapshotsDir = "testdata/__snapshots__"
+	// UpdateSnapshotsEnv is the environment variable that, when set to true, causes snapshots to be rewritten
+	UpdateSnapshotsEnv = "MARROW_UPDATE_SNAPSHOTS"
+	// Redacted is the value that redacted values are replaced with in snapshots
+	Redacted = "<redacted>"
+)
+
+// SnapshotOptions are the options used by ExpectSnapshot
+type SnapshotOptions struct {
+	// RedactPaths are json paths of values that are redacted (replaced with "<redacted>") before comparing/storing
+	// (e.g. "$.id", "$.items[*].createdAt")
+	//
+	// see JsonCompareOptions.IgnorePaths for path syntax
+	RedactPaths []string
+	// RedactPatterns are regular expressions - any matches in string values (or text values) are redacted (replaced with "<redacted>")
+	// before comparing/storing
+	RedactPatterns []string
+	// Dir overrides the directory in which the snapshot is stored
+	Dir string
+}
+
+// SnapshotTextDiff describes the line differences between a stored text snapshot and the actual text
+//
+// each line is prefixed with "-" (line in snapshot but not in actual) or "+" (line in actual but not in snapshot)
+type SnapshotTextDiff []string
+
+func (d SnapshotTextDiff) stringify() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d lines differ", len(d)))
+	for _, line := range d {
+		b.WriteString("\n\t          \t" + line)
+	}
+	return b.String()
+}
+
+type snapshot struct {
+	name    string
+	value   any
+	options SnapshotOptions
+	frame   *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*snapshot)(nil)
+
+// ExpectSnapshot asserts that the resolved value (typically Body) matches a stored golden file snapshot
+//
+// the snapshot is stored in the "testdata/__snapshots__" directory next to the test file that declares the expectation -
+// json values (maps, slices, structs etc.) are stored as indented json in "<name>.json" and string/[]byte values are stored
+// as text in "<name>.txt"
+//
+// if the snapshot does not yet exist, it is written (and the expectation is met)
+//
+// snapshots are rewritten (rather than compared) when tests are run with the environment variable
+// MARROW_UPDATE_SNAPSHOTS=true - or with the "-update" flag, where the test package declares that flag
+// (e.g. var update = flag.Bool("update", false, "update golden files"))
+//
+// if the value does not match, the unmet error actual value is a JsonDiff (for json values) or a SnapshotTextDiff (for text values)
+//
+//go:noinline
+func ExpectSnapshot(name string, value any, options ...SnapshotOptions) Expectation {
+	return &snapshot{
+		name:    name,
+		value:   value,
+		options: firstSnapshotOptions(options),
+		frame:   framing.NewFrame(0),
+	}
+}
+
+func firstSnapshotOptions(options []SnapshotOptions) SnapshotOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return SnapshotOptions{}
+}
+
+func (e *snapshot) Name() string {
+	return "Expect Snapshot " + e.name
+}
+
+func (e *snapshot) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *snapshot) Met(ctx Context) (unmet error, err error) {
+	var av any
+	if av, err = ResolveValue(e.value, ctx); err == nil {
+		var r *snapshotRedactor
+		if r, err = newSnapshotRedactor(e.options); err == nil {
+			text, isText := snapshotText(av)
+			filename := e.filename(isText)
+			var data []byte
+			if isText {
+				text = r.redactText(text)
+				data = []byte(text)
+			} else if av, err = normalizeJsonValue(av); err == nil {
+				av = r.redact(jsonPath{}, av)
+				var buf bytes.Buffer
+				enc := json.NewEncoder(&buf)
+				enc.SetEscapeHTML(false)
+				enc.SetIndent("", "  ")
+				if err = enc.Encode(av); err == nil {
+					data = buf.Bytes()
+				}
+			}
+			if err == nil {
+				var stored []byte
+				if stored, err = os.ReadFile(filename); err == nil && !updateSnapshots() {
+					var diff stringy
+					if diff, err = e.diff(isText, stored, text, av); err == nil && diff != nil {
+						unmet = &unmetError{
+							msg:      fmt.Sprintf("expected snapshot %q", e.name),
+							name:     e.Name(),
+							expected: OperandValue{Original: filename},
+							actual:   OperandValue{Original: e.value, Resolved: diff},
+							frame:    e.frame,
+						}
+					}
+				} else if err == nil || errors.Is(err, fs.ErrNotExist) {
+					if err = os.MkdirAll(filepath.Dir(filename), 0755); err == nil {
+						err = os.WriteFile(filename, data, 0644)
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+func (e *snapshot) diff(isText bool, stored []byte, text string, av any) (stringy, error) {
+	if isText {
+		if diff := snapshotLineDiff(string(stored), text); len(diff) > 0 {
+			return diff, nil
+		}
+		return nil, nil
+	}
+	sv, err := normalizeJsonValue(stored)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot %q: %w", e.name, err)
+	}
+	c, _ := newJsonComparer(false, JsonCompareOptions{})
+	if diff := c.compare(jsonPath{}, sv, av); len(diff) > 0 {
+		return diff, nil
+	}
+	return nil, nil
+}
+
+func (e *snapshot) filename(isText bool) string {
+	dir := e.options.Dir
+	if dir == "" {
+		dir = SnapshotsDir
+		if e.frame != nil && filepath.IsAbs(e.frame.File) {
+			dir = filepath.Join(filepath.Dir(e.frame.File), SnapshotsDir)
+		}
+	}
+	ext := ".json"
+	if isText {
+		ext = ".txt"
+	}
+	return filepath.Join(dir, snapshotFileRegex.ReplaceAllString(e.name, "_")+ext)
+}
+
+var snapshotFileRegex = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+func snapshotText(v any) (string, bool) {
+	switch vt := v.(type) {
+	case string:
+		return vt, true
+	case []byte:
+		return string(vt), true
+	}
+	return "", false
+}
+
+func updateSnapshots() bool {
+	if f := flag.Lookup("update"); f != nil {
+		if b, err := strconv.ParseBool(f.Value.String()); err == nil && b {
+			return true
+		}
+	}
+	b, _ := strconv.ParseBool(os.Getenv(UpdateSnapshotsEnv))
+	return b
+}
+
+type snapshotRedactor struct {
+	paths    []jsonPath
+	patterns []*regexp.Regexp
+}
+
+func newSnapshotRedactor(options SnapshotOptions) (*snapshotRedactor, error) {
+	result := &snapshotRedactor{}
+	for _, rp := range options.RedactPaths {
+		p, err := parseJsonPath(rp)
+		if err != nil {
+			return nil, err
+		}
+		result.paths = append(result.paths, p)
+	}
+	for _, rp := range options.RedactPatterns {
+		rx, err := regexp.Compile(rp)
+		if err != nil {
+			return nil, err
+		}
+		result.patterns = append(result.patterns, rx)
+	}
+	return result, nil
+}
+
+func (r *snapshotRedactor) redact(path jsonPath, v any) any {
+	for _, rp := range r.paths {
+		if path.matches(rp) {
+			return Redacted
+		}
+	}
+	switch vt := v.(type) {
+	case string:
+		return r.redactText(vt)
+	case map[string]any:
+		for k, mv := range vt {
+			vt[k] = r.redact(path.property(k), mv)
+		}
+	case []any:
+		for i, sv := range vt {
+			vt[i] = r.redact(path.element(i), sv)
+		}
+	}
+	return v
+}
+
+func (r *snapshotRedactor) redactText(s string) string {
+	for _, rx := range r.patterns {
+		s = rx.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
+
+// snapshotLineDiff produces a minimal line diff (using longest common subsequence)
+func snapshotLineDiff(expected string, actual string) SnapshotTextDiff {
+	el := strings.Split(expected, "\n")
+	al := strings.Split(actual, "\n")
+	lcs := make([][]int, len(el)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(al)+1)
+	}
+	for i := len(el) - 1; i >= 0; i-- {
+		for j := len(al) - 1; j >= 0; j-- {
+			if el[i] == al[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var diff SnapshotTextDiff
+	i, j := 0, 0
+	for i < len(el) || j < len(al) {
+		switch {
+		case i < len(el) && j < len(al) && el[i] == al[j]:
+			i++
+			j++
+		case j < len(al) && (i == len(el) || lcs[i][j+1] >= lcs[i+1][j]):
+			diff = append(diff, "+ "+al[j])
+			j++
+		default:
+			diff = append(diff, "- "+el[i])
+			i++
+		}
+	}
+	return diff
+}
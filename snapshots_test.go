@@ -0,0 +1,145 @@
+package marrow
+
+import (
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpectSnapshot(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		dir := t.TempDir()
+		opts := SnapshotOptions{Dir: dir, RedactPaths: []string{"$.id", "$.items[*].createdAt"}}
+		ctx := newTestContext(nil)
+		ctx.currBody = map[string]any{
+			"id":    "abc",
+			"name":  "foo",
+			"items": []any{map[string]any{"createdAt": "today", "qty": int64(1)}},
+		}
+		exp := ExpectSnapshot("get foos", Body, opts)
+		assert.Equal(t, "Expect Snapshot get foos", exp.Name())
+		assert.NotNil(t, exp.Frame())
+		assert.False(t, exp.IsRequired())
+		// first run writes snapshot...
+		unmet, err := exp.Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+		data, err := os.ReadFile(filepath.Join(dir, "get_foos.json"))
+		require.NoError(t, err)
+		assert.Equal(t, "{\n  \"id\": \"<redacted>\",\n  \"items\": [\n    {\n      \"createdAt\": \"<redacted>\",\n      \"qty\": 1\n    }\n  ],\n  \"name\": \"foo\"\n}\n", string(data))
+		// body not modified by redaction...
+		assert.Equal(t, "abc", ctx.currBody.(map[string]any)["id"])
+
+		// volatile values changed - still met...
+		ctx.currBody = map[string]any{
+			"id":    "def",
+			"name":  "foo",
+			"items": []any{map[string]any{"createdAt": "tomorrow", "qty": int64(1)}},
+		}
+		unmet, err = exp.Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+
+		ctx.currBody = map[string]any{
+			"id":    "def",
+			"name":  "bar",
+			"items": []any{},
+		}
+		unmet, err = exp.Met(ctx)
+		require.NoError(t, err)
+		require.Error(t, unmet)
+		assert.Equal(t, `expected snapshot "get foos"`, unmet.Error())
+		uerr := unmet.(UnmetError)
+		diff, ok := uerr.Actual().Resolved.(JsonDiff)
+		require.True(t, ok)
+		require.Len(t, diff, 2)
+		assert.Contains(t, uerr.TestFormat(), `$.items[0]: missing, expected {"createdAt":"<redacted>","qty":1}`)
+		assert.Contains(t, uerr.TestFormat(), `$.name: expected "foo", actual "bar"`)
+		assert.Contains(t, uerr.TestFormat(), filepath.Join(dir, "get_foos.json"))
+
+		// update...
+		t.Setenv(UpdateSnapshotsEnv, "true")
+		unmet, err = exp.Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+		data, err = os.ReadFile(filepath.Join(dir, "get_foos.json"))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"bar"`)
+	})
+	t.Run("text", func(t *testing.T) {
+		dir := t.TempDir()
+		opts := SnapshotOptions{Dir: dir, RedactPatterns: []string{`\d{4}-\d{2}-\d{2}`}}
+		ctx := newTestContext(map[Var]any{"text": "line 1\nline 2 2025-01-02\nline 3"})
+		exp := ExpectSnapshot("text", Var("text"), opts)
+		unmet, err := exp.Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+		data, err := os.ReadFile(filepath.Join(dir, "text.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "line 1\nline 2 <redacted>\nline 3", string(data))
+
+		ctx.SetVar("text", []byte("line 1\nline 2 2026-01-01\nline 3"))
+		unmet, err = exp.Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+
+		ctx.SetVar("text", "line 1\nline two\nline 3\nline 4")
+		unmet, err = exp.Met(ctx)
+		require.NoError(t, err)
+		require.Error(t, unmet)
+		diff, ok := unmet.(UnmetError).Actual().Resolved.(SnapshotTextDiff)
+		require.True(t, ok)
+		assert.Equal(t, SnapshotTextDiff{"+ line two", "- line 2 <redacted>", "+ line 4"}, diff)
+		assert.Equal(t, "3 lines differ\n\t          \t+ line two\n\t          \t- line 2 <redacted>\n\t          \t+ line 4", diff.stringify())
+	})
+	t.Run("invalid stored snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{`), 0644))
+		_, err := ExpectSnapshot("bad", JSON{}, SnapshotOptions{Dir: dir}).Met(newTestContext(nil))
+		require.Error(t, err)
+	})
+	t.Run("bad redactions", func(t *testing.T) {
+		_, err := ExpectSnapshot("bad", JSON{}, SnapshotOptions{RedactPaths: []string{"$["}}).Met(newTestContext(nil))
+		require.Error(t, err)
+		_, err = ExpectSnapshot("bad", JSON{}, SnapshotOptions{RedactPatterns: []string{"("}}).Met(newTestContext(nil))
+		require.Error(t, err)
+	})
+	t.Run("unresolved", func(t *testing.T) {
+		_, err := ExpectSnapshot("bad", Var("missing")).Met(newTestContext(nil))
+		require.Error(t, err)
+	})
+}
+
+// declaring an "-update" flag (the usual golden file idiom) must not conflict with marrow
+var updateFlag = flag.Bool("update", false, "update golden files")
+
+func TestUpdateSnapshots(t *testing.T) {
+	assert.False(t, updateSnapshots())
+	require.NoError(t, flag.Set("update", "true"))
+	defer func() {
+		*updateFlag = false
+	}()
+	assert.True(t, updateSnapshots())
+	*updateFlag = false
+	t.Setenv(UpdateSnapshotsEnv, "true")
+	assert.True(t, updateSnapshots())
+}
+
+func TestSnapshot_Filename(t *testing.T) {
+	exp := ExpectSnapshot("foo/bar baz", Body).(*snapshot)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(wd, SnapshotsDir, "foo_bar_baz.json"), exp.filename(false))
+	assert.Equal(t, filepath.Join(wd, SnapshotsDir, "foo_bar_baz.txt"), exp.filename(true))
+	exp.frame = nil
+	assert.Equal(t, filepath.Join(SnapshotsDir, "foo_bar_baz.json"), exp.filename(false))
+}
+
+func TestSnapshotLineDiff(t *testing.T) {
+	assert.Empty(t, snapshotLineDiff("a\nb", "a\nb"))
+	assert.Equal(t, SnapshotTextDiff{"- b"}, snapshotLineDiff("a\nb\nc", "a\nc"))
+	assert.Equal(t, SnapshotTextDiff{"+ b"}, snapshotLineDiff("a\nc", "a\nb\nc"))
+}
@@ -1,6 +1,7 @@
 package marrow
 
 import (
+	"crypto/tls"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"github.com/go-andiamo/marrow/mocks/service"
 	htesting "github.com/go-andiamo/marrow/testing"
 	"github.com/go-andiamo/marrow/with"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"io"
 	"net/http"
 	"os"
@@ -45,6 +47,7 @@ func Suite(endpoints ...Endpoint_) Suite_ {
 		cookies:      make(map[string]*http.Cookie),
 		mockServices: make(map[string]service.MockedService),
 		images:       make(map[string]with.Image),
+		codecs:       make(codecRegistry),
 	}
 }
 
@@ -62,6 +65,9 @@ type suite struct {
 	reportCov     func(*coverage.Coverage)
 	covCollector  coverage.Collector
 	oasReader     io.Reader
+	gqlReader     io.Reader
+	trafficPath   string
+	trafficRedact []string
 	repeats       int
 	repeatResets  []func()
 	stopOnFailure bool
@@ -70,6 +76,10 @@ type suite struct {
 	shutdowns     []func()
 	mockServices  map[string]service.MockedService
 	images        map[string]with.Image
+	codecs        codecRegistry
+	grpcTarget    string
+	grpcDescs     []protoreflect.FileDescriptor
+	grpcTLS       *tls.Config
 	orderedImages []with.Image
 	apiImage      with.ImageApi
 	mutex         sync.RWMutex
@@ -137,6 +147,19 @@ func (s *suite) SetOAS(r io.Reader) {
 	s.oasReader = r
 }
 
+func (s *suite) SetGraphQLSchema(r io.Reader) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.gqlReader = r
+}
+
+func (s *suite) SetRecordTraffic(path string, redactHeaders ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.trafficPath = path
+	s.trafficRedact = redactHeaders
+}
+
 func (s *suite) SetRepeats(n int, stopOnFailure bool, resets ...func()) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -188,6 +211,25 @@ func (s *suite) SetTraceTimings(collect bool) {
 	s.traceTimings = collect
 }
 
+func (s *suite) AddCodec(mediaType string, codec common.Codec) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.codecs.register(mediaType, codec)
+}
+
+func (s *suite) SetGrpc(target string, descriptors ...protoreflect.FileDescriptor) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.grpcTarget = target
+	s.grpcDescs = append(s.grpcDescs, descriptors...)
+}
+
+func (s *suite) SetGrpcTLS(config *tls.Config) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.grpcTLS = config
+}
+
 func (s *suite) ResolveEnv(v any) (string, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -368,6 +410,13 @@ func (s *suite) Run() error {
 			return err
 		}
 	}
+	if s.gqlReader != nil {
+		if gqc, ok := cov.(coverage.GraphQLCollector); ok {
+			if err := gqc.LoadGraphQLSchema(s.gqlReader); err != nil {
+				return err
+			}
+		}
+	}
 	t := htesting.NewHelper(s.testing, s.stdout, s.stderr)
 	s.finalizeContext(ctx, cov, t)
 	for _, e := range s.endpoints {
@@ -408,10 +457,14 @@ func (s *suite) Run() error {
 		s.reportCov(actualCov)
 	}
 	ctx.stopListeners()
+	ctx.grpc.close()
+	if ctx.traffic != nil {
+		err = ctx.traffic.write(s.trafficPath)
+	}
 	for _, sdfn := range s.shutdowns {
 		sdfn()
 	}
-	return nil
+	return err
 }
 
 func (s *suite) runInits() (*context, error) {
@@ -503,6 +556,13 @@ func (s *suite) finalizeContext(ctx *context, cov coverage.Collector, t htesting
 	ctx.host = fmt.Sprintf("http://%s:%d", host, s.port)
 	ctx.apiImage = s.apiImage
 	ctx.testing = t
+	for k, v := range s.codecs {
+		ctx.codecs[k] = v
+	}
+	ctx.grpc = newGrpcClient(s.grpcTarget, s.grpcTLS, s.grpcDescs)
+	if s.trafficPath != "" {
+		ctx.traffic = newTrafficRecorder(s.trafficRedact)
+	}
 	for k, v := range s.cookies {
 		ctx.cookieJar[k] = v
 	}
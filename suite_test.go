@@ -371,6 +371,54 @@ func TestWithLogging(t *testing.T) {
 	assert.Equal(t, nw, raw.stderr)
 }
 
+func TestWithGrpc(t *testing.T) {
+	host := startTestGrpcServer(t, false)
+	var cov *coverage.Coverage
+	s := Suite(Endpoint("/marrow.test.Things", "",
+		GrpcMethod("GetThing", "").
+			RequestBody(JSON{"id": "abc"}).
+			AssertOK(),
+	)).Init(
+		with.Grpc(host, testGrpcFile(t)),
+		with.ReportCoverage(func(coverage *coverage.Coverage) {
+			cov = coverage
+		}),
+		with.Logging(&nullWriter{}, &nullWriter{}),
+	)
+	raw, ok := s.(*suite)
+	require.True(t, ok)
+	err := s.Run()
+	require.NoError(t, err)
+	assert.Equal(t, host, raw.grpcTarget)
+	assert.Len(t, raw.grpcDescs, 1)
+	require.NotNil(t, cov)
+	assert.Empty(t, cov.Failures)
+	assert.Len(t, cov.Met, 1)
+}
+
+func TestWithGraphQLSchema(t *testing.T) {
+	var cov *coverage.Coverage
+	s := Suite().Init(
+		with.GraphQLSchema(strings.NewReader(`type Query { users: [String!]! }`)),
+		with.ReportCoverage(func(coverage *coverage.Coverage) {
+			cov = coverage
+		}),
+		with.Logging(&nullWriter{}, &nullWriter{}),
+	)
+	err := s.Run()
+	require.NoError(t, err)
+	require.NotNil(t, cov)
+	assert.NotNil(t, cov.GraphQLSchema)
+
+	s = Suite().Init(
+		with.GraphQLSchema(strings.NewReader(`type Query { users: Unknown }`)),
+		with.ReportCoverage(func(coverage *coverage.Coverage) {}),
+		with.Logging(&nullWriter{}, &nullWriter{}),
+	)
+	err = s.Run()
+	require.Error(t, err)
+}
+
 func TestAddSupportingImage(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		img := &mockImage{}
@@ -0,0 +1,451 @@
+package marrow
+
+import (
+	"fmt"
+	"github.com/go-andiamo/marrow/framing"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TableRowsOptions are the options used by ExpectTableRows, ExpectDbChanged and ExpectDbUnchanged
+type TableRowsOptions struct {
+	// KeyColumns are the columns that identify a row (defaults to "id" - if all rows have an "id" column)
+	//
+	// if not all rows have all the key columns, rows are matched by their compared column values (so changed rows are
+	// reported as deleted and inserted rather than updated)
+	KeyColumns []string
+	// IgnoreColumns are the columns that are ignored when comparing rows (e.g. timestamp columns)
+	IgnoreColumns []string
+}
+
+// TableDiff describes the differences between two sets of table rows
+//
+// for ExpectTableRows, Inserted are the actual rows that were not expected and Deleted are the expected rows that were not found
+type TableDiff struct {
+	Inserted []map[string]any
+	Updated  []TableRowChange
+	Deleted  []map[string]any
+}
+
+// TableRowChange describes a row that was changed
+type TableRowChange struct {
+	Before  map[string]any
+	After   map[string]any
+	Columns []string // the names of the columns that changed
+}
+
+// IsEmpty returns true if there were no differences
+func (d TableDiff) IsEmpty() bool {
+	return len(d.Inserted) == 0 && len(d.Updated) == 0 && len(d.Deleted) == 0
+}
+
+func (d TableDiff) stringify() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d inserted, %d updated, %d deleted", len(d.Inserted), len(d.Updated), len(d.Deleted)))
+	for _, row := range d.Inserted {
+		b.WriteString("\n\t          \t+ " + stringifyRow(row))
+	}
+	for _, chg := range d.Updated {
+		b.WriteString("\n\t          \t~ " + stringifyRow(chg.Before) + " -> " + stringifyRow(chg.After))
+	}
+	for _, row := range d.Deleted {
+		b.WriteString("\n\t          \t- " + stringifyRow(row))
+	}
+	return b.String()
+}
+
+func stringifyRow(row map[string]any) string {
+	cols := make([]string, 0, len(row))
+	for k := range row {
+		cols = append(cols, k)
+	}
+	sort.Strings(cols)
+	var b strings.Builder
+	b.WriteRune('{')
+	for i, col := range cols {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(col + ": " + fmt.Sprintf("%v", row[col]))
+	}
+	b.WriteRune('}')
+	return b.String()
+}
+
+type tableRows struct {
+	dbName   string
+	table    string
+	where    string
+	expected any
+	options  TableRowsOptions
+	frame    *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*tableRows)(nil)
+
+// ExpectTableRows asserts that the rows in a database table (optionally filtered by the where condition) match the expected rows
+//
+// the expected rows can be []map[string]any, []Columns, JSONArray (of JSON objects) or anything that resolves to these -
+// only the columns in each expected row are compared (less any TableRowsOptions.IgnoreColumns)
+//
+// the where condition can contain templates (e.g. "id = '{$id}'")
+//
+// if the rows do not match, the unmet error actual value is a TableDiff
+//
+// Note: when only one database is used by tests, the dbName can be ""
+//
+//go:noinline
+func ExpectTableRows(dbName string, table string, where string, expectedRows any, options ...TableRowsOptions) Expectation {
+	return &tableRows{
+		dbName:   dbName,
+		table:    table,
+		where:    where,
+		expected: expectedRows,
+		options:  firstTableRowsOptions(options),
+		frame:    framing.NewFrame(0),
+	}
+}
+
+func (e *tableRows) Name() string {
+	return "Expect Table Rows " + e.table
+}
+
+func (e *tableRows) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *tableRows) Met(ctx Context) (unmet error, err error) {
+	var expected []map[string]any
+	if expected, err = resolveExpectedRows(e.expected, ctx); err == nil {
+		var actual []map[string]any
+		if actual, err = fetchTableRows(ctx, e.dbName, e.table, e.where); err == nil {
+			var diff TableDiff
+			if diff, err = diffTableRows(expected, actual, e.options); err == nil && !diff.IsEmpty() {
+				unmet = &unmetError{
+					msg:      fmt.Sprintf("expected table %q rows", e.table),
+					name:     e.Name(),
+					expected: OperandValue{Original: e.expected, Resolved: expected},
+					actual:   OperandValue{Original: actual, Resolved: diff},
+					frame:    e.frame,
+				}
+			}
+		}
+	}
+	return
+}
+
+type dbChanged struct {
+	dbName     string
+	table      string
+	options    TableRowsOptions
+	unchanged  bool
+	beforeRows []map[string]any
+	frame      *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*dbChanged)(nil)
+var _ beforeExpectation = (*dbChanged)(nil)
+
+// ExpectDbChanged asserts that the rows in a database table were changed by the request
+//
+// the table rows are captured before the request is made and compared with the table rows after
+//
+// the unmet error actual value is a TableDiff
+//
+// Note: when only one database is used by tests, the dbName can be ""
+//
+//go:noinline
+func ExpectDbChanged(dbName string, table string, options ...TableRowsOptions) Expectation {
+	return &dbChanged{
+		dbName:  dbName,
+		table:   table,
+		options: firstTableRowsOptions(options),
+		frame:   framing.NewFrame(0),
+	}
+}
+
+// ExpectDbUnchanged asserts that the rows in a database table were not changed by the request
+//
+// the table rows are captured before the request is made and compared with the table rows after
+//
+// the unmet error actual value is a TableDiff (describing the inserted, updated and deleted rows)
+//
+// Note: when only one database is used by tests, the dbName can be ""
+//
+//go:noinline
+func ExpectDbUnchanged(dbName string, table string, options ...TableRowsOptions) Expectation {
+	return &dbChanged{
+		dbName:    dbName,
+		table:     table,
+		options:   firstTableRowsOptions(options),
+		unchanged: true,
+		frame:     framing.NewFrame(0),
+	}
+}
+
+func (e *dbChanged) Name() string {
+	if e.unchanged {
+		return "Expect Db Unchanged " + e.table
+	}
+	return "Expect Db Changed " + e.table
+}
+
+func (e *dbChanged) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *dbChanged) before(ctx Context) (err error) {
+	e.beforeRows, err = fetchTableRows(ctx, e.dbName, e.table, "")
+	return err
+}
+
+func (e *dbChanged) Met(ctx Context) (unmet error, err error) {
+	var after []map[string]any
+	if after, err = fetchTableRows(ctx, e.dbName, e.table, ""); err == nil {
+		var diff TableDiff
+		if diff, err = diffTableRows(e.beforeRows, after, e.options); err == nil && diff.IsEmpty() != e.unchanged {
+			msg := fmt.Sprintf("expected table %q to have changed", e.table)
+			if e.unchanged {
+				msg = fmt.Sprintf("expected table %q to be unchanged", e.table)
+			}
+			unmet = &unmetError{
+				msg:      msg,
+				name:     e.Name(),
+				expected: OperandValue{Original: e.beforeRows, Resolved: TableDiff{}},
+				actual:   OperandValue{Original: after, Resolved: diff},
+				frame:    e.frame,
+			}
+		}
+	}
+	return
+}
+
+// beforeExpectation is implemented by expectations that need to capture state before the request is made
+type beforeExpectation interface {
+	Expectation
+	before(ctx Context) error
+}
+
+// expectationBefore is the before (pre-capture) for a beforeExpectation
+type expectationBefore struct {
+	exp beforeExpectation
+}
+
+var _ Capture = (*expectationBefore)(nil)
+
+func (b *expectationBefore) Name() string {
+	return "BEFORE " + b.exp.Name()
+}
+
+func (b *expectationBefore) Run(ctx Context) error {
+	return wrapCaptureError(b.exp.before(ctx), "", b)
+}
+
+func (b *expectationBefore) Frame() *framing.Frame {
+	return b.exp.Frame()
+}
+
+func firstTableRowsOptions(options []TableRowsOptions) TableRowsOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return TableRowsOptions{}
+}
+
+func fetchTableRows(ctx Context, dbName string, table string, where string) ([]map[string]any, error) {
+	query := "SELECT * FROM " + table
+	if where != "" {
+		query += " WHERE " + where
+	}
+	av, err := QueryRows(dbName, query).ResolveValue(ctx)
+	if err == nil {
+		if rows, ok := av.([]map[string]any); ok {
+			return rows, nil
+		}
+	}
+	return nil, err
+}
+
+func resolveExpectedRows(expected any, ctx Context) (result []map[string]any, err error) {
+	var av any
+	if av, err = ResolveValue(expected, ctx); err == nil {
+		var rows []any
+		switch avt := av.(type) {
+		case []map[string]any:
+			for _, r := range avt {
+				rows = append(rows, r)
+			}
+		case []Columns:
+			for _, r := range avt {
+				rows = append(rows, r)
+			}
+		case []JSON:
+			for _, r := range avt {
+				rows = append(rows, r)
+			}
+		case []any:
+			rows = avt
+		case JSONArray:
+			rows = avt
+		case nil:
+		default:
+			return nil, fmt.Errorf("cannot use %T as expected rows", av)
+		}
+		result = make([]map[string]any, 0, len(rows))
+		for _, r := range rows {
+			var row map[string]any
+			switch rt := r.(type) {
+			case map[string]any:
+				row = rt
+			case Columns:
+				row = rt
+			case JSON:
+				row = rt
+			default:
+				return nil, fmt.Errorf("cannot use %T as expected row", r)
+			}
+			var rv any
+			if rv, err = resolveMap(row, ctx); err != nil {
+				return nil, err
+			}
+			result = append(result, rv.(map[string]any))
+		}
+	}
+	return result, err
+}
+
+// diffTableRows compares the before rows with the after rows
+//
+// only columns in the before rows are compared (less any ignored columns)
+//
+// rows are matched by their key columns - where not all rows have the key columns, rows are matched by their compared
+// column values (regardless of order) and unmatched rows are reported as inserted or deleted
+func diffTableRows(before []map[string]any, after []map[string]any, options TableRowsOptions) (diff TableDiff, err error) {
+	ignored := make(map[string]bool, len(options.IgnoreColumns))
+	for _, col := range options.IgnoreColumns {
+		ignored[col] = true
+	}
+	keyCols := options.KeyColumns
+	if len(keyCols) == 0 {
+		keyCols = []string{"id"}
+	}
+	if !allRowsHaveColumns(before, keyCols) || !allRowsHaveColumns(after, keyCols) {
+		return diffUnkeyedTableRows(before, after, ignored), nil
+	}
+	rowKey := func(row map[string]any) string {
+		parts := make([]string, len(keyCols))
+		for i, col := range keyCols {
+			parts[i] = fmt.Sprintf("%v", row[col])
+		}
+		return strings.Join(parts, "\x00")
+	}
+	afterRows := make(map[string]map[string]any, len(after))
+	afterKeys := make([]string, 0, len(after))
+	for _, row := range after {
+		k := rowKey(row)
+		if _, dup := afterRows[k]; dup {
+			return diff, fmt.Errorf("duplicate row key %s", stringifyKey(keyCols, row))
+		}
+		afterRows[k] = row
+		afterKeys = append(afterKeys, k)
+	}
+	seen := make(map[string]bool, len(before))
+	for _, row := range before {
+		k := rowKey(row)
+		if seen[k] {
+			return diff, fmt.Errorf("duplicate row key %s", stringifyKey(keyCols, row))
+		}
+		seen[k] = true
+		if afterRow, ok := afterRows[k]; ok {
+			if changed := changedColumns(row, afterRow, ignored); len(changed) > 0 {
+				diff.Updated = append(diff.Updated, TableRowChange{
+					Before:  row,
+					After:   afterRow,
+					Columns: changed,
+				})
+			}
+		} else {
+			diff.Deleted = append(diff.Deleted, row)
+		}
+	}
+	for _, k := range afterKeys {
+		if !seen[k] {
+			diff.Inserted = append(diff.Inserted, afterRows[k])
+		}
+	}
+	return diff, nil
+}
+
+// diffUnkeyedTableRows matches each before row with the first unmatched after row that has the same compared column values
+func diffUnkeyedTableRows(before []map[string]any, after []map[string]any, ignored map[string]bool) (diff TableDiff) {
+	matched := make([]bool, len(after))
+	for _, row := range before {
+		found := false
+		for i, afterRow := range after {
+			if !matched[i] && len(changedColumns(row, afterRow, ignored)) == 0 {
+				matched[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff.Deleted = append(diff.Deleted, row)
+		}
+	}
+	for i, row := range after {
+		if !matched[i] {
+			diff.Inserted = append(diff.Inserted, row)
+		}
+	}
+	return diff
+}
+
+func changedColumns(row map[string]any, afterRow map[string]any, ignored map[string]bool) []string {
+	changed := make([]string, 0)
+	for col, v := range row {
+		if !ignored[col] && !dbValuesEqual(v, afterRow[col]) {
+			changed = append(changed, col)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func stringifyKey(keyCols []string, row map[string]any) string {
+	key := make(map[string]any, len(keyCols))
+	for _, col := range keyCols {
+		key[col] = row[col]
+	}
+	return stringifyRow(key)
+}
+
+func allRowsHaveColumns(rows []map[string]any, cols []string) bool {
+	for _, row := range rows {
+		for _, col := range cols {
+			if _, ok := row[col]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func dbValuesEqual(v1 any, v2 any) bool {
+	if v1 == nil || v2 == nil {
+		return v1 == nil && v2 == nil
+	}
+	if t1, ok := v1.(time.Time); ok {
+		if t2, ok := v2.(time.Time); ok {
+			return t1.Equal(t2)
+		}
+	}
+	c := &comparator{v1: v1, v2: v2, comp: compEqual}
+	if unmet, err := c.Met(nil); unmet == nil && err == nil {
+		return true
+	}
+	return reflect.DeepEqual(v1, v2)
+}
@@ -0,0 +1,232 @@
+package marrow
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-andiamo/marrow/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestExpectTableRows(t *testing.T) {
+	t.Run("met", func(t *testing.T) {
+		exp := ExpectTableRows("", "people", "", []Columns{
+			{"id": 1, "name": "Bilbo"},
+			{"id": 2, "name": "Frodo"},
+		})
+		assert.Equal(t, "Expect Table Rows people", exp.Name())
+		assert.NotNil(t, exp.Frame())
+		assert.False(t, exp.IsRequired())
+
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectQuery(`SELECT \* FROM people`).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "age"}).
+			AddRow(2, "Frodo", 50).
+			AddRow(1, "Bilbo", 111))
+		ctx := newTestContext(nil)
+		ctx.dbs.register("", db, common.DatabaseArgs{})
+		unmet, err := exp.Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+	})
+	t.Run("with where", func(t *testing.T) {
+		exp := ExpectTableRows("", "people", "id = {$id}", JSONArray{JSON{"id": 1, "name": "Bilbo"}})
+
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectQuery(`SELECT \* FROM people WHERE id = 1`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Bilbo"))
+		ctx := newTestContext(map[Var]any{"id": 1})
+		ctx.dbs.register("", db, common.DatabaseArgs{})
+		unmet, err := exp.Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+	})
+	t.Run("unmet", func(t *testing.T) {
+		exp := ExpectTableRows("", "people", "", []map[string]any{
+			{"id": 1, "name": "Bilbo"},
+			{"id": 2, "name": "Frodo"},
+		})
+
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Bilbo Baggins").
+			AddRow(3, "Samwise"))
+		ctx := newTestContext(nil)
+		ctx.dbs.register("", db, common.DatabaseArgs{})
+		unmet, err := exp.Met(ctx)
+		require.NoError(t, err)
+		require.Error(t, unmet)
+		assert.Equal(t, `expected table "people" rows`, unmet.Error())
+		uerr, ok := unmet.(UnmetError)
+		require.True(t, ok)
+		diff, ok := uerr.Actual().Resolved.(TableDiff)
+		require.True(t, ok)
+		assert.Len(t, diff.Inserted, 1)
+		assert.Len(t, diff.Updated, 1)
+		assert.Equal(t, []string{"name"}, diff.Updated[0].Columns)
+		assert.Len(t, diff.Deleted, 1)
+		assert.Contains(t, uerr.Actual().TestFormat(), "1 inserted, 1 updated, 1 deleted")
+	})
+	t.Run("ignore columns", func(t *testing.T) {
+		exp := ExpectTableRows("", "people", "", []Columns{{"id": 1, "updated": "yesterday"}}, TableRowsOptions{IgnoreColumns: []string{"updated"}})
+
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows([]string{"id", "updated"}).AddRow(1, "today"))
+		ctx := newTestContext(nil)
+		ctx.dbs.register("", db, common.DatabaseArgs{})
+		unmet, err := exp.Met(ctx)
+		require.NoError(t, err)
+		require.NoError(t, unmet)
+	})
+	t.Run("bad expected rows", func(t *testing.T) {
+		exp := ExpectTableRows("", "people", "", "not rows")
+		_, err := exp.Met(newTestContext(nil))
+		require.Error(t, err)
+		assert.Equal(t, "cannot use string as expected rows", err.Error())
+	})
+	t.Run("query error", func(t *testing.T) {
+		exp := ExpectTableRows("", "people", "", nil)
+		_, err := exp.Met(newTestContext(nil))
+		require.Error(t, err)
+	})
+}
+
+func TestExpectDbChanged(t *testing.T) {
+	testCases := []struct {
+		exp         Expectation
+		afterRows   *sqlmock.Rows
+		expectName  string
+		expectUnmet string
+	}{
+		{
+			exp:         ExpectDbChanged("", "people"),
+			afterRows:   sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Bilbo"),
+			expectName:  "Expect Db Changed people",
+			expectUnmet: `expected table "people" to have changed`,
+		},
+		{
+			exp:        ExpectDbChanged("", "people"),
+			afterRows:  sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Bilbo").AddRow(2, "Frodo"),
+			expectName: "Expect Db Changed people",
+		},
+		{
+			exp:        ExpectDbUnchanged("", "people"),
+			afterRows:  sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Bilbo"),
+			expectName: "Expect Db Unchanged people",
+		},
+		{
+			exp:         ExpectDbUnchanged("", "people"),
+			afterRows:   sqlmock.NewRows([]string{"id", "name"}),
+			expectName:  "Expect Db Unchanged people",
+			expectUnmet: `expected table "people" to be unchanged`,
+		},
+		{
+			exp:        ExpectDbUnchanged("", "people", TableRowsOptions{IgnoreColumns: []string{"name"}}),
+			afterRows:  sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Bilbo Baggins"),
+			expectName: "Expect Db Unchanged people",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.expectName, func(t *testing.T) {
+			assert.Equal(t, tc.expectName, tc.exp.Name(), i)
+			assert.NotNil(t, tc.exp.Frame())
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+			mock.ExpectQuery(`SELECT \* FROM people`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Bilbo"))
+			mock.ExpectQuery(`SELECT \* FROM people`).WillReturnRows(tc.afterRows)
+			ctx := newTestContext(nil)
+			ctx.dbs.register("", db, common.DatabaseArgs{})
+
+			be, ok := tc.exp.(beforeExpectation)
+			require.True(t, ok)
+			c := &expectationBefore{exp: be}
+			assert.Equal(t, "BEFORE "+tc.expectName, c.Name())
+			assert.NotNil(t, c.Frame())
+			err = c.Run(ctx)
+			require.NoError(t, err)
+
+			unmet, err := tc.exp.Met(ctx)
+			require.NoError(t, err)
+			if tc.expectUnmet != "" {
+				require.Error(t, unmet)
+				assert.Equal(t, tc.expectUnmet, unmet.Error())
+			} else {
+				require.NoError(t, unmet)
+			}
+		})
+	}
+}
+
+func TestDiffTableRows(t *testing.T) {
+	t.Run("by id", func(t *testing.T) {
+		diff, err := diffTableRows(
+			[]map[string]any{{"id": 1, "name": "a"}, {"id": 2, "name": "b"}},
+			[]map[string]any{{"id": 2, "name": "b"}, {"id": 1, "name": "a"}},
+			TableRowsOptions{})
+		require.NoError(t, err)
+		assert.True(t, diff.IsEmpty())
+	})
+	t.Run("by key columns", func(t *testing.T) {
+		diff, err := diffTableRows(
+			[]map[string]any{{"k1": 1, "k2": "x", "v": 1}, {"k1": 1, "k2": "y", "v": 2}},
+			[]map[string]any{{"k1": 1, "k2": "y", "v": 3}, {"k1": 2, "k2": "x", "v": 1}},
+			TableRowsOptions{KeyColumns: []string{"k1", "k2"}})
+		require.NoError(t, err)
+		assert.Len(t, diff.Inserted, 1)
+		assert.Len(t, diff.Updated, 1)
+		assert.Equal(t, []string{"v"}, diff.Updated[0].Columns)
+		assert.Len(t, diff.Deleted, 1)
+	})
+	t.Run("by column values", func(t *testing.T) {
+		diff, err := diffTableRows(
+			[]map[string]any{{"name": "a"}, {"name": "b"}, {"name": "b"}},
+			[]map[string]any{{"name": "b"}, {"name": "c"}, {"name": "b"}, {"name": "a"}, {"name": "d"}},
+			TableRowsOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]any{{"name": "c"}, {"name": "d"}}, diff.Inserted)
+		assert.Len(t, diff.Updated, 0)
+		assert.Len(t, diff.Deleted, 0)
+		diff, err = diffTableRows(
+			[]map[string]any{{"name": "a", "age": 1}, {"name": "b", "age": 2}},
+			[]map[string]any{{"name": "b", "age": 3, "other": "x"}, {"name": "a", "age": 1, "other": "y"}},
+			TableRowsOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]any{{"name": "b", "age": 3, "other": "x"}}, diff.Inserted)
+		assert.Equal(t, []map[string]any{{"name": "b", "age": 2}}, diff.Deleted)
+	})
+	t.Run("duplicate keys", func(t *testing.T) {
+		_, err := diffTableRows(
+			[]map[string]any{{"id": 1, "name": "a"}},
+			[]map[string]any{{"id": 1, "name": "a"}, {"id": 1, "name": "b"}},
+			TableRowsOptions{})
+		require.Error(t, err)
+		assert.Equal(t, "duplicate row key {id: 1}", err.Error())
+		_, err = diffTableRows(
+			[]map[string]any{{"id": 1, "name": "a"}, {"id": 1, "name": "b"}},
+			[]map[string]any{{"id": 1, "name": "a"}},
+			TableRowsOptions{})
+		require.Error(t, err)
+	})
+}
+
+func TestDbValuesEqual(t *testing.T) {
+	now := time.Now()
+	assert.True(t, dbValuesEqual(nil, nil))
+	assert.False(t, dbValuesEqual(nil, 1))
+	assert.False(t, dbValuesEqual(1, nil))
+	assert.True(t, dbValuesEqual(1, int64(1)))
+	assert.True(t, dbValuesEqual("1", 1))
+	assert.False(t, dbValuesEqual(1, 2))
+	assert.True(t, dbValuesEqual(now, now.UTC()))
+	assert.False(t, dbValuesEqual(now, now.Add(time.Second)))
+	assert.True(t, dbValuesEqual([]byte("abc"), []byte("abc")))
+}
@@ -0,0 +1,138 @@
+package marrow
+
+import (
+	"fmt"
+	"github.com/go-andiamo/marrow/framing"
+	"time"
+)
+
+type timeCompareKind int
+
+const (
+	timeWithin timeCompareKind = iota
+	timeBefore
+	timeAfter
+)
+
+type timeCompare struct {
+	value     any
+	reference any
+	tolerance any
+	kind      timeCompareKind
+	frame     *framing.Frame
+	commonExpectation
+}
+
+var _ Expectation = (*timeCompare)(nil)
+
+// ExpectTimeWithin asserts that the time value (or resolved value) is within the tolerance of the reference time (or resolved reference)
+//
+// values can be time.Time or strings (RFC 3339 or HTTP date format)
+//
+// the tolerance (or resolved tolerance) can be a time.Duration or a string (e.g. "5s")
+//
+// example:
+//
+//	ExpectTimeWithin(JsonPath(Body, "createdAt"), Now(), 5*time.Second)
+//
+//go:noinline
+func ExpectTimeWithin(value any, reference any, tolerance any) Expectation {
+	return &timeCompare{
+		value:     value,
+		reference: reference,
+		tolerance: tolerance,
+		kind:      timeWithin,
+		frame:     framing.NewFrame(0),
+	}
+}
+
+// ExpectBefore asserts that the time value (or resolved value) v1 is before v2
+//
+// values can be time.Time or strings (RFC 3339 or HTTP date format)
+//
+//go:noinline
+func ExpectBefore(v1, v2 any) Expectation {
+	return &timeCompare{
+		value:     v1,
+		reference: v2,
+		kind:      timeBefore,
+		frame:     framing.NewFrame(0),
+	}
+}
+
+// ExpectAfter asserts that the time value (or resolved value) v1 is after v2
+//
+// values can be time.Time or strings (RFC 3339 or HTTP date format)
+//
+//go:noinline
+func ExpectAfter(v1, v2 any) Expectation {
+	return &timeCompare{
+		value:     v1,
+		reference: v2,
+		kind:      timeAfter,
+		frame:     framing.NewFrame(0),
+	}
+}
+
+func (e *timeCompare) Name() string {
+	switch e.kind {
+	case timeBefore:
+		return "Expect Before"
+	case timeAfter:
+		return "Expect After"
+	default:
+		return "Expect Time Within"
+	}
+}
+
+func (e *timeCompare) Frame() *framing.Frame {
+	return e.frame
+}
+
+func (e *timeCompare) Met(ctx Context) (unmet error, err error) {
+	ov1 := OperandValue{Original: e.value}
+	ov2 := OperandValue{Original: e.reference}
+	if ov1.Resolved, ov2.Resolved, err = ResolveValues(e.value, e.reference, ctx); err == nil {
+		var t1, t2 time.Time
+		if t1, err = toTime(ov1.Resolved); err != nil {
+			return
+		}
+		if t2, err = toTime(ov2.Resolved); err != nil {
+			return
+		}
+		ov1.Coerced, ov2.Coerced = t1, t2
+		var msg string
+		switch e.kind {
+		case timeBefore:
+			if !t1.Before(t2) {
+				msg = "expected time before"
+			}
+		case timeAfter:
+			if !t1.After(t2) {
+				msg = "expected time after"
+			}
+		default:
+			var tv any
+			if tv, err = ResolveValue(e.tolerance, ctx); err != nil {
+				return
+			}
+			var tolerance time.Duration
+			if tolerance, err = toDuration(tv); err != nil {
+				return
+			}
+			if diff := t1.Sub(t2).Abs(); diff > tolerance.Abs() {
+				msg = fmt.Sprintf("expected time within %s - difference was %s", tolerance.Abs(), diff)
+			}
+		}
+		if msg != "" {
+			unmet = &unmetError{
+				msg:      msg,
+				name:     e.Name(),
+				expected: ov2,
+				actual:   ov1,
+				frame:    e.frame,
+			}
+		}
+	}
+	return
+}
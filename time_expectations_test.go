@@ -0,0 +1,72 @@
+package marrow
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestExpectTimeWithin(t *testing.T) {
+	ref := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	exp := ExpectTimeWithin(Var("t"), ref, "5s")
+	assert.Equal(t, "Expect Time Within", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	assert.False(t, exp.IsRequired())
+
+	ctx := newTestContext(map[Var]any{"t": "2025-01-02T03:04:08Z"})
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	ctx.SetVar("t", "2025-01-02T03:03:55Z")
+	unmet, err = exp.Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected time within 5s - difference was 10s", unmet.Error())
+
+	unmet, err = ExpectTimeWithin(Now(), TimeAdd(Now(), "-1s"), 5*time.Second).Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+
+	_, err = ExpectTimeWithin(ref, ref, "not a duration").Met(ctx)
+	require.Error(t, err)
+	_, err = ExpectTimeWithin(ref, ref, Var("missing")).Met(ctx)
+	require.Error(t, err)
+	_, err = ExpectTimeWithin("not a time", ref, time.Second).Met(ctx)
+	require.Error(t, err)
+	_, err = ExpectTimeWithin(ref, 1, time.Second).Met(ctx)
+	require.Error(t, err)
+	_, err = ExpectTimeWithin(Var("missing"), ref, time.Second).Met(ctx)
+	require.Error(t, err)
+}
+
+func TestExpectBeforeAfter(t *testing.T) {
+	t1 := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	t2 := "Thu, 02 Jan 2025 03:04:06 GMT"
+	ctx := newTestContext(nil)
+
+	exp := ExpectBefore(t1, t2)
+	assert.Equal(t, "Expect Before", exp.Name())
+	assert.NotNil(t, exp.Frame())
+	unmet, err := exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+	unmet, err = ExpectBefore(t2, t1).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected time before", unmet.Error())
+	unmet, err = ExpectBefore(t1, t1).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+
+	exp = ExpectAfter(t2, t1)
+	assert.Equal(t, "Expect After", exp.Name())
+	unmet, err = exp.Met(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unmet)
+	unmet, err = ExpectAfter(t1, t2).Met(ctx)
+	require.NoError(t, err)
+	require.Error(t, unmet)
+	assert.Equal(t, "expected time after", unmet.Error())
+}
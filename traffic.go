@@ -0,0 +1,385 @@
+package marrow
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/go-andiamo/marrow/common"
+	"github.com/go-andiamo/marrow/coverage"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const redactedValue = "[REDACTED]"
+
+// defaultRedactHeaders are the headers whose values are always redacted in recorded traffic
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// redactBodyFields are the names of (form or json) body fields and query params whose values are always redacted in recorded traffic
+// (e.g. OAuth2 token exchange client secrets and tokens)
+var redactBodyFields = map[string]struct{}{
+	"client_secret": {},
+	"access_token":  {},
+	"refresh_token": {},
+	"id_token":      {},
+	"password":      {},
+}
+
+// trafficRecorder records http requests & responses (see with.RecordTraffic) - and writes them as a HAR 1.2 file
+type trafficRecorder struct {
+	redact  map[string]struct{}
+	entries []harEntry
+	mutex   sync.Mutex
+}
+
+func newTrafficRecorder(redactHeaders []string) *trafficRecorder {
+	result := &trafficRecorder{
+		redact:  make(map[string]struct{}, len(defaultRedactHeaders)+len(redactHeaders)),
+		entries: make([]harEntry, 0),
+	}
+	for _, h := range append(slices.Clone(defaultRedactHeaders), redactHeaders...) {
+		result.redact[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	return result
+}
+
+// wrap wraps the http do so that requests & responses are recorded - if the recorder is nil, the http do is returned as is
+func (r *trafficRecorder) wrap(do common.HttpDo) common.HttpDo {
+	if r == nil {
+		return do
+	}
+	return &recordingDo{do: do, recorder: r}
+}
+
+func (r *trafficRecorder) write(path string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	data, err := json.MarshalIndent(harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "marrow", Version: marrowVersion()},
+		Entries: r.entries,
+	}}, "", "  ")
+	if err == nil {
+		if dir := filepath.Dir(path); dir != "" {
+			err = os.MkdirAll(dir, 0o755)
+		}
+		if err == nil {
+			err = os.WriteFile(path, data, 0o644)
+		}
+	}
+	return err
+}
+
+func marrowVersion() string {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range bi.Deps {
+			if dep.Path == "github.com/go-andiamo/marrow" {
+				return dep.Version
+			}
+		}
+	}
+	return "(devel)"
+}
+
+func (r *trafficRecorder) record(req *http.Request, reqBody []byte, res *http.Response, resBody []byte, tt *coverage.TraceTiming, end time.Time, err error) {
+	entry := harEntry{
+		StartedDateTime: tt.Start.Format(time.RFC3339Nano),
+		Time:            millis(end.Sub(tt.Start)),
+		Request:         r.harRequest(req, reqBody),
+		Response:        r.harResponse(res, resBody),
+		Cache:           struct{}{},
+		Timings:         harTimingsFrom(tt, end),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+func (r *trafficRecorder) harRequest(req *http.Request, body []byte) harRequest {
+	u := *req.URL
+	u.RawQuery = string(redactForm([]byte(u.RawQuery)))
+	result := harRequest{
+		Method:      req.Method,
+		Url:         u.String(),
+		HttpVersion: httpVersion(req.Proto),
+		Cookies:     make([]harNameValue, 0),
+		Headers:     r.harHeaders(req.Header),
+		QueryString: make([]harNameValue, 0),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	q := req.URL.Query()
+	for _, k := range slices.Sorted(maps.Keys(q)) {
+		_, redact := redactBodyFields[strings.ToLower(k)]
+		for _, v := range q[k] {
+			if redact {
+				v = redactedValue
+			}
+			result.QueryString = append(result.QueryString, harNameValue{Name: k, Value: v})
+		}
+	}
+	if len(body) > 0 {
+		text, encoding := harText(redactBody(body, req.Header.Get(hdrContentType)))
+		result.PostData = &harPostData{
+			MimeType: req.Header.Get(hdrContentType),
+			Text:     text,
+			Encoding: encoding,
+		}
+	}
+	return result
+}
+
+func (r *trafficRecorder) harResponse(res *http.Response, body []byte) harResponse {
+	if res == nil {
+		return harResponse{
+			Cookies:     make([]harNameValue, 0),
+			Headers:     make([]harNameValue, 0),
+			Content:     harContent{MimeType: "x-unknown"},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+	}
+	text, encoding := harText(redactBody(body, res.Header.Get(hdrContentType)))
+	return harResponse{
+		Status:      res.StatusCode,
+		StatusText:  http.StatusText(res.StatusCode),
+		HttpVersion: httpVersion(res.Proto),
+		Cookies:     make([]harNameValue, 0),
+		Headers:     r.harHeaders(res.Header),
+		Content: harContent{
+			Size:     len(body),
+			MimeType: res.Header.Get(hdrContentType),
+			Text:     text,
+			Encoding: encoding,
+		},
+		RedirectUrl: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+}
+
+func (r *trafficRecorder) harHeaders(hdrs http.Header) []harNameValue {
+	result := make([]harNameValue, 0, len(hdrs))
+	for _, k := range slices.Sorted(maps.Keys(hdrs)) {
+		_, redact := r.redact[http.CanonicalHeaderKey(k)]
+		for _, v := range hdrs[k] {
+			if redact {
+				v = redactedValue
+			}
+			result = append(result, harNameValue{Name: k, Value: v})
+		}
+	}
+	return result
+}
+
+// redactBody redacts the values of known secret fields (see redactBodyFields) in form or json bodies
+func redactBody(body []byte, contentType string) []byte {
+	if strings.Contains(strings.ToLower(contentType), "x-www-form-urlencoded") {
+		return redactForm(body)
+	}
+	var v any
+	if json.Unmarshal(body, &v) == nil && redactJson(v) {
+		if data, err := json.Marshal(v); err == nil {
+			return data
+		}
+	}
+	return body
+}
+
+func redactForm(body []byte) []byte {
+	redacted := false
+	parts := strings.Split(string(body), "&")
+	for i, part := range parts {
+		k, _, _ := strings.Cut(part, "=")
+		if uk, err := url.QueryUnescape(k); err == nil {
+			if _, ok := redactBodyFields[strings.ToLower(uk)]; ok {
+				parts[i] = k + "=" + url.QueryEscape(redactedValue)
+				redacted = true
+			}
+		}
+	}
+	if !redacted {
+		return body
+	}
+	return []byte(strings.Join(parts, "&"))
+}
+
+func redactJson(v any) (redacted bool) {
+	switch vt := v.(type) {
+	case map[string]any:
+		for k, pv := range vt {
+			if _, ok := redactBodyFields[strings.ToLower(k)]; ok {
+				vt[k] = redactedValue
+				redacted = true
+			} else if redactJson(pv) {
+				redacted = true
+			}
+		}
+	case []any:
+		for _, item := range vt {
+			if redactJson(item) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+func harText(body []byte) (text string, encoding string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func httpVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+	return proto
+}
+
+func harTimingsFrom(tt *coverage.TraceTiming, end time.Time) harTimings {
+	result := harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	between := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() {
+			return -1
+		}
+		return millis(to.Sub(from))
+	}
+	result.DNS = between(tt.DNSStart, tt.DNSDone)
+	result.Connect = between(tt.ConnStart, tt.ConnDone)
+	result.SSL = between(tt.TLSStart, tt.TLSDone)
+	sendFrom := tt.Start
+	for _, t := range []time.Time{tt.DNSDone, tt.ConnDone, tt.TLSDone} {
+		if t.After(sendFrom) {
+			sendFrom = t
+		}
+	}
+	result.Send = max(0, between(sendFrom, tt.WroteReq))
+	result.Wait = max(0, between(tt.WroteReq, tt.FirstByte))
+	result.Receive = max(0, between(tt.FirstByte, end))
+	return result
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+type recordingDo struct {
+	do       common.HttpDo
+	recorder *trafficRecorder
+}
+
+func (d *recordingDo) Do(req *http.Request) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	tt := &coverage.TraceTiming{}
+	traced := req.WithContext(httptrace.WithClientTrace(req.Context(), newClientTrace(tt)))
+	tt.Start = time.Now()
+	res, err := d.do.Do(traced)
+	var resBody []byte
+	if err == nil && res.Body != nil {
+		resBody, err = io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		res.Body = io.NopCloser(bytes.NewReader(resBody))
+	}
+	d.recorder.record(req, reqBody, res, resBody, tt, time.Now(), err)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	Url         string         `json:"url"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectUrl string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"_encoding,omitempty"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
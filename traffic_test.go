@@ -0,0 +1,246 @@
+package marrow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/go-andiamo/marrow/with"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTrafficRecorder_Wrap(t *testing.T) {
+	do := &dummyDo{status: http.StatusOK}
+	var r *trafficRecorder
+	assert.Equal(t, do, r.wrap(do))
+	r = newTrafficRecorder(nil)
+	rd, ok := r.wrap(do).(*recordingDo)
+	require.True(t, ok)
+	assert.Equal(t, do, rd.do)
+}
+
+func TestRecordingDo(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Echo", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+	defer svr.Close()
+	r := newTrafficRecorder([]string{"x-echo"})
+	do := r.wrap(http.DefaultClient)
+
+	req, err := http.NewRequest(http.MethodPost, svr.URL+"/foos?b=2&a=1", strings.NewReader(`{"foo":"bar"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer token")
+	res, err := do.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, string(body))
+	assert.Equal(t, "Bearer token", res.Header.Get("X-Echo"))
+
+	require.Len(t, r.entries, 1)
+	entry := r.entries[0]
+	assert.Empty(t, entry.Error)
+	assert.GreaterOrEqual(t, entry.Time, float64(0))
+	assert.Equal(t, http.MethodPost, entry.Request.Method)
+	assert.Equal(t, svr.URL+"/foos?b=2&a=1", entry.Request.Url)
+	assert.Equal(t, "HTTP/1.1", entry.Request.HttpVersion)
+	assert.Equal(t, []harNameValue{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}, entry.Request.QueryString)
+	assert.Contains(t, entry.Request.Headers, harNameValue{Name: "Authorization", Value: redactedValue})
+	assert.Contains(t, entry.Request.Headers, harNameValue{Name: "Content-Type", Value: "application/json"})
+	require.NotNil(t, entry.Request.PostData)
+	assert.Equal(t, "application/json", entry.Request.PostData.MimeType)
+	assert.Equal(t, `{"foo":"bar"}`, entry.Request.PostData.Text)
+	assert.Equal(t, 13, entry.Request.BodySize)
+	assert.Equal(t, http.StatusCreated, entry.Response.Status)
+	assert.Equal(t, "Created", entry.Response.StatusText)
+	assert.Contains(t, entry.Response.Headers, harNameValue{Name: "Set-Cookie", Value: redactedValue})
+	assert.Contains(t, entry.Response.Headers, harNameValue{Name: "X-Echo", Value: redactedValue})
+	assert.Equal(t, "application/json", entry.Response.Content.MimeType)
+	assert.Equal(t, `{"foo":"bar"}`, entry.Response.Content.Text)
+	assert.Equal(t, 13, entry.Response.Content.Size)
+	assert.Equal(t, float64(-1), entry.Timings.Blocked)
+	assert.GreaterOrEqual(t, entry.Timings.Connect, float64(0))
+	assert.Equal(t, float64(-1), entry.Timings.SSL)
+	assert.GreaterOrEqual(t, entry.Timings.Send, float64(0))
+	assert.GreaterOrEqual(t, entry.Timings.Wait, float64(0))
+	assert.GreaterOrEqual(t, entry.Timings.Receive, float64(0))
+}
+
+func TestRecordingDo_BinaryBodies(t *testing.T) {
+	r := newTrafficRecorder(nil)
+	do := r.wrap(&dummyDo{status: http.StatusOK, body: []byte{0xff, 0xfe}})
+	req, err := http.NewRequest(http.MethodPut, "http://localhost/foos", io.NopCloser(bytes.NewReader([]byte{0xff, 0x00})))
+	require.NoError(t, err)
+	assert.Nil(t, req.GetBody)
+	res, err := do.Do(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, []byte{0xff, 0xfe}, body)
+	reqBody, _ := io.ReadAll(req.Body)
+	assert.Equal(t, []byte{0xff, 0x00}, reqBody)
+
+	require.Len(t, r.entries, 1)
+	entry := r.entries[0]
+	require.NotNil(t, entry.Request.PostData)
+	assert.Equal(t, "/wA=", entry.Request.PostData.Text)
+	assert.Equal(t, "base64", entry.Request.PostData.Encoding)
+	assert.Equal(t, "//4=", entry.Response.Content.Text)
+	assert.Equal(t, "base64", entry.Response.Content.Encoding)
+}
+
+func TestRecordingDo_Error(t *testing.T) {
+	r := newTrafficRecorder(nil)
+	do := r.wrap(&dummyDo{err: errors.New("fooey")})
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/foos", nil)
+	require.NoError(t, err)
+	_, err = do.Do(req)
+	require.Error(t, err)
+
+	require.Len(t, r.entries, 1)
+	entry := r.entries[0]
+	assert.Equal(t, "fooey", entry.Error)
+	assert.Nil(t, entry.Request.PostData)
+	assert.Equal(t, 0, entry.Response.Status)
+	assert.Equal(t, "x-unknown", entry.Response.Content.MimeType)
+	assert.Equal(t, -1, entry.Response.BodySize)
+}
+
+func TestRecordingDo_RedactsTokenExchange(t *testing.T) {
+	svr, _ := newTestTokenServer(t, 3600, true)
+	defer svr.Close()
+	r := newTrafficRecorder(nil)
+	ctx := newTestContext(nil)
+	ctx.httpDo = r.wrap(http.DefaultClient)
+	values := url.Values{"grant_type": {"password"}, "username": {"me"}, "password": {"my-password"}, "client_secret": {"my-secret"}}
+	token, err := fetchOAuth2Token(ctx, &oauth2TokenRequest{tokenUrl: svr.URL, clientId: "my-client", clientSecret: "my-secret"}, values)
+	require.NoError(t, err)
+	assert.Equal(t, "password-1", token.AccessToken)
+
+	require.Len(t, r.entries, 1)
+	entry := r.entries[0]
+	require.NotNil(t, entry.Request.PostData)
+	assert.Equal(t, "client_secret=%5BREDACTED%5D&grant_type=password&password=%5BREDACTED%5D&username=me", entry.Request.PostData.Text)
+	assert.NotContains(t, entry.Response.Content.Text, "password-1")
+	assert.NotContains(t, entry.Response.Content.Text, `"refresh"`)
+	body := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(entry.Response.Content.Text), &body))
+	assert.Equal(t, redactedValue, body["access_token"])
+	assert.Equal(t, redactedValue, body["refresh_token"])
+	assert.Equal(t, "Bearer", body["token_type"])
+	data, err := json.Marshal(r.entries)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "my-secret")
+	assert.NotContains(t, string(data), "my-password")
+}
+
+func TestTrafficRecorder_RedactsQueryParams(t *testing.T) {
+	r := newTrafficRecorder(nil)
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/callback?code=1&access_token=tok&Client_Secret=sec", nil)
+	require.NoError(t, err)
+	hr := r.harRequest(req, nil)
+	assert.Equal(t, "http://localhost/callback?code=1&access_token=%5BREDACTED%5D&Client_Secret=%5BREDACTED%5D", hr.Url)
+	assert.Equal(t, []harNameValue{
+		{Name: "Client_Secret", Value: redactedValue},
+		{Name: "access_token", Value: redactedValue},
+		{Name: "code", Value: "1"},
+	}, hr.QueryString)
+	assert.Equal(t, "access_token=tok", strings.Split(req.URL.RawQuery, "&")[1])
+}
+
+func TestRedactBody(t *testing.T) {
+	testCases := []struct {
+		body        string
+		contentType string
+		expect      string
+	}{
+		{`{"foo":"bar"}`, "application/json", `{"foo":"bar"}`},
+		{`{"a": 1,  "b":  [2]}`, "application/json", `{"a": 1,  "b":  [2]}`},
+		{`{"items":[{"Access_Token":"x"}],"id_token":"y"}`, "application/json", `{"id_token":"[REDACTED]","items":[{"Access_Token":"[REDACTED]"}]}`},
+		{`b=1&client_secret=s&a=2`, "application/x-www-form-urlencoded", `b=1&client_secret=%5BREDACTED%5D&a=2`},
+		{`b=1&a=2`, "application/x-www-form-urlencoded", `b=1&a=2`},
+		{`client_secret=s`, "text/plain", `client_secret=s`},
+		{`not json`, "", `not json`},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.expect, string(redactBody([]byte(tc.body), tc.contentType)))
+		})
+	}
+}
+
+func TestTrafficRecorder_Write(t *testing.T) {
+	r := newTrafficRecorder(nil)
+	do := r.wrap(&dummyDo{status: http.StatusOK, body: []byte(`{}`)})
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/foos", nil)
+	require.NoError(t, err)
+	_, err = do.Do(req)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "sub", "traffic.har")
+	err = r.write(path)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	har := map[string]any{}
+	require.NoError(t, json.Unmarshal(data, &har))
+	log := har["log"].(map[string]any)
+	assert.Equal(t, "1.2", log["version"])
+	assert.Equal(t, "marrow", log["creator"].(map[string]any)["name"])
+	assert.Len(t, log["entries"], 1)
+
+	err = r.write(filepath.Join(path, "not-a-dir.har"))
+	require.Error(t, err)
+}
+
+func TestWithRecordTraffic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.har")
+	do := &dummyDo{status: http.StatusOK, body: []byte(`{"foo":"bar"}`)}
+	s := Suite(Endpoint("/foos", "",
+		Method(GET, "").AuthHeader(BearerAuth, "token").AssertOK(),
+		Method(POST, "").RequestBody(JSON{"foo": "bar"}).AssertOK(),
+	)).Init(
+		with.HttpDo(do),
+		with.RecordTraffic(path, "X-Secret"),
+		with.Logging(&nullWriter{}, &nullWriter{}),
+	)
+	raw, ok := s.(*suite)
+	require.True(t, ok)
+	err := s.Run()
+	require.NoError(t, err)
+	assert.Equal(t, path, raw.trafficPath)
+	assert.Equal(t, []string{"X-Secret"}, raw.trafficRedact)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	har := harFile{}
+	require.NoError(t, json.Unmarshal(data, &har))
+	require.Len(t, har.Log.Entries, 2)
+	assert.Equal(t, http.MethodGet, har.Log.Entries[0].Request.Method)
+	assert.Contains(t, har.Log.Entries[0].Request.Headers, harNameValue{Name: "Authorization", Value: redactedValue})
+	assert.Equal(t, http.MethodPost, har.Log.Entries[1].Request.Method)
+	require.NotNil(t, har.Log.Entries[1].Request.PostData)
+	assert.Equal(t, `{"foo":"bar"}`, har.Log.Entries[1].Request.PostData.Text)
+
+	s = Suite().Init(
+		with.RecordTraffic(filepath.Join(path, "not-a-dir.har")),
+		with.Logging(&nullWriter{}, &nullWriter{}),
+	)
+	err = s.Run()
+	require.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package with
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HttpClient initialises a marrow.Suite with a http client built from the options
+//
+// see HttpClientOptions
+func HttpClient(options HttpClientOptions) With {
+	return &httpClient{
+		options: options,
+	}
+}
+
+// RedirectPolicy is the redirect policy used by a http client built with HttpClient
+type RedirectPolicy int
+
+const (
+	RedirectFollow RedirectPolicy = iota // follows redirects (up to the standard limit of 10 redirects)
+	RedirectNone                         // does not follow redirects - the redirect response is the response
+	RedirectMax                          // follows redirects up to HttpClientOptions.MaxRedirects - the last redirect response is the response when the limit is reached
+)
+
+// HttpClientOptions are the options for building the http client used by a marrow.Suite
+//
+// see HttpClient
+type HttpClientOptions struct {
+	// Timeout is the default timeout for each request (zero means no timeout)
+	//
+	// the timeout can be overridden for individual methods using marrow.Method_.Timeout
+	Timeout time.Duration
+	// Redirects is the redirect policy (default RedirectFollow)
+	//
+	// the redirects followed can be examined using marrow.RedirectChain
+	Redirects RedirectPolicy
+	// MaxRedirects is the maximum number of redirects followed when Redirects is RedirectMax (zero follows no redirects, i.e. the same as RedirectNone)
+	MaxRedirects int
+	// RootCAs are PEM encoded CA certificates to be trusted (in addition to the system CA certificates)
+	RootCAs [][]byte
+	// ClientCertificates are the client certificates presented to servers (i.e. for mTLS)
+	ClientCertificates []tls.Certificate
+	// InsecureSkipVerify disables verification of server certificates
+	InsecureSkipVerify bool
+	// DisableHTTP2 disables HTTP/2 (i.e. only HTTP/1.1 is used)
+	DisableHTTP2 bool
+	// Proxy is the url of a proxy to use for all requests
+	//
+	// if empty, the environment proxy settings (i.e. HTTP_PROXY, HTTPS_PROXY and NO_PROXY) are used
+	Proxy string
+}
+
+// Client builds the http client from the options
+func (o HttpClientOptions) Client() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{
+		Certificates:       o.ClientCertificates,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if len(o.RootCAs) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for i, pem := range o.RootCAs {
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("invalid root CA certificate PEM [%d]", i)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+	if o.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	if o.Proxy != "" {
+		proxyUrl, err := url.Parse(o.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+	result := &http.Client{
+		Transport: transport,
+		Timeout:   o.Timeout,
+	}
+	switch o.Redirects {
+	case RedirectFollow:
+	case RedirectNone:
+		result.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	case RedirectMax:
+		maxRedirects := o.MaxRedirects
+		result.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		}
+	default:
+		return nil, errors.New("invalid redirect policy")
+	}
+	return result, nil
+}
+
+type httpClient struct {
+	options HttpClientOptions
+}
+
+var _ With = (*httpClient)(nil)
+
+func (h *httpClient) Init(init SuiteInit) error {
+	client, err := h.options.Client()
+	if err == nil {
+		init.SetHttpDo(client)
+	}
+	return err
+}
+
+func (h *httpClient) Stage() Stage {
+	return Initial
+}
+
+func (h *httpClient) Shutdown() func() {
+	return nil
+}
@@ -0,0 +1,209 @@
+package with
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/go-andiamo/marrow/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHttpClient(t *testing.T) {
+	w := HttpClient(HttpClientOptions{Timeout: time.Second})
+	assert.Equal(t, Initial, w.Stage())
+	assert.Nil(t, w.Shutdown())
+	mock := &mockHttpInit{mockInit: newMockInit()}
+	err := w.Init(mock)
+	require.NoError(t, err)
+	hc, ok := mock.do.(*http.Client)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, hc.Timeout)
+
+	mock = &mockHttpInit{mockInit: newMockInit()}
+	err = HttpClient(HttpClientOptions{RootCAs: [][]byte{[]byte("not a pem")}}).Init(mock)
+	require.Error(t, err)
+	assert.Nil(t, mock.do)
+}
+
+type mockHttpInit struct {
+	*mockInit
+	do common.HttpDo
+}
+
+func (d *mockHttpInit) SetHttpDo(do common.HttpDo) {
+	d.do = do
+}
+
+func TestHttpClientOptions_Client(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		hc, err := HttpClientOptions{}.Client()
+		require.NoError(t, err)
+		assert.Nil(t, hc.CheckRedirect)
+		assert.Equal(t, time.Duration(0), hc.Timeout)
+		tr := hc.Transport.(*http.Transport)
+		assert.True(t, tr.ForceAttemptHTTP2)
+		assert.Nil(t, tr.TLSClientConfig.RootCAs)
+	})
+	t.Run("invalid root CA", func(t *testing.T) {
+		_, err := HttpClientOptions{RootCAs: [][]byte{[]byte("not a pem")}}.Client()
+		require.Error(t, err)
+		assert.Equal(t, "invalid root CA certificate PEM [0]", err.Error())
+	})
+	t.Run("proxy", func(t *testing.T) {
+		hc, err := HttpClientOptions{Proxy: "http://proxy.example.com:3128"}.Client()
+		require.NoError(t, err)
+		req, _ := http.NewRequest(http.MethodGet, "http://api.example.com/foo", nil)
+		pu, err := hc.Transport.(*http.Transport).Proxy(req)
+		require.NoError(t, err)
+		assert.Equal(t, "http://proxy.example.com:3128", pu.String())
+	})
+	t.Run("invalid proxy", func(t *testing.T) {
+		_, err := HttpClientOptions{Proxy: ":not a url"}.Client()
+		require.Error(t, err)
+	})
+	t.Run("invalid redirect policy", func(t *testing.T) {
+		_, err := HttpClientOptions{Redirects: -1}.Client()
+		require.Error(t, err)
+		assert.Equal(t, "invalid redirect policy", err.Error())
+	})
+	t.Run("disable http2", func(t *testing.T) {
+		hc, err := HttpClientOptions{DisableHTTP2: true}.Client()
+		require.NoError(t, err)
+		tr := hc.Transport.(*http.Transport)
+		assert.False(t, tr.ForceAttemptHTTP2)
+		assert.NotNil(t, tr.TLSNextProto)
+		assert.Empty(t, tr.TLSNextProto)
+	})
+}
+
+func TestHttpClientOptions_Redirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		if n > 0 {
+			http.Redirect(w, r, "/?n="+strconv.Itoa(n-1), http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	testCases := []struct {
+		options        HttpClientOptions
+		expectStatus   int
+		expectLocation string
+	}{
+		{
+			options:      HttpClientOptions{},
+			expectStatus: http.StatusOK,
+		},
+		{
+			options:        HttpClientOptions{Redirects: RedirectNone},
+			expectStatus:   http.StatusFound,
+			expectLocation: "/?n=2",
+		},
+		{
+			options:      HttpClientOptions{Redirects: RedirectMax, MaxRedirects: 3},
+			expectStatus: http.StatusOK,
+		},
+		{
+			options:        HttpClientOptions{Redirects: RedirectMax, MaxRedirects: 2},
+			expectStatus:   http.StatusFound,
+			expectLocation: "/?n=0",
+		},
+		{
+			options:        HttpClientOptions{Redirects: RedirectMax},
+			expectStatus:   http.StatusFound,
+			expectLocation: "/?n=2",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			hc, err := tc.options.Client()
+			require.NoError(t, err)
+			res, err := hc.Get(srv.URL + "/?n=3")
+			require.NoError(t, err)
+			_ = res.Body.Close()
+			assert.Equal(t, tc.expectStatus, res.StatusCode)
+			assert.Equal(t, tc.expectLocation, res.Header.Get("Location"))
+		})
+	}
+}
+
+func TestHttpClientOptions_TLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strconv.Itoa(len(r.TLS.PeerCertificates)) + " " + r.Proto))
+	}))
+	srv.EnableHTTP2 = true
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+	rootCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	clientCert := testClientCertificate(t)
+
+	t.Run("unknown authority", func(t *testing.T) {
+		hc, err := HttpClientOptions{ClientCertificates: []tls.Certificate{clientCert}}.Client()
+		require.NoError(t, err)
+		_, err = hc.Get(srv.URL)
+		require.Error(t, err)
+	})
+	t.Run("insecure", func(t *testing.T) {
+		hc, err := HttpClientOptions{ClientCertificates: []tls.Certificate{clientCert}, InsecureSkipVerify: true}.Client()
+		require.NoError(t, err)
+		res, err := hc.Get(srv.URL)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		assert.Equal(t, "1 HTTP/2.0", string(body))
+	})
+	t.Run("root CA + client cert", func(t *testing.T) {
+		hc, err := HttpClientOptions{RootCAs: [][]byte{rootCA}, ClientCertificates: []tls.Certificate{clientCert}}.Client()
+		require.NoError(t, err)
+		res, err := hc.Get(srv.URL)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		assert.Equal(t, "1 HTTP/2.0", string(body))
+	})
+	t.Run("no client cert", func(t *testing.T) {
+		hc, err := HttpClientOptions{RootCAs: [][]byte{rootCA}}.Client()
+		require.NoError(t, err)
+		_, err = hc.Get(srv.URL)
+		require.Error(t, err)
+	})
+	t.Run("http2 disabled", func(t *testing.T) {
+		hc, err := HttpClientOptions{RootCAs: [][]byte{rootCA}, ClientCertificates: []tls.Certificate{clientCert}, DisableHTTP2: true}.Client()
+		require.NoError(t, err)
+		res, err := hc.Get(srv.URL)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		assert.Equal(t, "1 HTTP/1.1", string(body))
+	})
+}
+
+func testClientCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "marrow-test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
@@ -1,5 +1,7 @@
 package with
 
+import "database/sql"
+
 // Image is the interface that describes a running docker image
 type Image interface {
 	Name() string
@@ -15,3 +17,17 @@ type Image interface {
 type ImageResolveEnv interface {
 	ResolveEnv(tokens ...string) (string, bool)
 }
+
+// ImageDatabaseSnapshots is an additional interface that images providing a database (see SuiteInit.AddDb) can implement
+// to support taking and restoring named snapshots of that database
+type ImageDatabaseSnapshots interface {
+	Image
+	// Database returns the *sql.DB that the image added to the suite
+	Database() *sql.DB
+	// TakeSnapshot takes a named snapshot of the current state of the database
+	//
+	// if a snapshot with the same name already exists, it is replaced
+	TakeSnapshot(snapshotName string) error
+	// RestoreSnapshot restores the database to the state of a previously taken named snapshot
+	RestoreSnapshot(snapshotName string) error
+}
@@ -6,21 +6,29 @@ import "github.com/go-andiamo/marrow/mocks/service"
 //
 // Many apis may call other services - MockService can be used to mock the responses as well as
 // assert/require calls were made
-func MockService(name string) With {
+//
+// options can be used to proxy calls to a real service, record them and replay them - e.g.
+//
+//	with.MockService("downstream", service.ReplayFrom(os.DirFS("testdata/cassettes")))
+//
+// see service.Proxy, service.Record and service.ReplayFrom
+func MockService(name string, options ...service.Option) With {
 	return &mockService{
-		name: name,
+		name:    name,
+		options: options,
 	}
 }
 
 type mockService struct {
-	name string
-	svc  service.MockedService
+	name    string
+	options []service.Option
+	svc     service.MockedService
 }
 
 var _ With = (*mockService)(nil)
 
 func (m *mockService) Init(init SuiteInit) (err error) {
-	m.svc = service.NewMockedService(m.name)
+	m.svc = service.NewMockedService(m.name, m.options...)
 	if err = m.svc.Start(); err == nil {
 		init.AddMockService(m.svc)
 	}
@@ -1,9 +1,11 @@
 package with
 
 import (
+	"github.com/go-andiamo/marrow/mocks/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"testing/fstest"
 )
 
 func TestMockService(t *testing.T) {
@@ -23,3 +25,20 @@ func TestMockService(t *testing.T) {
 	require.NotNil(t, w.Shutdown())
 	w.Shutdown()()
 }
+
+func TestMockService_WithOptions(t *testing.T) {
+	w := MockService("foo", service.ReplayFrom(fstest.MapFS{}))
+	mock := newMockInit()
+	err := w.Init(mock)
+	require.Error(t, err)
+	assert.Len(t, mock.services, 0)
+
+	w = MockService("foo", service.ReplayFrom(fstest.MapFS{
+		"foo.json": {Data: []byte(`{"name":"foo","interactions":[]}`)},
+	}))
+	err = w.Init(mock)
+	require.NoError(t, err)
+	assert.Len(t, mock.services, 1)
+	require.NotNil(t, w.Shutdown())
+	w.Shutdown()()
+}
@@ -0,0 +1,356 @@
+package with
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-andiamo/marrow/internal/jwks"
+	"github.com/go-andiamo/marrow/internal/netutil"
+	"github.com/golang-jwt/jwt/v5"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OIDCIssuer initialises a marrow.Suite with a local OIDC issuer stand-in
+//
+// the issuer serves "/.well-known/openid-configuration" and its JWKS (at "/.well-known/jwks.json") - so that the API
+// being tested can be configured to trust tokens minted in tests (see marrow.JwtRS256, marrow.JwtES256 & marrow.JwtEdDSA
+// used with marrow.OIDCIssuerKey)
+//
+// the issuer also serves a token endpoint (at "/token") supporting the "client_credentials", "password" and "refresh_token"
+// grants (see marrow.OAuth2Token) - any client credentials and username/password are accepted, and the issued access tokens
+// are JWTs signed with the issuer key (the "sub" claim being the username or client id)
+//
+// if no keys are provided, the issuer generates an RSA (RS256), an ECDSA P-256 (ES256) and an Ed25519 (EdDSA) key -
+// provided keys must be *rsa.PrivateKey, *ecdsa.PrivateKey (P-256) or ed25519.PrivateKey
+//
+// the issuer is added as a supporting image - and the following values can be resolved (e.g. in ApiImage env):
+//   - "{$name:issuer}" - the issuer url (also used as the "iss" claim)
+//   - "{$name:jwks}" - the JWKS url
+//   - "{$name:discovery}" - the openid-configuration url
+//   - "{$name:token}" - the token endpoint url
+func OIDCIssuer(name string, keys ...crypto.Signer) With {
+	return &oidcIssuer{
+		name:          name,
+		keys:          keys,
+		keyIds:        make(map[string]string),
+		signers:       make(map[string]crypto.Signer),
+		refreshTokens: make(map[string]jwt.MapClaims),
+	}
+}
+
+// ImageJwtIssuer is an additional interface that images can implement to provide keys for signing JWTs
+type ImageJwtIssuer interface {
+	Image
+	// Issuer returns the issuer url (used as the "iss" claim)
+	Issuer() string
+	// SigningKey returns the key id and private key for a JWT signing algorithm (e.g. "RS256", "ES256" or "EdDSA")
+	SigningKey(alg string) (kid string, key crypto.Signer, ok bool)
+}
+
+type oidcIssuer struct {
+	name     string
+	keys     []crypto.Signer
+	keyIds   map[string]string
+	signers  map[string]crypto.Signer
+	jwks     []map[string]any
+	host     string
+	port     int
+	server   *http.Server
+	listener net.Listener
+	// refreshTokens maps issued refresh tokens to the claims of the original access token
+	refreshTokens map[string]jwt.MapClaims
+	mutex         sync.Mutex
+}
+
+var _ With = (*oidcIssuer)(nil)
+var _ ImageJwtIssuer = (*oidcIssuer)(nil)
+var _ ImageResolveEnv = (*oidcIssuer)(nil)
+
+const (
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+	oidcJwksPath      = "/.well-known/jwks.json"
+	oidcTokenPath     = "/token"
+	oidcTokenExpiry   = time.Hour
+)
+
+func (o *oidcIssuer) Init(init SuiteInit) (err error) {
+	if err = o.initKeys(); err == nil {
+		err = o.start()
+	}
+	if err == nil {
+		init.AddSupportingImage(o)
+	} else {
+		err = fmt.Errorf("oidc issuer: %w", err)
+	}
+	return err
+}
+
+func (o *oidcIssuer) Stage() Stage {
+	return Supporting
+}
+
+func (o *oidcIssuer) Shutdown() (fn func()) {
+	if o.server != nil {
+		fn = func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = o.server.Shutdown(ctx)
+		}
+	}
+	return fn
+}
+
+func (o *oidcIssuer) Name() string {
+	return o.name
+}
+
+func (o *oidcIssuer) Host() string {
+	return o.host
+}
+
+func (o *oidcIssuer) Port() string {
+	return strconv.Itoa(o.port)
+}
+
+func (o *oidcIssuer) MappedPort() string {
+	return strconv.Itoa(o.port)
+}
+
+func (o *oidcIssuer) IsDocker() bool {
+	return false
+}
+
+func (o *oidcIssuer) Username() string {
+	return ""
+}
+
+func (o *oidcIssuer) Password() string {
+	return ""
+}
+
+func (o *oidcIssuer) ResolveEnv(tokens ...string) (string, bool) {
+	if len(tokens) > 0 {
+		switch tokens[0] {
+		case "issuer":
+			return o.Issuer(), true
+		case "jwks":
+			return o.Issuer() + oidcJwksPath, true
+		case "discovery":
+			return o.Issuer() + oidcDiscoveryPath, true
+		case "token":
+			return o.Issuer() + oidcTokenPath, true
+		}
+	}
+	return "", false
+}
+
+func (o *oidcIssuer) Issuer() string {
+	return "http://" + o.host + ":" + strconv.Itoa(o.port)
+}
+
+func (o *oidcIssuer) SigningKey(alg string) (kid string, key crypto.Signer, ok bool) {
+	if key, ok = o.signers[alg]; ok {
+		kid = o.keyIds[alg]
+	}
+	return
+}
+
+func (o *oidcIssuer) initKeys() (err error) {
+	if len(o.keys) == 0 {
+		var rsaKey *rsa.PrivateKey
+		var ecKey *ecdsa.PrivateKey
+		var edKey ed25519.PrivateKey
+		if rsaKey, err = rsa.GenerateKey(rand.Reader, 2048); err == nil {
+			if ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err == nil {
+				if _, edKey, err = ed25519.GenerateKey(rand.Reader); err == nil {
+					o.keys = []crypto.Signer{rsaKey, ecKey, edKey}
+				}
+			}
+		}
+	}
+	for i := 0; i < len(o.keys) && err == nil; i++ {
+		var alg string
+		var jwk map[string]any
+		if alg, jwk, err = jwks.PublicJwk(o.keys[i]); err == nil {
+			kid := jwks.Thumbprint(jwk)
+			jwk["kid"] = kid
+			jwk["alg"] = alg
+			jwk["use"] = "sig"
+			o.jwks = append(o.jwks, jwk)
+			if _, exists := o.signers[alg]; !exists {
+				o.signers[alg] = o.keys[i]
+				o.keyIds[alg] = kid
+			}
+		}
+	}
+	return err
+}
+
+func (o *oidcIssuer) start() (err error) {
+	if o.listener, err = net.Listen("tcp", ":0"); err == nil {
+		o.port = o.listener.Addr().(*net.TCPAddr).Port
+		o.host = netutil.LocalIP()
+		mux := http.NewServeMux()
+		mux.HandleFunc(oidcDiscoveryPath, o.serveDiscovery)
+		mux.HandleFunc(oidcJwksPath, o.serveJwks)
+		mux.HandleFunc(oidcTokenPath, o.serveToken)
+		o.server = &http.Server{Handler: mux}
+		go func() {
+			_ = o.server.Serve(o.listener)
+		}()
+	}
+	return err
+}
+
+func (o *oidcIssuer) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	algs := make([]string, 0, len(o.signers))
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		if _, ok := o.signers[alg]; ok {
+			algs = append(algs, alg)
+		}
+	}
+	writeJson(w, http.StatusOK, map[string]any{
+		"issuer":                                o.Issuer(),
+		"jwks_uri":                              o.Issuer() + oidcJwksPath,
+		"token_endpoint":                        o.Issuer() + oidcTokenPath,
+		"grant_types_supported":                 []string{"client_credentials", "password", "refresh_token"},
+		"response_types_supported":              []string{"token", "id_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": algs,
+	})
+}
+
+func (o *oidcIssuer) serveJwks(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, map[string]any{
+		"keys": o.jwks,
+	})
+}
+
+func (o *oidcIssuer) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJson(w, http.StatusMethodNotAllowed, map[string]any{"error": "invalid_request"})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]any{"error": "invalid_request"})
+		return
+	}
+	clientId, _, ok := r.BasicAuth()
+	if ok {
+		// client credentials in basic auth are form url encoded...
+		clientId, _ = url.QueryUnescape(clientId)
+	} else {
+		clientId = r.PostForm.Get("client_id")
+	}
+	if clientId == "" {
+		writeJson(w, http.StatusUnauthorized, map[string]any{"error": "invalid_client"})
+		return
+	}
+	var claims jwt.MapClaims
+	issueRefresh := false
+	switch r.PostForm.Get("grant_type") {
+	case "client_credentials":
+		claims = jwt.MapClaims{"sub": clientId}
+	case "password":
+		if username := r.PostForm.Get("username"); username != "" {
+			claims = jwt.MapClaims{"sub": username}
+			issueRefresh = true
+		} else {
+			writeJson(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+			return
+		}
+	case "refresh_token":
+		o.mutex.Lock()
+		claims, ok = o.refreshTokens[r.PostForm.Get("refresh_token")]
+		delete(o.refreshTokens, r.PostForm.Get("refresh_token"))
+		o.mutex.Unlock()
+		if !ok {
+			writeJson(w, http.StatusBadRequest, map[string]any{"error": "invalid_grant"})
+			return
+		}
+		issueRefresh = true
+	default:
+		writeJson(w, http.StatusBadRequest, map[string]any{"error": "unsupported_grant_type"})
+		return
+	}
+	claims["client_id"] = clientId
+	if scope := r.PostForm.Get("scope"); scope != "" {
+		claims["scope"] = scope
+	}
+	body, err := o.issueToken(claims)
+	if err == nil && issueRefresh {
+		var refresh string
+		if refresh, err = randomToken(); err == nil {
+			o.mutex.Lock()
+			o.refreshTokens[refresh] = claims
+			o.mutex.Unlock()
+			body["refresh_token"] = refresh
+		}
+	}
+	if err != nil {
+		writeJson(w, http.StatusInternalServerError, map[string]any{"error": "server_error", "error_description": err.Error()})
+		return
+	}
+	writeJson(w, http.StatusOK, body)
+}
+
+func (o *oidcIssuer) issueToken(claims jwt.MapClaims) (map[string]any, error) {
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodRS256, jwt.SigningMethodES256, jwt.SigningMethodEdDSA} {
+		if kid, key, ok := o.SigningKey(method.Alg()); ok {
+			now := time.Now()
+			tokenClaims := jwt.MapClaims{
+				"iss": o.Issuer(),
+				"iat": now.Unix(),
+				"exp": now.Add(oidcTokenExpiry).Unix(),
+			}
+			for k, v := range claims {
+				tokenClaims[k] = v
+			}
+			token := jwt.NewWithClaims(method, tokenClaims)
+			token.Header["kid"] = kid
+			signed, err := token.SignedString(key)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{
+				"access_token": signed,
+				"token_type":   "Bearer",
+				"expires_in":   int64(oidcTokenExpiry / time.Second),
+			}, nil
+		}
+	}
+	return nil, errors.New("no signing key")
+}
+
+func randomToken() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return b64(data), nil
+}
+
+func writeJson(w http.ResponseWriter, status int, v any) {
+	data, _ := json.Marshal(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,166 @@
+package with
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestOIDCIssuer(t *testing.T) {
+	w := OIDCIssuer("oidc")
+	require.Equal(t, Supporting, w.Stage())
+	assert.Nil(t, w.Shutdown())
+
+	mock := newMockInit()
+	err := w.Init(mock)
+	require.NoError(t, err)
+	require.NotNil(t, w.Shutdown())
+	defer w.Shutdown()()
+	_, ok := mock.called["AddSupportingImage:oidc"]
+	assert.True(t, ok)
+	img, ok := mock.images["oidc"].(ImageJwtIssuer)
+	require.True(t, ok)
+	assert.Equal(t, "oidc", img.Name())
+	assert.False(t, img.IsDocker())
+	assert.NotEmpty(t, img.Host())
+	assert.NotEmpty(t, img.Port())
+	assert.Equal(t, img.Port(), img.MappedPort())
+	assert.Empty(t, img.Username())
+	assert.Empty(t, img.Password())
+	assert.Equal(t, "http://"+img.Host()+":"+img.Port(), img.Issuer())
+
+	ire, ok := img.(ImageResolveEnv)
+	require.True(t, ok)
+	v, ok := ire.ResolveEnv("issuer")
+	assert.True(t, ok)
+	assert.Equal(t, img.Issuer(), v)
+	v, ok = ire.ResolveEnv("jwks")
+	assert.True(t, ok)
+	assert.Equal(t, img.Issuer()+"/.well-known/jwks.json", v)
+	discovery, ok := ire.ResolveEnv("discovery")
+	assert.True(t, ok)
+	assert.Equal(t, img.Issuer()+"/.well-known/openid-configuration", discovery)
+	_, ok = ire.ResolveEnv("unknown")
+	assert.False(t, ok)
+
+	kids := make([]string, 0)
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		kid, key, ok := img.SigningKey(alg)
+		assert.True(t, ok)
+		assert.NotNil(t, key)
+		assert.NotEmpty(t, kid)
+		kids = append(kids, kid)
+	}
+	_, _, ok = img.SigningKey("HS256")
+	assert.False(t, ok)
+
+	res, err := http.Get(discovery)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	config := map[string]any{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&config))
+	assert.Equal(t, img.Issuer(), config["issuer"])
+	assert.Equal(t, []any{"RS256", "ES256", "EdDSA"}, config["id_token_signing_alg_values_supported"])
+
+	res, err = http.Get(config["jwks_uri"].(string))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	jwks := struct {
+		Keys []map[string]any `json:"keys"`
+	}{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&jwks))
+	require.Len(t, jwks.Keys, 3)
+	for i, k := range jwks.Keys {
+		assert.Equal(t, kids[i], k["kid"])
+		assert.Equal(t, "sig", k["use"])
+	}
+	assert.Equal(t, "RSA", jwks.Keys[0]["kty"])
+	assert.Equal(t, "EC", jwks.Keys[1]["kty"])
+	assert.Equal(t, "OKP", jwks.Keys[2]["kty"])
+}
+
+func TestOIDCIssuer_SuppliedKeys(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	w := OIDCIssuer("oidc", key)
+	mock := newMockInit()
+	err = w.Init(mock)
+	require.NoError(t, err)
+	defer w.Shutdown()()
+	img := mock.images["oidc"].(ImageJwtIssuer)
+	_, sk, ok := img.SigningKey("ES256")
+	assert.True(t, ok)
+	assert.Equal(t, crypto.Signer(key), sk)
+	_, _, ok = img.SigningKey("RS256")
+	assert.False(t, ok)
+
+	key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	w = OIDCIssuer("oidc", key)
+	err = w.Init(newMockInit())
+	require.Error(t, err)
+	assert.Equal(t, "oidc issuer: only P-256 ecdsa keys are supported", err.Error())
+}
+
+func TestOIDCIssuer_Token(t *testing.T) {
+	w := OIDCIssuer("oidc")
+	mock := newMockInit()
+	require.NoError(t, w.Init(mock))
+	defer w.Shutdown()()
+	tokenUrl, ok := mock.images["oidc"].(ImageResolveEnv).ResolveEnv("token")
+	require.True(t, ok)
+
+	post := func(form url.Values, clientId string) (int, map[string]any) {
+		req, err := http.NewRequest(http.MethodPost, tokenUrl, strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if clientId != "" {
+			req.SetBasicAuth(clientId, "secret")
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body := map[string]any{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		return res.StatusCode, body
+	}
+	status, body := post(url.Values{"grant_type": {"client_credentials"}}, "my-client")
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotEmpty(t, body["access_token"])
+	assert.Equal(t, "Bearer", body["token_type"])
+	assert.Equal(t, float64(3600), body["expires_in"])
+	assert.NotContains(t, body, "refresh_token")
+
+	status, body = post(url.Values{"grant_type": {"password"}, "username": {"bilbo"}, "password": {"x"}}, "my-client")
+	assert.Equal(t, http.StatusOK, status)
+	refresh, ok := body["refresh_token"].(string)
+	require.True(t, ok)
+	status, body = post(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}}, "my-client")
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotEmpty(t, body["access_token"])
+	status, body = post(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}}, "my-client")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "invalid_grant", body["error"])
+
+	status, body = post(url.Values{"grant_type": {"password"}}, "my-client")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "invalid_grant", body["error"])
+	status, body = post(url.Values{"grant_type": {"other"}}, "my-client")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "unsupported_grant_type", body["error"])
+	status, body = post(url.Values{"grant_type": {"client_credentials"}}, "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "invalid_client", body["error"])
+	status, body = post(url.Values{"grant_type": {"client_credentials"}, "client_id": {"my-client"}}, "")
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -1,10 +1,12 @@
 package with
 
 import (
+	"crypto/tls"
 	"database/sql"
 	"github.com/go-andiamo/marrow/common"
 	"github.com/go-andiamo/marrow/coverage"
 	"github.com/go-andiamo/marrow/mocks/service"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"io"
 	"net/http"
 	"testing"
@@ -86,10 +88,33 @@ type SuiteInit interface {
 	//
 	// by default, the marrow.Suite will ue http.DefaultClient
 	//
-	// see also HttpDo
+	// see also HttpDo and HttpClient
 	SetHttpDo(do common.HttpDo)
 	// SetTraceTimings sets whether the marrow.Suite should collect trace timings within coverage
 	//
 	// see also TraceTimings
 	SetTraceTimings(collect bool)
+	// AddCodec registers a codec for decoding response bodies of a media type
+	//
+	// see also Codec
+	AddCodec(mediaType string, codec common.Codec)
+	// SetGrpc sets the gRPC target and descriptors used by gRPC method tests
+	//
+	// see also Grpc
+	SetGrpc(target string, descriptors ...protoreflect.FileDescriptor)
+	// SetGrpcTLS sets the TLS config used when dialing a secure gRPC target (e.g. for a private CA, a self-signed
+	// certificate or mTLS client certificates)
+	//
+	// see also GrpcTLS
+	SetGrpcTLS(config *tls.Config)
+	// SetGraphQLSchema sets a GraphQL schema (SDL) reader
+	//
+	// When a GraphQL schema is provided, coverage can report test coverage of GraphQL operations and fields against the schema
+	//
+	// see also GraphQLSchema
+	SetGraphQLSchema(r io.Reader)
+	// SetRecordTraffic sets the marrow.Suite to record all http requests and responses to a HAR file
+	//
+	// see also RecordTraffic
+	SetRecordTraffic(path string, redactHeaders ...string)
 }
@@ -1,9 +1,11 @@
 package with
 
 import (
+	"crypto/tls"
 	"database/sql"
 	"github.com/go-andiamo/marrow/common"
 	"github.com/go-andiamo/marrow/coverage"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"io"
 	"net/http"
 	"os"
@@ -102,6 +104,30 @@ func OAS(r io.Reader) With {
 	})
 }
 
+// GraphQLSchema initialises a marrow.Suite with a GraphQL schema (SDL) reader
+//
+// When a GraphQL schema is provided, coverage can report test coverage of GraphQL operations and fields
+// against the schema (see coverage.Coverage.GraphQLCoverage)
+func GraphQLSchema(r io.Reader) With {
+	return withFn(func(init SuiteInit) {
+		init.SetGraphQLSchema(r)
+	})
+}
+
+// RecordTraffic initialises a marrow.Suite to record all http requests and responses (made by methods, marrow.ApiCall, listeners etc.)
+// to a HAR 1.2 file at the path - the file is written when the suite run finishes
+//
+// the values of "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie" and "X-Api-Key" headers are always redacted -
+// the redactHeaders arg specifies any additional headers to be redacted
+//
+// the values of "client_secret", "access_token", "refresh_token", "id_token" and "password" fields in form and json request
+// and response bodies (e.g. OAuth2 token exchanges) and in request query params are also always redacted - other body content is recorded as is
+func RecordTraffic(path string, redactHeaders ...string) With {
+	return withFn(func(init SuiteInit) {
+		init.SetRecordTraffic(path, redactHeaders...)
+	})
+}
+
 // Repeats initialises a marrow.Suite with a number of repeats to run
 //
 // repeats are run after the main endpoint+method tests - and is useful for gauging response timing stats
@@ -132,6 +158,44 @@ func TraceTimings() With {
 	})
 }
 
+// Codec initialises a marrow.Suite with a codec for decoding (and encoding) bodies of the media type
+//
+// the codec used to decode a response body is selected from the response "Content-Type" - built-in codecs
+// (see common.JsonCodec, common.XmlCodec, common.YamlCodec, common.CsvCodec, common.FormCodec, common.TextCodec & common.BinaryCodec)
+// are already registered for common media types, registering a codec for the same media type overrides the built-in
+//
+// the media type can be a wildcard subtype (e.g. "text/*")
+func Codec(mediaType string, codec common.Codec) With {
+	return withFn(func(init SuiteInit) {
+		init.AddCodec(mediaType, codec)
+	})
+}
+
+// Grpc initialises a marrow.Suite with settings for gRPC method tests (see marrow.GrpcMethod)
+//
+// the target is the address to dial (e.g. "localhost:9090") - if the target is empty, the API host is dialed
+//
+// a secure target (i.e. "https://" prefixed, or an https API host) is dialed using TLS - see GrpcTLS for
+// providing the TLS config
+//
+// the descriptors supply the service and message descriptors - services not found in the descriptors
+// (or in the global proto registry) are resolved using server reflection
+func Grpc(target string, descriptors ...protoreflect.FileDescriptor) With {
+	return withFn(func(init SuiteInit) {
+		init.SetGrpc(target, descriptors...)
+	})
+}
+
+// GrpcTLS initialises a marrow.Suite with the TLS config used when dialing a secure gRPC target (see Grpc)
+//
+// e.g. to trust a private CA or self-signed certificate (RootCAs) or to present mTLS client certificates (Certificates) - if
+// not set, secure targets are dialed using the default TLS config
+func GrpcTLS(config *tls.Config) With {
+	return withFn(func(init SuiteInit) {
+		init.SetGrpcTLS(config)
+	})
+}
+
 // DisableReaperShutdowns initialises a marrow.Suite to disable/enable container auto-shutdowns (RYUK)
 //
 // it sets the os env var "TESTCONTAINERS_RYUK_DISABLED"
@@ -1,6 +1,7 @@
 package with
 
 import (
+	"crypto/tls"
 	"database/sql"
 	"fmt"
 	"github.com/go-andiamo/marrow/common"
@@ -8,6 +9,7 @@ import (
 	"github.com/go-andiamo/marrow/mocks/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"io"
 	"net/http"
 	"os"
@@ -28,6 +30,11 @@ func TestWith_Initials(t *testing.T) {
 		Repeats(0, false),
 		Logging(nil, nil),
 		TraceTimings(),
+		Codec("text/csv", common.CsvCodec),
+		Grpc(""),
+		GrpcTLS(nil),
+		GraphQLSchema(nil),
+		RecordTraffic(""),
 		DisableReaperShutdowns(false),
 		DisableReaperShutdowns(true),
 	}
@@ -44,7 +51,7 @@ func TestWith_Initials(t *testing.T) {
 		})
 	}
 	assert.Len(t, mock.called, len(testCases)-2)
-	assert.Len(t, mock.called, 12)
+	assert.Len(t, mock.called, 17)
 	v, ok := os.LookupEnv("TESTCONTAINERS_RYUK_DISABLED")
 	assert.True(t, ok)
 	assert.Equal(t, "true", v)
@@ -127,3 +134,23 @@ func (d *mockInit) ResolveEnv(v any) (string, error) {
 func (d *mockInit) SetTraceTimings(collect bool) {
 	d.called["SetTraceTimings"] = struct{}{}
 }
+
+func (d *mockInit) AddCodec(mediaType string, codec common.Codec) {
+	d.called["AddCodec:"+mediaType] = struct{}{}
+}
+
+func (d *mockInit) SetGrpc(target string, descriptors ...protoreflect.FileDescriptor) {
+	d.called["SetGrpc"] = struct{}{}
+}
+
+func (d *mockInit) SetGrpcTLS(config *tls.Config) {
+	d.called["SetGrpcTLS"] = struct{}{}
+}
+
+func (d *mockInit) SetGraphQLSchema(r io.Reader) {
+	d.called["SetGraphQLSchema"] = struct{}{}
+}
+
+func (d *mockInit) SetRecordTraffic(path string, redactHeaders ...string) {
+	d.called["SetRecordTraffic"] = struct{}{}
+}